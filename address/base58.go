@@ -49,6 +49,9 @@ var base58Lookup = map[string]int{
 }
 var bigBase = big.NewInt(58)
 
+// decodedBlockSizes maps the length of an encoded block to the number of bytes it holds
+var decodedBlockSizes = map[int]int{2: 1, 3: 2, 5: 3, 6: 4, 7: 5, 9: 6, 10: 7, 11: 8}
+
 func encodeChunk(raw []byte, padding int) (result string) {
 	remainder := new(big.Int)
 	remainder.SetBytes(raw)
@@ -74,7 +77,14 @@ func decodeChunk(encoded string) (result []byte) {
 		bigResult.Add(bigResult, tmp)
 		currentMultiplier.Mul(currentMultiplier, bigBase)
 	}
-	result = bigResult.Bytes()
+	// leading zero bytes of the block are kept
+	result = make([]byte, decodedBlockSizes[len(encoded)])
+	b := bigResult.Bytes()
+	if len(b) > len(result) {
+		result = b
+		return
+	}
+	copy(result[len(result)-len(b):], b)
 	return
 }
 
@@ -1,7 +1,67 @@
 package address
 
+// address prefixes, the Network of an address is its prefix
+//
+// monero/src/cryptonote_config.h CRYPTONOTE_PUBLIC_ADDRESS_BASE58_PREFIX
 const (
-	MainNetwork           = 18
-	MainNetworkSubAddress = 42
-	TestNetwork           = 53
+	MainNetwork                   = 18
+	MainNetworkSubAddress         = 42
+	MainNetworkIntegratedAddress  = 19
+	TestNetwork                   = 53
+	TestNetworkSubAddress         = 63
+	TestNetworkIntegratedAddress  = 54
+	StageNetwork                  = 24
+	StageNetworkSubAddress        = 36
+	StageNetworkIntegratedAddress = 25
 )
+
+// networkPrefixes are the standard, subaddress and integrated address prefixes of each network
+var networkPrefixes = [][3]int{
+	{MainNetwork, MainNetworkSubAddress, MainNetworkIntegratedAddress},
+	{TestNetwork, TestNetworkSubAddress, TestNetworkIntegratedAddress},
+	{StageNetwork, StageNetworkSubAddress, StageNetworkIntegratedAddress},
+}
+
+// networkPrefix returns the prefix of kind, 0 standard, 1 subaddress or 2 integrated, of the network of the address
+// prefix network, network is returned unchanged if it is unknown
+func networkPrefix(network, kind int) (prefix int) {
+	prefix = network
+	for _, prefixes := range networkPrefixes {
+		for _, p := range prefixes {
+			if p == network {
+				prefix = prefixes[kind]
+				return
+			}
+		}
+	}
+	return
+}
+
+// StandardNetwork returns the standard address prefix of the network of the address prefix network
+func StandardNetwork(network int) (prefix int) {
+	prefix = networkPrefix(network, 0)
+	return
+}
+
+// SubAddressNetwork returns the subaddress prefix of the network of the address prefix network
+func SubAddressNetwork(network int) (prefix int) {
+	prefix = networkPrefix(network, 1)
+	return
+}
+
+// IntegratedAddressNetwork returns the integrated address prefix of the network of the address prefix network
+func IntegratedAddressNetwork(network int) (prefix int) {
+	prefix = networkPrefix(network, 2)
+	return
+}
+
+// IsSubAddressNetwork returns true if network is a subaddress prefix
+func IsSubAddressNetwork(network int) (r bool) {
+	r = network == MainNetworkSubAddress || network == TestNetworkSubAddress || network == StageNetworkSubAddress
+	return
+}
+
+// PaymentIDLength is the length of the short payment id of integrated addresses
+const PaymentIDLength = 8
+
+const encryptedPaymentIDTail = 0x8d //ENCRYPTED_PAYMENT_ID_TAIL
@@ -0,0 +1,50 @@
+package address
+
+import (
+	"bytes"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// IntegratedAddress is a standard address with a short payment id, the payment id is encrypted in the transaction
+// extra of transactions paying it
+type IntegratedAddress struct {
+	Network   int
+	Kv        *crypto.PublicKey
+	Ks        *crypto.PublicKey
+	PaymentID [PaymentIDLength]byte
+}
+
+func (a *IntegratedAddress) Base58() (result string) {
+	prefix := []byte{byte(a.Network)}
+	checksum := crypto.GetChecksum(prefix, a.Ks.Bytes(), a.Kv.Bytes(), a.PaymentID[:])
+	result = EncodeMoneroBase58(prefix, a.Ks.Bytes(), a.Kv.Bytes(), a.PaymentID[:], checksum[:])
+	return
+}
+
+func NewIntegratedAddress(address string) (result *IntegratedAddress, err error) {
+	raw := DecodeMoneroBase58(address)
+	if len(raw) != 77 {
+		err = err_msg.LengthError
+		return
+	}
+	checksum := crypto.GetChecksum(raw[:73])
+	if bytes.Compare(checksum[:], raw[73:]) != 0 {
+		err = err_msg.ChecksumError
+		return
+	}
+
+	result = &IntegratedAddress{
+		Network: int(raw[0]),
+		Kv:      crypto.NewPointFromBytes(raw[33:65]),
+		Ks:      crypto.NewPointFromBytes(raw[1:33]),
+	}
+	copy(result.PaymentID[:], raw[65:73])
+	return
+}
+
+// StandardAddress returns the address without its payment id
+func (a *IntegratedAddress) StandardAddress() (result *StandardAddress) {
+	result = &StandardAddress{Network: StandardNetwork(a.Network), Kv: a.Kv, Ks: a.Ks}
+	return
+}
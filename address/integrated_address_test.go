@@ -0,0 +1,74 @@
+package address
+
+import (
+	"errors"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"testing"
+)
+
+func TestIntegratedAddress(t *testing.T) {
+	a := &IntegratedAddress{
+		Network:   MainNetworkIntegratedAddress,
+		Kv:        crypto.NewRandomScalar().MultG(),
+		Ks:        crypto.NewRandomScalar().MultG(),
+		PaymentID: [PaymentIDLength]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	encoded := a.Base58()
+	if len(encoded) != 106 || encoded[0] != '4' {
+		t.Errorf("want a 106 character address starting with 4, got %s", encoded)
+	}
+	decoded, err := NewIntegratedAddress(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Network != a.Network || decoded.Kv.Equal(a.Kv) == 0 || decoded.Ks.Equal(a.Ks) == 0 || decoded.PaymentID != a.PaymentID {
+		t.Errorf("want %s, got %s", encoded, decoded.Base58())
+	}
+	if s := a.StandardAddress(); s.Network != MainNetwork || s.Kv.Equal(a.Kv) == 0 || s.Ks.Equal(a.Ks) == 0 {
+		t.Errorf("standard address does not have the keys of the integrated address")
+	}
+
+	if _, err = NewIntegratedAddress(encoded[:95]); !errors.Is(err, err_msg.LengthError) {
+		t.Errorf("want: %s, got: %s", err_msg.LengthError, err)
+	}
+	corrupted := []byte(encoded)
+	corrupted[50]++
+	if corrupted[50] > 'z' {
+		corrupted[50] = '1'
+	}
+	if _, err = NewIntegratedAddress(string(corrupted)); err == nil {
+		t.Errorf("corrupted address decodes")
+	}
+}
+
+func TestNetworkPrefixes(t *testing.T) {
+	for _, test := range []struct {
+		standard, subaddress, integrated int
+		first                            [3]byte //first character of each address
+	}{
+		{MainNetwork, MainNetworkSubAddress, MainNetworkIntegratedAddress, [3]byte{'4', '8', '4'}},
+		{TestNetwork, TestNetworkSubAddress, TestNetworkIntegratedAddress, [3]byte{'9', 'B', 'A'}},
+		{StageNetwork, StageNetworkSubAddress, StageNetworkIntegratedAddress, [3]byte{'5', '7', '5'}},
+	} {
+		for _, network := range []int{test.standard, test.subaddress, test.integrated} {
+			if StandardNetwork(network) != test.standard || SubAddressNetwork(network) != test.subaddress || IntegratedAddressNetwork(network) != test.integrated {
+				t.Errorf("%d: wrong prefixes of the network", network)
+			}
+		}
+		Kv, Ks := crypto.NewRandomScalar().MultG(), crypto.NewRandomScalar().MultG()
+		encoded := []string{
+			(&StandardAddress{Network: test.standard, Kv: Kv, Ks: Ks}).Base58(),
+			(&Subaddress{Network: test.subaddress, Kvi: Kv, Ksi: Ks}).Base58(),
+			(&IntegratedAddress{Network: test.integrated, Kv: Kv, Ks: Ks}).Base58(),
+		}
+		for n, a := range encoded {
+			if a[0] != test.first[n] {
+				t.Errorf("%d: want an address starting with %c, got %s", test.standard, test.first[n], a)
+			}
+		}
+		if !IsSubAddressNetwork(test.subaddress) || IsSubAddressNetwork(test.standard) {
+			t.Errorf("%d: subaddress prefix not recognized", test.standard)
+		}
+	}
+}
@@ -4,11 +4,18 @@ import (
 	"gomonero/crypto"
 )
 
+const (
+	JamtisAddressIndexLength   = 16 //j is a 128 bit index
+	JamtisAddressTagHintLength = 2  //hint used to recognize tags ciphered with the wallet cipher key
+	JamtisAddressTagLength     = JamtisAddressIndexLength + JamtisAddressTagHintLength
+)
+
 type JamtisAddress struct {
 	Network int
 	K1      *crypto.PublicKey
 	K2      *crypto.PublicKey
 	K3      *crypto.PublicKey
+	Tag     [JamtisAddressTagLength]byte //ciphered address index and hint
 }
 
 //TODO add base58 methods
@@ -49,12 +49,13 @@ func NewStandardAddressFromKeys(ks *crypto.PrivateKey, kv *crypto.PrivateKey, ne
 	return
 }
 
-func (a *StandardAddress) OneTimeAddress() (Ko *crypto.PublicKey, Ke *crypto.PublicKey, err error) {
+// OneTimeAddress returns the one time address and view tag of output outputIndex of a transaction paying a
+func (a *StandardAddress) OneTimeAddress(outputIndex uint64) (Ko *crypto.PublicKey, Ke *crypto.PublicKey, viewTag byte, err error) {
 	//Ko = one time address
 	//Ke = ephemeral key = transaction public key
 	//Kss = Shared Secret
 
-	if a.Network != MainNetworkSubAddress {
+	if IsSubAddressNetwork(a.Network) {
 		err = err_msg.AddressTypeError
 		return
 	}
@@ -65,9 +66,11 @@ func (a *StandardAddress) OneTimeAddress() (Ko *crypto.PublicKey, Ke *crypto.Pub
 	Ke = r.MultG()
 
 	//Kss = Shared Secret = random scalar * public view key * 8
-	Kss := r.MultPoint(a.Kv).MultByCofactor()
+	Kss := crypto.GenerateKeyDerivation(a.Kv, r)
 
-	//Ko = one time address = Hs(Kss) * G + Ks
-	Ko = Kss.HashToScalar().MultG().Add(a.Ks)
+	viewTag = Kss.ViewTag(outputIndex)
+
+	//Ko = one time address = Hs(Kss || varint(outputIndex)) * G + Ks
+	Ko = crypto.DerivePublicKey(Kss, outputIndex, a.Ks)
 	return
 }
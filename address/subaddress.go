@@ -51,12 +51,13 @@ func NewSubaddressFromKeys(ksi *crypto.PrivateKey, kvi *crypto.PrivateKey, netwo
 	return
 }
 
-func (a *Subaddress) OneTimeAddress() (Ko *crypto.PublicKey, Ke *crypto.PublicKey, ok bool) {
-	// Ko = one time address = Hs(Kss) * G + Ksi
+// OneTimeAddress returns the one time address and view tag of output outputIndex of a transaction paying a
+func (a *Subaddress) OneTimeAddress(outputIndex uint64) (Ko *crypto.PublicKey, Ke *crypto.PublicKey, viewTag byte, ok bool) {
+	// Ko = one time address = Hs(Kss || varint(outputIndex)) * G + Ksi
 	// Ke = ephemeral key (Transaction Public Key)
 	// Kss = shared secret
 
-	if a.Network != MainNetworkSubAddress {
+	if !IsSubAddressNetwork(a.Network) {
 		Ko = nil
 		Ke = nil
 		ok = false
@@ -66,14 +67,17 @@ func (a *Subaddress) OneTimeAddress() (Ko *crypto.PublicKey, Ke *crypto.PublicKe
 	r := crypto.NewRandomScalar()
 	// monero later checks r < l, but this is already true here
 
-	// Ke = ephemeral key  = random scalar * public spend key * 8
-	Ke = a.Ksi.ScalarMult(r).MultByCofactor()
+	// Ke = ephemeral key  = random scalar * public spend key
+	Ke = a.Ksi.ScalarMult(r)
 
 	// Kss = shared secret = random scalar * public view key * 8
-	Kss := a.Kvi.ScalarMult(r).MultByCofactor()
+	Kss := crypto.GenerateKeyDerivation(a.Kvi, r)
 
-	// Ko = one time address = Hs(Kss) * G + Ksi
-	Ko = Kss.HashToScalar().MultG().Add(a.Ksi)
+	viewTag = Kss.ViewTag(outputIndex)
 
+	// Ko = one time address = Hs(Kss || varint(outputIndex)) * G + Ksi
+	Ko = crypto.DerivePublicKey(Kss, outputIndex, a.Ksi)
+
+	ok = true
 	return
 }
@@ -0,0 +1,204 @@
+package address
+
+import (
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// Destination is an address paid by a transaction
+type Destination interface {
+	SpendKey() *crypto.PublicKey
+	ViewKey() *crypto.PublicKey
+	IsSubaddress() bool
+}
+
+func (a *StandardAddress) SpendKey() (K *crypto.PublicKey) {
+	K = a.Ks
+	return
+}
+
+func (a *StandardAddress) ViewKey() (K *crypto.PublicKey) {
+	K = a.Kv
+	return
+}
+
+func (a *StandardAddress) IsSubaddress() (r bool) {
+	r = false
+	return
+}
+
+func (a *IntegratedAddress) SpendKey() (K *crypto.PublicKey) {
+	K = a.Ks
+	return
+}
+
+func (a *IntegratedAddress) ViewKey() (K *crypto.PublicKey) {
+	K = a.Kv
+	return
+}
+
+func (a *IntegratedAddress) IsSubaddress() (r bool) {
+	r = false
+	return
+}
+
+func (a *Subaddress) SpendKey() (K *crypto.PublicKey) {
+	K = a.Ksi
+	return
+}
+
+func (a *Subaddress) ViewKey() (K *crypto.PublicKey) {
+	K = a.Kvi
+	return
+}
+
+func (a *Subaddress) IsSubaddress() (r bool) {
+	r = true
+	return
+}
+
+// OutputKeys are the one time address and view tag of a transaction output
+type OutputKeys struct {
+	Ko        *crypto.PublicKey
+	ViewTag   byte
+	AmountKey *crypto.Scalar //Hs(Kss || varint(outputIndex)), used to encrypt the amount
+}
+
+// TxKeys are the public keys of a transaction paying one output to each destination
+type TxKeys struct {
+	R              *crypto.PublicKey   //transaction public key
+	AdditionalKeys []*crypto.PublicKey //additional transaction public keys, one per output, nil if not needed
+	Outputs        []OutputKeys
+	r              *crypto.Scalar   //transaction private key
+	additional     []*crypto.Scalar //additional transaction private keys
+}
+
+// NewTxKeys generates the transaction keys and one time addresses paying destinations, output i pays destinations[i],
+// destinations[change] is the change of the sender with private view key kv, change is -1 for a transaction without
+// change
+//
+// the change is not counted to choose the keys: a transaction paying a single subaddress uses R = r * Ksi, a
+// transaction paying a subaddress and any other address needs a separate key per output, Ri = ri * Ksi for
+// subaddresses and Ri = ri * G otherwise, the sender derives the change from kv * R
+//
+// monero/src/cryptonote_core/cryptonote_tx_utils.cpp construct_tx_with_tx_key
+func NewTxKeys(destinations []Destination, change int, kv *crypto.PrivateKey) (keys *TxKeys, err error) {
+	needAdditionalKeys, _ := classifyDestinations(destinations, change)
+	var additional []*crypto.PrivateKey
+	if needAdditionalKeys {
+		for range destinations {
+			additional = append(additional, crypto.NewRandomScalar())
+		}
+	}
+	keys, err = TxKeysFromPrivateKeys(destinations, change, kv, crypto.NewRandomScalar(), additional)
+	return
+}
+
+// TxKeysFromPrivateKeys returns the keys of NewTxKeys for the transaction private key r and the additional private
+// keys, one per destination if the transaction needs them, the signers of a multisig transaction rebuild its outputs
+// with them
+func TxKeysFromPrivateKeys(destinations []Destination, change int, kv *crypto.PrivateKey, r *crypto.PrivateKey, additional []*crypto.PrivateKey) (keys *TxKeys, err error) {
+	if len(destinations) == 0 {
+		err = err_msg.ErrNoDestinations
+		return
+	}
+	if change < -1 || change >= len(destinations) || (change >= 0 && kv == nil) {
+		err = err_msg.ErrOutOfBounds
+		return
+	}
+	needAdditionalKeys, subaddress := classifyDestinations(destinations, change)
+	if r == nil || (needAdditionalKeys && len(additional) != len(destinations)) || (!needAdditionalKeys && len(additional) > 0) {
+		err = err_msg.ErrTxKey
+		return
+	}
+
+	keys = &TxKeys{r: r}
+	if subaddress != nil {
+		keys.R = subaddress.SpendKey().ScalarMult(r)
+	} else {
+		keys.R = r.MultG()
+	}
+
+	for i, d := range destinations {
+		outputIndex := uint64(i)
+		txKey := r
+		if needAdditionalKeys {
+			ri := additional[i]
+			if ri == nil {
+				keys, err = nil, err_msg.ErrTxKey
+				return
+			}
+			keys.additional = append(keys.additional, ri)
+			if d.IsSubaddress() {
+				keys.AdditionalKeys = append(keys.AdditionalKeys, d.SpendKey().ScalarMult(ri))
+				// standard addresses are still paid with the transaction key
+				txKey = ri
+			} else {
+				keys.AdditionalKeys = append(keys.AdditionalKeys, ri.MultG())
+			}
+		}
+
+		// Kss = shared secret = random scalar * public view key * 8
+		var Kss *crypto.Point
+		if i == change {
+			// the sender knows kv and pays the change to kv * R
+			Kss = crypto.GenerateKeyDerivation(keys.R, kv)
+		} else {
+			Kss = crypto.GenerateKeyDerivation(d.ViewKey(), txKey)
+		}
+		keys.Outputs = append(keys.Outputs, OutputKeys{
+			Ko:        crypto.DerivePublicKey(Kss, outputIndex, d.SpendKey()),
+			ViewTag:   Kss.ViewTag(outputIndex),
+			AmountKey: Kss.DerivationToScalar(outputIndex),
+		})
+	}
+	return
+}
+
+// classifyDestinations returns whether a transaction paying destinations needs additional keys, and the subaddress
+// it pays if it pays a single subaddress and no standard address, the change is not counted
+//
+// monero/src/cryptonote_basic/cryptonote_format_utils.cpp classify_addresses
+func classifyDestinations(destinations []Destination, change int) (needAdditionalKeys bool, subaddress Destination) {
+	var subaddresses, standardAddresses int
+	for i, d := range destinations {
+		if i == change {
+			continue
+		}
+		if d.IsSubaddress() {
+			subaddresses++
+			subaddress = d
+		} else {
+			standardAddresses++
+		}
+	}
+	needAdditionalKeys = subaddresses > 0 && (standardAddresses > 0 || subaddresses > 1)
+	if subaddresses != 1 || standardAddresses != 0 {
+		subaddress = nil
+	}
+	return
+}
+
+// PrivateKeys returns the transaction private key and the additional private keys, the sender keeps them to prove
+// the payments of the transaction
+func (keys *TxKeys) PrivateKeys() (r *crypto.PrivateKey, additional []*crypto.PrivateKey) {
+	r, additional = keys.r, keys.additional
+	return
+}
+
+// EncryptPaymentID encrypts the payment id of an integrated address with view key Kv for the transaction extra
+func (keys *TxKeys) EncryptPaymentID(Kv *crypto.PublicKey, paymentID [PaymentIDLength]byte) (r [PaymentIDLength]byte) {
+	r = EncryptPaymentID(crypto.GenerateKeyDerivation(Kv, keys.r), paymentID)
+	return
+}
+
+// EncryptPaymentID xors paymentID with H(Kss || 0x8d), it also decrypts
+//
+// monero/src/device/device_default.cpp encrypt_payment_id
+func EncryptPaymentID(Kss *crypto.Point, paymentID [PaymentIDLength]byte) (r [PaymentIDLength]byte) {
+	h := crypto.Keccak256(Kss.Bytes(), []byte{encryptedPaymentIDTail})
+	for n := range paymentID {
+		r[n] = paymentID[n] ^ h[n]
+	}
+	return
+}
@@ -136,7 +136,9 @@ func (P *Point) fromFEBytes(s []byte) (R *Point) {
 }
 
 func feDivPowM1(out, u, v *field.Element) {
-	var v3, uv7, t0 *field.Element
+	v3 := new(field.Element)
+	uv7 := new(field.Element)
+	t0 := new(field.Element)
 
 	//FeSquare(&v3, v)
 	v3.Square(v)
@@ -0,0 +1,386 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+	"math/bits"
+	"sync"
+)
+
+const (
+	BulletproofPlusBits      = 64 //each value is proven to be in [0, 2^64)
+	BulletproofPlusMaxValues = 16 //BULLETPROOF_PLUS_MAX_OUTPUTS
+)
+
+// BulletproofPlus is an aggregated range proof that the values of commitments V = gamma G + v H are 64 bit
+// integers, the points are stored multiplied by 1/8
+//
+// monero/src/ringct/bulletproofs_plus.cc, following the weighted inner product argument of
+// https://eprint.iacr.org/2020/735
+type BulletproofPlus struct {
+	A  *Point
+	A1 *Point
+	B  *Point
+	R1 *Scalar
+	S1 *Scalar
+	D1 *Scalar
+	L  []*Point
+	R  []*Point
+}
+
+var bulletproofPlusGenerators struct {
+	mu     sync.Mutex
+	Gi, Hi []*Point
+}
+
+// bppGenerators returns the first count vector generators, Hi[i] = get_exponent(H, 2i) and
+// Gi[i] = get_exponent(H, 2i + 1)
+//
+// monero/src/ringct/bulletproofs_plus.cc init_exponents
+func bppGenerators(count int) (Gi, Hi []*Point) {
+	g := &bulletproofPlusGenerators
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := len(g.Gi); i < count; i++ {
+		g.Hi = append(g.Hi, bppExponent(uint64(2*i)))
+		g.Gi = append(g.Gi, bppExponent(uint64(2*i+1)))
+	}
+	Gi, Hi = g.Gi[:count], g.Hi[:count]
+	return
+}
+
+func bppExponent(index uint64) (R *Point) {
+	h := Keccak256(PointH().Bytes(), []byte("bulletproof_plus"), varint(index))
+	h = Keccak256(h[:])
+	R = new(Point).fromFEBytes(h[:]).MultByCofactor()
+	return
+}
+
+// bppPaddedSize returns the number of values rounded up to a power of 2
+func bppPaddedSize(n int) (M int) {
+	M = 1
+	for M < n {
+		M *= 2
+	}
+	return
+}
+
+// weightedInnerProduct returns sum a[i] b[i] y^(i+1)
+func weightedInnerProduct(a, b []*Scalar, y *Scalar) (r *Scalar) {
+	r = ScalarZero()
+	yi := ScalarIdentity()
+	for i := range a {
+		yi = yi.Multiply(y)
+		r = a[i].Multiply(b[i]).MultiplyAdd(yi, r)
+	}
+	return
+}
+
+// bppInitialTranscript returns hash_to_p3(Keccak("bulletproof_plus_transcript"))
+//
+// monero/src/ringct/bulletproofs_plus.cc get_initial_transcript
+func bppInitialTranscript() (t *Point) {
+	h := Keccak256([]byte("bulletproof_plus_transcript"))
+	h = Keccak256(h[:])
+	t = new(Point).fromFEBytes(h[:]).MultByCofactor()
+	return
+}
+
+// bppTranscript returns the transcript updated with the commitments, Hs(initial transcript || Hs(V / 8)) as the proofs
+// store the commitments multiplied by 1/8
+func bppTranscript(V []*Point) (t *Scalar) {
+	inv8 := invEight()
+	var data [][]byte
+	for _, P := range V {
+		data = append(data, P.ScalarMult(inv8).Bytes())
+	}
+	t = HashToScalar(bppInitialTranscript().Bytes(), HashToScalar(data...).Bytes())
+	return
+}
+
+func bppChallenge(t *Scalar, P ...*Point) (e *Scalar) {
+	data := [][]byte{t.Bytes()}
+	for _, Q := range P {
+		data = append(data, Q.Bytes())
+	}
+	e = HashToScalar(data...)
+	return
+}
+
+// NewBulletproofPlus proves that values are 64 bit integers, it returns the proof and the commitments
+// V[j] = blinds[j] G + values[j] H it is verified against
+func NewBulletproofPlus(values []uint64, blinds []*Scalar) (proof *BulletproofPlus, V []*Point, err error) {
+	if len(values) != len(blinds) {
+		err = err_msg.ErrMismatchedLengths
+		return
+	}
+	if len(values) == 0 || len(values) > BulletproofPlusMaxValues {
+		err = err_msg.ErrBulletproofPlusSize
+		return
+	}
+	M := bppPaddedSize(len(values))
+	N := M * BulletproofPlusBits
+	Gi, Hi := bppGenerators(N)
+	inv8 := invEight()
+
+	V = make([]*Point, len(values))
+	for j := range values {
+		V[j] = blinds[j].DoubleScalarBaseMult(amountScalar(values[j]), PointH())
+	}
+	t := bppTranscript(V)
+
+	// aL are the bits of the values, aR = aL - 1
+	aL, aR := make([]*Scalar, N), make([]*Scalar, N)
+	for j := 0; j < M; j++ {
+		var v uint64
+		if j < len(values) {
+			v = values[j]
+		}
+		for i := 0; i < BulletproofPlusBits; i++ {
+			aL[j*BulletproofPlusBits+i] = ScalarZero()
+			if v>>i&1 == 1 {
+				aL[j*BulletproofPlusBits+i] = ScalarIdentity()
+			}
+			aR[j*BulletproofPlusBits+i] = aL[j*BulletproofPlusBits+i].Subtract(ScalarIdentity())
+		}
+	}
+
+	//A = aL Gi + aR Hi + alpha G
+	alpha := NewRandomScalar()
+	terms := newMSMBuilder()
+	for i := 0; i < N; i++ {
+		terms.add(aL[i], Gi[i])
+		terms.add(aR[i], Hi[i])
+	}
+	terms.add(alpha, PointG())
+	proof = new(BulletproofPlus)
+	proof.A = terms.secretSum().ScalarMult(inv8)
+
+	y := bppChallenge(t, proof.A)
+	z := HashToScalar(y.Bytes())
+	t = z
+	yPowers := y.PowersOfScalar(N + 2).slice
+	z2 := z.Multiply(z)
+
+	// aL^ = aL - z, aR^ = aR + d y^(N-i) + z with d[j n + i] = z^(2(j+1)) 2^i
+	// alpha^ = alpha + y^(N+1) sum z^(2(j+1)) gamma[j]
+	a, b := make([]*Scalar, N), make([]*Scalar, N)
+	zj := z2
+	for j := 0; j < M; j++ {
+		twoI := ScalarIdentity()
+		for i := 0; i < BulletproofPlusBits; i++ {
+			k := j*BulletproofPlusBits + i
+			a[k] = aL[k].Subtract(z)
+			b[k] = zj.Multiply(twoI).MultiplyAdd(yPowers[N-k], aR[k].Add(z))
+			twoI = twoI.Add(twoI)
+		}
+		if j < len(blinds) {
+			alpha = zj.Multiply(yPowers[N+1]).MultiplyAdd(blinds[j], alpha)
+		}
+		zj = zj.Multiply(z2)
+	}
+
+	// weighted inner product argument
+	G := append([]*Point(nil), Gi...)
+	H := append([]*Point(nil), Hi...)
+	yInv := y.Invert()
+	for n := N; n > 1; n /= 2 {
+		n2 := n / 2
+		a1, a2, b1, b2 := a[:n2], a[n2:n], b[:n2], b[n2:n]
+		G1, G2, H1, H2 := G[:n2], G[n2:n], H[:n2], H[n2:n]
+		yN2 := yPowers[n2]
+		yInvN2 := yInv.PowersOfScalar(n2 + 1).slice[n2]
+
+		cL := weightedInnerProduct(a1, b2, y)
+		a2y := make([]*Scalar, n2)
+		for i := range a2 {
+			a2y[i] = a2[i].Multiply(yN2)
+		}
+		cR := weightedInnerProduct(a2y, b1, y)
+		dL, dR := NewRandomScalar(), NewRandomScalar()
+
+		//L = y^-n2 a1 G2 + b2 H1 + cL H + dL G
+		//R = y^n2 a2 G1 + b1 H2 + cR H + dR G
+		Lterms, Rterms := newMSMBuilder(), newMSMBuilder()
+		for i := 0; i < n2; i++ {
+			Lterms.add(a1[i].Multiply(yInvN2), G2[i])
+			Lterms.add(b2[i], H1[i])
+			Rterms.add(a2y[i], G1[i])
+			Rterms.add(b1[i], H2[i])
+		}
+		Lterms.add(cL, PointH())
+		Lterms.add(dL, PointG())
+		Rterms.add(cR, PointH())
+		Rterms.add(dR, PointG())
+		L := Lterms.secretSum().ScalarMult(inv8)
+		R := Rterms.secretSum().ScalarMult(inv8)
+		proof.L = append(proof.L, L)
+		proof.R = append(proof.R, R)
+
+		e := bppChallenge(t, L, R)
+		t = e
+		eInv := e.Invert()
+		e2, eInv2 := e.Multiply(e), eInv.Multiply(eInv)
+
+		for i := 0; i < n2; i++ {
+			G[i] = G1[i].ScalarMult(eInv).Add(G2[i].ScalarMult(e.Multiply(yInvN2)))
+			H[i] = H1[i].ScalarMult(e).Add(H2[i].ScalarMult(eInv))
+			a[i] = a1[i].Multiply(e).Add(a2y[i].Multiply(eInv))
+			b[i] = b1[i].Multiply(eInv).Add(b2[i].Multiply(e))
+		}
+		alpha = dL.Multiply(e2).Add(alpha).Add(dR.Multiply(eInv2))
+	}
+
+	//A1 = r G' + s H' + (r y b + s y a) H + delta G, B = r y s H + eta G
+	r, s, delta, eta := NewRandomScalar(), NewRandomScalar(), NewRandomScalar(), NewRandomScalar()
+	ry := r.Multiply(y)
+	A1terms := newMSMBuilder()
+	A1terms.add(r, G[0])
+	A1terms.add(s, H[0])
+	A1terms.add(ry.Multiply(b[0]).Add(s.Multiply(y).Multiply(a[0])), PointH())
+	A1terms.add(delta, PointG())
+	proof.A1 = A1terms.secretSum().ScalarMult(inv8)
+	proof.B = ry.Multiply(s).MultH().Add(eta.MultG()).ScalarMult(inv8)
+
+	e := bppChallenge(t, proof.A1, proof.B)
+	proof.R1 = a[0].MultiplyAdd(e, r)
+	proof.S1 = b[0].MultiplyAdd(e, s)
+	proof.D1 = alpha.Multiply(e).Multiply(e).Add(delta.MultiplyAdd(e, eta))
+	return
+}
+
+func amountScalar(v uint64) (r *Scalar) {
+	var a [8]byte
+	for i := range a {
+		a[i] = byte(v >> (8 * i))
+	}
+	r = NewScalarFromAmount(a)
+	return
+}
+
+// Verify returns true if the proof shows the values of V are 64 bit integers
+func (proof *BulletproofPlus) Verify(V []*Point) (r bool) {
+	r = VerifyBulletproofsPlus([]*BulletproofPlus{proof}, [][]*Point{V})
+	return
+}
+
+// VerifyBulletproofsPlus verifies the proofs against the commitments at the same index with a single multi-scalar
+// multiplication, the checks of each proof are weighted with a random scalar
+func VerifyBulletproofsPlus(proofs []*BulletproofPlus, V [][]*Point) (r bool) {
+	if len(proofs) == 0 || len(proofs) != len(V) {
+		return
+	}
+	maxN := 0
+	for p := range proofs {
+		if len(V[p]) == 0 || len(V[p]) > BulletproofPlusMaxValues {
+			return
+		}
+		if N := bppPaddedSize(len(V[p])) * BulletproofPlusBits; N > maxN {
+			maxN = N
+		}
+	}
+	Gi, Hi := bppGenerators(maxN)
+	giWeights, hiWeights := make([]*Scalar, maxN), make([]*Scalar, maxN)
+	for i := range giWeights {
+		giWeights[i], hiWeights[i] = ScalarZero(), ScalarZero()
+	}
+	gWeight, hWeight := ScalarZero(), ScalarZero()
+	terms := newMSMBuilder()
+
+	for p, proof := range proofs {
+		M := bppPaddedSize(len(V[p]))
+		N := M * BulletproofPlusBits
+		rounds := bits.Len(uint(N)) - 1
+		if len(proof.L) != rounds || len(proof.R) != rounds {
+			return
+		}
+
+		t := bppTranscript(V[p])
+		y := bppChallenge(t, proof.A)
+		z := HashToScalar(y.Bytes())
+		t = z
+		challenges := make([]*Scalar, rounds)
+		for k := 0; k < rounds; k++ {
+			challenges[k] = bppChallenge(t, proof.L[k], proof.R[k])
+			t = challenges[k]
+		}
+		e := bppChallenge(t, proof.A1, proof.B)
+		w := NewRandomScalar()
+		e2 := e.Multiply(e)
+		we2 := w.Multiply(e2)
+
+		yPowers := y.PowersOfScalar(N + 2).slice
+		yInv := y.Invert()
+		z2 := z.Multiply(z)
+
+		// scalars of the folded generators G' = sum gi Gi and H' = sum hi Hi
+		eInvs := NewScalarSliceFrom(challenges...).Invert().slice
+		gi, hi := make([]*Scalar, N), make([]*Scalar, N)
+		for i := 0; i < N; i++ {
+			gi[i], hi[i] = ScalarIdentity(), ScalarIdentity()
+		}
+		for k := 0; k < rounds; k++ {
+			n2 := N >> (k + 1)
+			yInvN2 := yInv.PowersOfScalar(n2 + 1).slice[n2]
+			secondG := challenges[k].Multiply(yInvN2)
+			for i := 0; i < N; i++ {
+				if i>>(rounds-1-k)&1 == 0 {
+					gi[i] = gi[i].Multiply(eInvs[k])
+					hi[i] = hi[i].Multiply(challenges[k])
+				} else {
+					gi[i] = gi[i].Multiply(secondG)
+					hi[i] = hi[i].Multiply(eInvs[k])
+				}
+			}
+		}
+
+		//w (e^2 P^ + e A1 + B - r1 e G' - s1 e H' - r1 y s1 H - d1 G) = 0
+		//P^ = A - z Gi + (d y^(N-i) + z) Hi + y^(N+1) z^(2(j+1)) V[j] + c H + e_k^2 L_k + e_k^-2 R_k
+		terms.add(we2, proof.A.MultByCofactor())
+		terms.add(w.Multiply(e), proof.A1.MultByCofactor())
+		terms.add(w, proof.B.MultByCofactor())
+		for k := 0; k < rounds; k++ {
+			terms.add(we2.Multiply(challenges[k]).Multiply(challenges[k]), proof.L[k].MultByCofactor())
+			terms.add(we2.Multiply(eInvs[k]).Multiply(eInvs[k]), proof.R[k].MultByCofactor())
+		}
+
+		r1e, s1e := proof.R1.Multiply(e), proof.S1.Multiply(e)
+		sumD := ScalarZero()
+		zj := z2
+		for j := 0; j < M; j++ {
+			if j < len(V[p]) {
+				terms.add(we2.Multiply(yPowers[N+1]).Multiply(zj), V[p][j])
+			}
+			twoI := ScalarIdentity()
+			for i := 0; i < BulletproofPlusBits; i++ {
+				k := j*BulletproofPlusBits + i
+				d := zj.Multiply(twoI)
+				sumD = sumD.Add(d)
+				// Gi: -e^2 z - r1 e gi, Hi: e^2 (d y^(N-k) + z) - s1 e hi
+				gk := we2.Multiply(z).Add(w.Multiply(r1e).Multiply(gi[k])).Negate()
+				hk := we2.Multiply(d.MultiplyAdd(yPowers[N-k], z)).Subtract(w.Multiply(s1e).Multiply(hi[k]))
+				giWeights[k] = giWeights[k].Add(gk)
+				hiWeights[k] = hiWeights[k].Add(hk)
+				twoI = twoI.Add(twoI)
+			}
+			zj = zj.Multiply(z2)
+		}
+
+		//c = (z - z^2) sum y^i - z y^(N+1) sum d
+		sumY := ScalarZero()
+		for i := 1; i <= N; i++ {
+			sumY = sumY.Add(yPowers[i])
+		}
+		c := z.Subtract(z2).Multiply(sumY).Subtract(z.Multiply(yPowers[N+1]).Multiply(sumD))
+		hWeight = hWeight.Add(we2.Multiply(c)).Subtract(w.Multiply(proof.R1.Multiply(y).Multiply(proof.S1)))
+		gWeight = gWeight.Subtract(w.Multiply(proof.D1))
+	}
+
+	terms.add(gWeight, PointG())
+	terms.add(hWeight, PointH())
+	for i := 0; i < maxN; i++ {
+		terms.add(giWeights[i], Gi[i])
+		terms.add(hiWeights[i], Hi[i])
+	}
+	r = terms.isIdentity()
+	return
+}
@@ -0,0 +1,76 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+	"math"
+	"testing"
+)
+
+func TestBulletproofPlus(t *testing.T) {
+	for _, values := range [][]uint64{{0}, {math.MaxUint64}, {1, 2, 3}, {7, 1 << 40, 0, 5, 9}} {
+		blinds := RandomScalars(len(values)).slice
+		proof, V, err := NewBulletproofPlus(values, blinds)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !proof.Verify(V) {
+			t.Errorf("%v: valid proof does not verify", values)
+		}
+		V[0] = V[0].Add(PointH())
+		if proof.Verify(V) {
+			t.Errorf("%v: proof verifies for another commitment", values)
+		}
+	}
+}
+
+func TestBulletproofPlusInvalid(t *testing.T) {
+	// a commitment to -1 is a commitment to a value outside the range
+	blind := NewRandomScalar()
+	proof, _, err := NewBulletproofPlus([]uint64{1}, []*Scalar{blind})
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	negative := blind.MultG().Subtract(PointH())
+	if proof.Verify([]*Point{negative}) {
+		t.Errorf("proof verifies for a negative value")
+	}
+	proof, V, _ := NewBulletproofPlus([]uint64{1, 2}, RandomScalars(2).slice)
+	proof.R1 = proof.R1.Add(ScalarIdentity())
+	if proof.Verify(V) {
+		t.Errorf("modified proof verifies")
+	}
+	if _, _, err = NewBulletproofPlus(make([]uint64, 17), RandomScalars(17).slice); err != err_msg.ErrBulletproofPlusSize {
+		t.Errorf("want %v, got %v", err_msg.ErrBulletproofPlusSize, err)
+	}
+}
+
+func TestVerifyBulletproofsPlus(t *testing.T) {
+	var proofs []*BulletproofPlus
+	var V [][]*Point
+	for n := 1; n <= 3; n++ {
+		values := make([]uint64, n)
+		for i := range values {
+			values[i] = uint64(1000 * (i + 1))
+		}
+		proof, Vn, err := NewBulletproofPlus(values, RandomScalars(n).slice)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		proofs, V = append(proofs, proof), append(V, Vn)
+	}
+	if !VerifyBulletproofsPlus(proofs, V) {
+		t.Errorf("valid batch does not verify")
+	}
+	V[1][0] = V[2][0]
+	if VerifyBulletproofsPlus(proofs, V) {
+		t.Errorf("batch with an invalid proof verifies")
+	}
+}
+
+func BenchmarkBulletproofPlusVerify(b *testing.B) {
+	proof, V, _ := NewBulletproofPlus([]uint64{1, 2}, RandomScalars(2).slice)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		proof.Verify(V)
+	}
+}
@@ -0,0 +1,114 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+)
+
+// CLSAG is a concise linkable spontaneous anonymous group signature, it proves that the signer knows the private key
+// p of one member P[l] of a ring and z with C[l] - C_offset = z G, the key image I = p Hp(P[l]) links signatures
+// spending the same output
+//
+// monero/src/ringct/rctSigs.cpp CLSAG_Gen, verRctCLSAGSimple
+type CLSAG struct {
+	S  []*Scalar //responses, one per ring member
+	C1 *Scalar   //challenge of the first ring member
+	D  *Point    //(1/8) * commitment key image z Hp(P[l])
+}
+
+// clsagDomain returns the domain separator of the CLSAG hashes, padded to a key as in the reference implementation
+func clsagDomain(domain string) (r []byte) {
+	r = make([]byte, KeyLength)
+	copy(r, domain)
+	return
+}
+
+// clsagTranscript returns the ring keys hashed by all CLSAG hashes, P[0] .. P[n-1] || C[0] .. C[n-1]
+func clsagTranscript(P, C []*Point) (r []byte) {
+	for _, K := range P {
+		r = append(r, K.Bytes()...)
+	}
+	for _, K := range C {
+		r = append(r, K.Bytes()...)
+	}
+	return
+}
+
+// clsagAggregationCoefficients returns mu_P and mu_C
+func clsagAggregationCoefficients(ring []byte, I, D, Coffset *Point) (muP, muC *Scalar) {
+	muP = HashToScalar(clsagDomain("CLSAG_agg_0"), ring, I.Bytes(), D.Bytes(), Coffset.Bytes())
+	muC = HashToScalar(clsagDomain("CLSAG_agg_1"), ring, I.Bytes(), D.Bytes(), Coffset.Bytes())
+	return
+}
+
+func clsagRound(ring []byte, Coffset *Point, m Hash, L, R *Point) (c *Scalar) {
+	c = HashToScalar(clsagDomain("CLSAG_round"), ring, Coffset.Bytes(), m[:], L.Bytes(), R.Bytes())
+	return
+}
+
+// NewCLSAG signs m with the ring of one time addresses P and commitments C, the signer knows p = log_G P[l] and
+// z = log_G (C[l] - Coffset), Coffset is the pseudo output commitment of the input
+func NewCLSAG(m Hash, P, C []*Point, Coffset *Point, l int, p, z *Scalar) (sig *CLSAG, I *Point, err error) {
+	n := len(P)
+	if n == 0 || len(C) != n || l < 0 || l >= n {
+		err = err_msg.ErrCLSAGRing
+		return
+	}
+	if p.MultG().Equal(P[l]) == 0 || z.MultG().Equal(C[l].Subtract(Coffset)) == 0 {
+		err = err_msg.ErrCLSAGKeys
+		return
+	}
+
+	Hp := P[l].HashToEC()
+	I = Hp.ScalarMult(p)
+	D := Hp.ScalarMult(z)
+	sig = &CLSAG{S: make([]*Scalar, n), D: D.ScalarMult(invEight())}
+
+	ring := clsagTranscript(P, C)
+	muP, muC := clsagAggregationCoefficients(ring, I, sig.D, Coffset)
+
+	a := NewRandomScalar()
+	c := clsagRound(ring, Coffset, m, a.MultG(), Hp.ScalarMult(a))
+	for i := (l + 1) % n; i != l; i = (i + 1) % n {
+		if i == 0 {
+			sig.C1 = c
+		}
+		sig.S[i] = NewRandomScalar()
+		c = clsagChallenge(ring, Coffset, m, P[i], C[i], I, D, sig.S[i], muP.Multiply(c), muC.Multiply(c))
+	}
+	if l == 0 {
+		sig.C1 = c
+	}
+	//s_l = a - c_l (mu_P p + mu_C z)
+	sig.S[l] = a.Subtract(c.Multiply(muP.MultiplyAdd(p, muC.Multiply(z))))
+	return
+}
+
+// clsagChallenge returns the challenge of the next ring member after the member with keys Pi and Ci
+// L = s G + cP Pi + cC (Ci - Coffset), R = s Hp(Pi) + cP I + cC D
+func clsagChallenge(ring []byte, Coffset *Point, m Hash, Pi, Ci, I, D *Point, s, cP, cC *Scalar) (c *Scalar) {
+	L := MultiScalarMult(NewScalarSliceFrom(s, cP, cC), []*Point{PointG(), Pi, Ci.Subtract(Coffset)})
+	R := MultiScalarMult(NewScalarSliceFrom(s, cP, cC), []*Point{Pi.HashToEC(), I, D})
+	c = clsagRound(ring, Coffset, m, L, R)
+	return
+}
+
+// Verify returns true if sig is a signature of m by a member of the ring P, C with key image I
+func (sig *CLSAG) Verify(m Hash, P, C []*Point, Coffset, I *Point) (r bool) {
+	n := len(P)
+	if n == 0 || len(C) != n || len(sig.S) != n || sig.C1 == nil || sig.D == nil {
+		return
+	}
+	if I.Equal(PointI()) == 1 {
+		return
+	}
+	D := sig.D.MultByCofactor()
+	ring := clsagTranscript(P, C)
+	muP, muC := clsagAggregationCoefficients(ring, I, sig.D, Coffset)
+
+	c := sig.C1
+	for i := 0; i < n; i++ {
+		c = clsagChallenge(ring, Coffset, m, P[i], C[i], I, D, sig.S[i], muP.Multiply(c), muC.Multiply(c))
+	}
+	r = c.Equal(sig.C1) == 1
+	return
+}
@@ -0,0 +1,162 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+)
+
+// multisig CLSAG, the private key p of the real ring member is the sum of a public part x and of the shares of the
+// signers, each signer contributes two nonces and a partial response, the nonces are merged with a binding factor as
+// in MuSig2 so that concurrent signing sessions do not leak the shares
+//
+// monero/src/multisig/multisig_clsag_context.cpp
+
+// CLSAGNonce is the public nonce pair of a signer on G and on Hp(P[l])
+type CLSAGNonce struct {
+	G  [2]*Point
+	Hp [2]*Point
+}
+
+// MultisigCLSAG is a CLSAG being signed by several signers, it holds what they must agree on before exchanging nonces
+type MultisigCLSAG struct {
+	L int       //index of the real ring member
+	S []*Scalar //responses of the decoys, S[L] is computed by Combine
+	I *Point    //key image, combined from the partial key images of the signers
+	D *Point    //commitment key image z Hp(P[l])
+}
+
+// NewMultisigCLSAG starts a CLSAG spending P[l] with key image I, z = log_G (C[l] - Coffset)
+func NewMultisigCLSAG(P []*Point, l int, I *Point, z *Scalar) (ms *MultisigCLSAG, err error) {
+	if len(P) == 0 || l < 0 || l >= len(P) {
+		err = err_msg.ErrCLSAGRing
+		return
+	}
+	ms = &MultisigCLSAG{L: l, S: make([]*Scalar, len(P)), I: I, D: P[l].HashToEC().ScalarMult(z)}
+	for i := range P {
+		if i != l {
+			ms.S[i] = NewRandomScalar()
+		}
+	}
+	return
+}
+
+// NewCLSAGNonce returns the secret nonces of a signer of a CLSAG spending Pl and their public nonce, secret must be
+// used for a single partial signature
+func NewCLSAGNonce(Pl *Point) (secret [2]*Scalar, nonce *CLSAGNonce) {
+	Hp := Pl.HashToEC()
+	nonce = new(CLSAGNonce)
+	for i := range secret {
+		secret[i] = NewRandomScalar()
+		nonce.G[i], nonce.Hp[i] = secret[i].MultG(), Hp.ScalarMult(secret[i])
+	}
+	return
+}
+
+// challenge returns the binding factor b of nonces, the ring, the message, the decoy responses, the key images and
+// z G = C[l] - Coffset, the challenge cl of the real ring member, the challenge c1 of the first ring member and the
+// aggregation coefficients
+func (ms *MultisigCLSAG) challenge(m Hash, P, C []*Point, Coffset *Point, nonces []*CLSAGNonce) (b, cl, c1, muP, muC *Scalar, err error) {
+	n, l := len(P), ms.L
+	if n == 0 || len(C) != n || len(ms.S) != n || l < 0 || l >= n || ms.I == nil || ms.D == nil {
+		err = err_msg.ErrCLSAGRing
+		return
+	}
+	for i, s := range ms.S {
+		if i != l && s == nil {
+			err = err_msg.ErrCLSAGRing
+			return
+		}
+	}
+	if len(nonces) == 0 {
+		err = err_msg.ErrCLSAGNonce
+		return
+	}
+	ring := clsagTranscript(P, C)
+	// b binds everything the challenge depends on, so that the same nonces can not be signed with other decoy
+	// responses, which would give another c_l for the same aggregated nonce
+	data := [][]byte{clsagDomain("CLSAG_multisig_binonce"), ring, Coffset.Bytes(), m[:], varint(uint64(l)),
+		ms.I.Bytes(), ms.D.Bytes(), C[l].Subtract(Coffset).Bytes()}
+	for i, s := range ms.S {
+		if i != l {
+			data = append(data, s.Bytes())
+		}
+	}
+	for _, nonce := range nonces {
+		if nonce == nil || nonce.G[0] == nil || nonce.G[1] == nil || nonce.Hp[0] == nil || nonce.Hp[1] == nil {
+			err = err_msg.ErrCLSAGNonce
+			return
+		}
+		data = append(data, nonce.G[0].Bytes(), nonce.G[1].Bytes(), nonce.Hp[0].Bytes(), nonce.Hp[1].Bytes())
+	}
+	b = HashToScalar(data...)
+
+	// L = sum a_0 G + b a_1 G, R = sum a_0 Hp + b a_1 Hp
+	L, R := PointI(), PointI()
+	for _, nonce := range nonces {
+		L = L.Add(nonce.G[0]).Add(nonce.G[1].ScalarMult(b))
+		R = R.Add(nonce.Hp[0]).Add(nonce.Hp[1].ScalarMult(b))
+	}
+	muP, muC = clsagAggregationCoefficients(ring, ms.I, ms.D.ScalarMult(invEight()), Coffset)
+	c := clsagRound(ring, Coffset, m, L, R)
+	for i := (l + 1) % n; i != l; i = (i + 1) % n {
+		if i == 0 {
+			c1 = c
+		}
+		c = clsagChallenge(ring, Coffset, m, P[i], C[i], ms.I, ms.D, ms.S[i], muP.Multiply(c), muC.Multiply(c))
+	}
+	if l == 0 {
+		c1 = c
+	}
+	cl = c
+	return
+}
+
+// PartialSign returns the partial response of the signer holding the share p of the private key and the secret
+// nonces of one of nonces, s = a_0 + b a_1 - c_l mu_P p
+func (ms *MultisigCLSAG) PartialSign(m Hash, P, C []*Point, Coffset *Point, nonces []*CLSAGNonce, secret [2]*Scalar, p *Scalar) (s *Scalar, err error) {
+	b, cl, _, muP, _, err := ms.challenge(m, P, C, Coffset, nonces)
+	if err != nil {
+		return
+	}
+	G := secret[0].MultG()
+	found := false
+	for _, nonce := range nonces {
+		if nonce.G[0].Equal(G) == 1 {
+			found = true
+		}
+	}
+	if !found {
+		err = err_msg.ErrCLSAGNonce
+		return
+	}
+	s = secret[0].Add(b.Multiply(secret[1])).Subtract(cl.Multiply(muP.Multiply(p)))
+	return
+}
+
+// Combine returns the CLSAG of the partial responses of the signers of nonces, x is the public part of the private
+// key and z = log_G (C[l] - Coffset)
+// s_l = sum s_i - c_l (mu_P x + mu_C z)
+func (ms *MultisigCLSAG) Combine(m Hash, P, C []*Point, Coffset *Point, nonces []*CLSAGNonce, partials []*Scalar, x, z *Scalar) (sig *CLSAG, err error) {
+	_, cl, c1, muP, muC, err := ms.challenge(m, P, C, Coffset, nonces)
+	if err != nil {
+		return
+	}
+	if len(partials) != len(nonces) {
+		err = err_msg.ErrMultisigCLSAG
+		return
+	}
+	sl := cl.Multiply(muP.MultiplyAdd(x, muC.Multiply(z))).Negate()
+	for _, s := range partials {
+		if s == nil {
+			err = err_msg.ErrMultisigCLSAG
+			return
+		}
+		sl = sl.Add(s)
+	}
+	sig = &CLSAG{S: append([]*Scalar{}, ms.S...), C1: c1, D: ms.D.ScalarMult(invEight())}
+	sig.S[ms.L] = sl
+	if !sig.Verify(m, P, C, Coffset, ms.I) {
+		sig = nil
+		err = err_msg.ErrMultisigCLSAG
+	}
+	return
+}
@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+	"testing"
+)
+
+func TestMultisigCLSAG(t *testing.T) {
+	m := Keccak256([]byte("message"))
+	P, C, Coffset, p, z := clsagRing(11, 3)
+	// p = x + p_0 + p_1 + p_2
+	shares := []*Scalar{NewRandomScalar(), NewRandomScalar(), NewRandomScalar()}
+	x := p.Subtract(shares[0]).Subtract(shares[1]).Subtract(shares[2])
+
+	ms, err := NewMultisigCLSAG(P, 3, p.KeyImage(), z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secrets [][2]*Scalar
+	var nonces []*CLSAGNonce
+	for range shares {
+		secret, nonce := NewCLSAGNonce(P[3])
+		secrets, nonces = append(secrets, secret), append(nonces, nonce)
+	}
+	var partials []*Scalar
+	for n, share := range shares {
+		s, err := ms.PartialSign(m, P, C, Coffset, nonces, secrets[n], share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		partials = append(partials, s)
+	}
+	sig, err := ms.Combine(m, P, C, Coffset, nonces, partials, x, z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sig.Verify(m, P, C, Coffset, p.KeyImage()) {
+		t.Errorf("combined signature does not verify")
+	}
+
+	partials[1] = partials[1].Add(ScalarIdentity())
+	if _, err = ms.Combine(m, P, C, Coffset, nonces, partials, x, z); err != err_msg.ErrMultisigCLSAG {
+		t.Errorf("want %v, got %v", err_msg.ErrMultisigCLSAG, err)
+	}
+	secret, _ := NewCLSAGNonce(P[3])
+	if _, err = ms.PartialSign(m, P, C, Coffset, nonces, secret, shares[0]); err != err_msg.ErrCLSAGNonce {
+		t.Errorf("want %v, got %v", err_msg.ErrCLSAGNonce, err)
+	}
+}
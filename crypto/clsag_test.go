@@ -0,0 +1,67 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+	"testing"
+)
+
+// clsagRing returns a ring of n random members where member l is opened by p and z with the pseudo output Coffset
+func clsagRing(n, l int) (P, C []*Point, Coffset *Point, p, z *Scalar) {
+	for i := 0; i < n; i++ {
+		_, Pi := NewKeyPair()
+		_, Ci := NewKeyPair()
+		P, C = append(P, Pi), append(C, Ci)
+	}
+	p, P[l] = NewKeyPair()
+	amount := NewScalarFromAmount([8]byte{100})
+	mask, pseudoMask := NewRandomScalar(), NewRandomScalar()
+	C[l] = mask.DoubleScalarBaseMult(amount, PointH())
+	Coffset = pseudoMask.DoubleScalarBaseMult(amount, PointH())
+	z = mask.Subtract(pseudoMask)
+	return
+}
+
+// like the CLSAG tests of monero/tests/unit_tests/ringct.cpp
+func TestCLSAG(t *testing.T) {
+	m := Keccak256([]byte("message"))
+	for _, l := range []int{0, 5, 10} {
+		P, C, Coffset, p, z := clsagRing(11, l)
+		sig, I, err := NewCLSAG(m, P, C, Coffset, l, p, z)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if I.Equal(p.KeyImage()) == 0 {
+			t.Errorf("key image is not p Hp(P)")
+		}
+		if !sig.Verify(m, P, C, Coffset, I) {
+			t.Errorf("valid signature does not verify")
+		}
+		if sig.Verify(Keccak256([]byte("other message")), P, C, Coffset, I) {
+			t.Errorf("signature verifies for another message")
+		}
+		if sig.Verify(m, P, C, Coffset, NewRandomScalar().KeyImage()) {
+			t.Errorf("signature verifies for another key image")
+		}
+		if sig.Verify(m, P, C, Coffset.Add(PointH()), I) {
+			t.Errorf("signature verifies for another pseudo output")
+		}
+		P[(l+1)%len(P)] = P[(l+1)%len(P)].Add(PointG())
+		if sig.Verify(m, P, C, Coffset, I) {
+			t.Errorf("signature verifies for another ring")
+		}
+	}
+}
+
+func TestCLSAGKeys(t *testing.T) {
+	m := Keccak256([]byte("message"))
+	P, C, Coffset, p, z := clsagRing(4, 1)
+	if _, _, err := NewCLSAG(m, P, C, Coffset, 2, p, z); err != err_msg.ErrCLSAGKeys {
+		t.Errorf("want %v, got %v", err_msg.ErrCLSAGKeys, err)
+	}
+	if _, _, err := NewCLSAG(m, P, C, Coffset.Add(PointH()), 1, p, z); err != err_msg.ErrCLSAGKeys {
+		t.Errorf("want %v, got %v", err_msg.ErrCLSAGKeys, err)
+	}
+	if _, _, err := NewCLSAG(m, P, C, Coffset, 4, p, z); err != err_msg.ErrCLSAGRing {
+		t.Errorf("want %v, got %v", err_msg.ErrCLSAGRing, err)
+	}
+}
@@ -0,0 +1,100 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+)
+
+// CompositionProof proves knowledge of x, y, z such that K = x G + y X + z U with the key image KI = (z/y) U, it is
+// the spend proof of a seraphis enote image with K the masked address
+//
+// the proof follows sp_composition_proof.cpp but its challenges are Keccak hashes of the concatenated keys, not the
+// Blake2b SpTranscript of seraphis_lib, so proofs are deliberately not interchangeable with the reference while the
+// seraphis transcript format is still changing
+//
+// seraphis_lib/src/seraphis_crypto/sp_composition_proof.cpp
+type CompositionProof struct {
+	C   *Scalar //challenge
+	Rt1 *Scalar //responses
+	Rt2 *Scalar
+	Rki *Scalar
+	Kt1 *Point //(1/8) * (1/y) K
+}
+
+// KeyImage returns the key image of the key K = x G + y X + z U
+// KI = (z/y) U
+func KeyImage(y, z *Scalar) (KI *Point) {
+	KI = z.Multiply(y.Invert()).MultU()
+	return
+}
+
+// NewCompositionProof proves that K = x G + y X + z U with the key image (z/y) U for message m, y and z can not be
+// zero
+func NewCompositionProof(m Hash, K *Point, x, y, z *Scalar) (proof *CompositionProof, err error) {
+	if y.Equal(ScalarZero()) == 1 || z.Equal(ScalarZero()) == 1 {
+		err = err_msg.ErrCompositionProofKeys
+		return
+	}
+	if x.MultG().Add(y.MultX()).Add(z.MultU()).Equal(K) == 0 {
+		err = err_msg.ErrCompositionProofKeys
+		return
+	}
+	yInv := y.Invert()
+	KI := z.Multiply(yInv).MultU()
+
+	proof = new(CompositionProof)
+	//Kt1 = (1/y) K, stored multiplied by 1/8
+	Kt1 := K.ScalarMult(yInv)
+	proof.Kt1 = Kt1.ScalarMult(invEight())
+
+	alphaT1, alphaT2, alphaKI := NewRandomScalar(), NewRandomScalar(), NewRandomScalar()
+	mcp := compositionProofMessage(m, K, KI, proof.Kt1)
+	proof.C = compositionProofChallenge(mcp, K.ScalarMult(alphaT1), alphaT2.MultG(), alphaKI.MultU())
+
+	//rt1 = alpha_t1 - c (1/y)
+	proof.Rt1 = alphaT1.Subtract(proof.C.Multiply(yInv))
+	//rt2 = alpha_t2 - c (x/y)
+	proof.Rt2 = alphaT2.Subtract(proof.C.Multiply(x).Multiply(yInv))
+	//rki = alpha_ki - c (z/y)
+	proof.Rki = alphaKI.Subtract(proof.C.Multiply(z).Multiply(yInv))
+	return
+}
+
+// Verify returns true if the proof shows that K = x G + y X + z U with KI = (z/y) U for message m, KI must be in the
+// prime order subgroup, KI + T for a torsion point T would pass for an even challenge and link to another key image
+func (proof *CompositionProof) Verify(m Hash, K, KI *Point) (r bool) {
+	if KI.Equal(PointI()) == 1 || K.Equal(PointI()) == 1 || !KI.InPrimeSubgroup() {
+		return
+	}
+	Kt1 := proof.Kt1.MultByCofactor()
+	//Kt2 = Kt1 - X - KI = (x/y) G
+	Kt2 := Kt1.Subtract(PointX()).Subtract(KI)
+
+	//the prover's commitments are recovered from the responses
+	//rt1 K + c Kt1, rt2 G + c Kt2, rki U + c KI
+	t1 := K.ScalarMult(proof.Rt1).Add(Kt1.ScalarMult(proof.C))
+	t2 := proof.Rt2.DoubleScalarBaseMult(proof.C, Kt2)
+	ki := proof.Rki.MultU().Add(KI.ScalarMult(proof.C))
+
+	mcp := compositionProofMessage(m, K, KI, proof.Kt1)
+	c := compositionProofChallenge(mcp, t1, t2, ki)
+	r = c.Equal(proof.C) == 1
+	return
+}
+
+// compositionProofMessage returns Hs("sp_composition_proof_transcript" || m || K || KI || Kt1), it stands for the
+// SpTranscript of the reference and is not compatible with it
+func compositionProofMessage(m Hash, K, KI, Kt1 *Point) (r *Scalar) {
+	r = HashToScalar([]byte("sp_composition_proof_transcript"), m[:], K.Bytes(), KI.Bytes(), Kt1.Bytes())
+	return
+}
+
+func compositionProofChallenge(mcp *Scalar, t1, t2, ki *Point) (c *Scalar) {
+	c = HashToScalar(mcp.Bytes(), t1.Bytes(), t2.Bytes(), ki.Bytes())
+	return
+}
+
+// invEight returns 1/8, points multiplied by it are recovered in the prime order subgroup by multiplying by 8
+func invEight() (r *Scalar) {
+	r = NewScalarFromAmount([8]byte{8}).Invert()
+	return
+}
@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+	"testing"
+)
+
+// like the composition proof tests of seraphis_lib/tests/unit_tests/seraphis_crypto.cpp
+func TestCompositionProof(t *testing.T) {
+	m := Keccak256([]byte("message"))
+	for _, x := range []*Scalar{ScalarZero(), NewRandomScalar()} {
+		y, z := NewRandomScalar(), NewRandomScalar()
+		K := x.MultG().Add(y.MultX()).Add(z.MultU())
+		KI := KeyImage(y, z)
+
+		proof, err := NewCompositionProof(m, K, x, y, z)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		if !proof.Verify(m, K, KI) {
+			t.Errorf("valid proof does not verify")
+		}
+		if proof.Verify(Keccak256([]byte("other message")), K, KI) {
+			t.Errorf("proof verifies for another message")
+		}
+		if proof.Verify(m, K, KeyImage(y, NewRandomScalar())) {
+			t.Errorf("proof verifies for another key image")
+		}
+		if proof.Verify(m, K.Add(PointG()), KI) {
+			t.Errorf("proof verifies for another key")
+		}
+	}
+}
+
+func TestCompositionProofKeys(t *testing.T) {
+	m := Keccak256([]byte("message"))
+	x, y, z := NewRandomScalar(), NewRandomScalar(), NewRandomScalar()
+	K := x.MultG().Add(y.MultX()).Add(z.MultU())
+
+	if _, err := NewCompositionProof(m, K, x, y, NewRandomScalar()); err != err_msg.ErrCompositionProofKeys {
+		t.Errorf("want %v, got %v", err_msg.ErrCompositionProofKeys, err)
+	}
+	K = x.MultG().Add(y.MultX())
+	if _, err := NewCompositionProof(m, K, x, y, ScalarZero()); err != err_msg.ErrCompositionProofKeys {
+		t.Errorf("want %v, got %v", err_msg.ErrCompositionProofKeys, err)
+	}
+}
+
+// pointT is the torsion point of order 2, (0, -1)
+var pointT = NewPointFromHexString("ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+
+// forgeCompositionProof returns a proof for the key image KI + T, valid but for the subgroup check when the challenge
+// is even, T vanishes from c (KI + T) and from c Kt2
+func forgeCompositionProof(m Hash, K *Point, x, y, z *Scalar) (proof *CompositionProof, KIt *Point) {
+	yInv := y.Invert()
+	KIt = KeyImage(y, z).Add(pointT)
+	proof = &CompositionProof{Kt1: K.ScalarMult(yInv).ScalarMult(invEight())}
+	for {
+		alphaT1, alphaT2, alphaKI := NewRandomScalar(), NewRandomScalar(), NewRandomScalar()
+		mcp := compositionProofMessage(m, K, KIt, proof.Kt1)
+		proof.C = compositionProofChallenge(mcp, K.ScalarMult(alphaT1), alphaT2.MultG(), alphaKI.MultU())
+		if proof.C.Bytes()[0]&1 == 1 {
+			continue
+		}
+		proof.Rt1 = alphaT1.Subtract(proof.C.Multiply(yInv))
+		proof.Rt2 = alphaT2.Subtract(proof.C.Multiply(x).Multiply(yInv))
+		proof.Rki = alphaKI.Subtract(proof.C.Multiply(z).Multiply(yInv))
+		return
+	}
+}
+
+func TestCompositionProofTorsion(t *testing.T) {
+	if pointT.Err != nil || pointT.MultByCofactor().Equal(PointI()) == 0 || pointT.InPrimeSubgroup() {
+		t.Fatal("T is not a torsion point")
+	}
+	m := Keccak256([]byte("message"))
+	x, y, z := NewRandomScalar(), NewRandomScalar(), NewRandomScalar()
+	K := x.MultG().Add(y.MultX()).Add(z.MultU())
+	KI := KeyImage(y, z)
+	if !KI.InPrimeSubgroup() || KI.Add(pointT).Equal(KI) == 1 {
+		t.Fatal("KI + T is not another key image")
+	}
+
+	proof, KIt := forgeCompositionProof(m, K, x, y, z)
+	Kt1 := proof.Kt1.MultByCofactor()
+	t1 := K.ScalarMult(proof.Rt1).Add(Kt1.ScalarMult(proof.C))
+	t2 := proof.Rt2.DoubleScalarBaseMult(proof.C, Kt1.Subtract(PointX()).Subtract(KIt))
+	ki := proof.Rki.MultU().Add(KIt.ScalarMult(proof.C))
+	if compositionProofChallenge(compositionProofMessage(m, K, KIt, proof.Kt1), t1, t2, ki).Equal(proof.C) == 0 {
+		t.Fatal("forged proof does not match its challenge")
+	}
+	if proof.Verify(m, K, KIt) {
+		t.Errorf("proof verifies for a key image outside of the prime order subgroup")
+	}
+}
+
+func BenchmarkCompositionProofVerify(b *testing.B) {
+	m := Keccak256([]byte("message"))
+	x, y, z := NewRandomScalar(), NewRandomScalar(), NewRandomScalar()
+	K := x.MultG().Add(y.MultX()).Add(z.MultU())
+	KI := KeyImage(y, z)
+	proof, _ := NewCompositionProof(m, K, x, y, z)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		proof.Verify(m, K, KI)
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"gomonero/err_msg"
 )
 
-//todo add stringers
-
 type Point struct {
 	edPoint *edwards25519.Point
 	Err     error
@@ -117,6 +115,17 @@ func (P *Point) MultByCofactor() (R *Point) {
 	return
 }
 
+// InPrimeSubgroup returns true if P is in the subgroup of order L, l P = (l - 1) P + P = I
+func (P *Point) InPrimeSubgroup() (r bool) {
+	if P.Err != nil {
+		return
+	}
+	minusOne := new(edwards25519.Scalar).Negate(scalarIdentity)
+	lP := new(edwards25519.Point).ScalarMult(minusOne, P.edPoint)
+	r = lP.Add(lP, P.edPoint).Equal(edwards25519.NewIdentityPoint()) == 1
+	return
+}
+
 func (P *Point) Equal(Q *Point) (r int) {
 	if P.Err != nil || Q.Err != nil {
 		return
@@ -135,6 +144,18 @@ func (P *Point) HashToScalar() (r *Scalar) {
 	return
 }
 
+// HashToEC Creates a point on the Edwards Curve by hashing the Point (hash_to_ec in monero reference implementation)
+func (P *Point) HashToEC() (result *Point) {
+	if P.Err != nil {
+		result = new(Point)
+		result.Err = P.Err
+		return
+	}
+	h := Keccak256(P.Bytes())
+	result = new(Point).fromFEBytes(h[:]).MultByCofactor()
+	return
+}
+
 func NewScalarFromBytes(b []byte) (r *Scalar) {
 	r = new(Scalar)
 	r.edScalar, r.Err = new(edwards25519.Scalar).SetCanonicalBytes(b)
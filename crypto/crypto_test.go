@@ -1609,6 +1609,32 @@ func TestPointToBytesToPoint(t *testing.T) {
 	}
 }
 
+func TestTextMarshal(t *testing.T) {
+	a := NewRandomScalar()
+	A := a.MultG()
+	aText, _ := a.MarshalText()
+	AText, _ := A.MarshalText()
+	gotA := new(Point)
+	got := new(Scalar)
+	if err := gotA.UnmarshalText(AText); err != nil || A.Equal(gotA) == 0 {
+		t.Errorf("Point UnmarshalText failed: want %v, got %v", A, gotA)
+	}
+	if err := got.UnmarshalText(aText); err != nil || a.Equal(got) == 0 {
+		t.Errorf("Scalar UnmarshalText failed: want %v, got %v", a, got)
+	}
+}
+
+func TestKeyImage(t *testing.T) {
+	ko := NewRandomScalar()
+	want := ko.MultG().HashToEC().ScalarMult(ko)
+	if want.Equal(ko.KeyImage()) == 0 {
+		t.Errorf("KeyImage failed: want %v, got %v", want, ko.KeyImage())
+	}
+	if ko.KeyImage().Equal(NewRandomScalar().KeyImage()) != 0 {
+		t.Errorf("KeyImage failed: different keys have the same key image")
+	}
+}
+
 //validates Scalar Multiply assuming other functions are valid
 func TestMultiply(t *testing.T) {
 	for i := 0; i < 5; i++ {
@@ -0,0 +1,59 @@
+package crypto
+
+import (
+	"encoding/binary"
+)
+
+// key derivations of the monero reference implementation
+//monero/src/crypto/crypto.cpp
+//
+// a key derivation is the shared secret 8 * r * Kv = 8 * kv * R between the sender and the receiver of an output
+
+// varint returns the monero varint encoding of n, which is the same as the unsigned varint of encoding/binary
+func varint(n uint64) (r []byte) {
+	r = make([]byte, binary.MaxVarintLen64)
+	r = r[:binary.PutUvarint(r, n)]
+	return
+}
+
+// DerivationToScalar returns Hs(P || varint(outputIndex)) for the key derivation P (derivation_to_scalar)
+func (P *Point) DerivationToScalar(outputIndex uint64) (r *Scalar) {
+	if P.Err != nil {
+		r = new(Scalar)
+		r.Err = P.Err
+		return
+	}
+	r = HashToScalar(P.Bytes(), varint(outputIndex))
+	return
+}
+
+// ViewTag returns the first byte of H("view_tag" || P || varint(outputIndex)) for the key derivation P (derive_view_tag)
+func (P *Point) ViewTag(outputIndex uint64) (r byte) {
+	r = Hash8([]byte("view_tag"), P.Bytes(), varint(outputIndex))
+	return
+}
+
+// GenerateKeyDerivation returns the key derivation 8 * k * R (generate_key_derivation)
+func GenerateKeyDerivation(R *PublicKey, k *PrivateKey) (D *Point) {
+	D = R.ScalarMult(k).MultByCofactor()
+	return
+}
+
+// DerivePublicKey returns the one time address Hs(D || varint(outputIndex)) * G + B (derive_public_key)
+func DerivePublicKey(D *Point, outputIndex uint64, B *PublicKey) (Ko *PublicKey) {
+	Ko = D.DerivationToScalar(outputIndex).MultG().Add(B)
+	return
+}
+
+// DeriveSecretKey returns the one time address private key Hs(D || varint(outputIndex)) + b (derive_secret_key)
+func DeriveSecretKey(D *Point, outputIndex uint64, b *PrivateKey) (ko *PrivateKey) {
+	ko = D.DerivationToScalar(outputIndex).Add(b)
+	return
+}
+
+// DeriveSubaddressPublicKey returns the spend key Ko - Hs(D || varint(outputIndex)) * G the output Ko was sent to
+// (derive_subaddress_public_key)
+func DeriveSubaddressPublicKey(Ko *PublicKey, D *Point, outputIndex uint64) (B *PublicKey) {
+	B = Ko.Subtract(D.DerivationToScalar(outputIndex).MultG())
+	return
+}
@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"testing"
+)
+
+// vectors from monero/tests/crypto/tests.txt
+
+func TestGenerateKeyDerivation(t *testing.T) {
+	tests := []struct {
+		R, k, want string
+	}{
+		{"fdfd97d2ea9f1c25df773ff2c973d885653a3ee643157eb0ae2b6dd98f0b6984", "eb2bd1cf0c5e074f9dbf38ebbc99c316f54e21803048c687a3bb359f7a713b02", "4e0bd2c41325a1b89a9f7413d4d05e0a5a4936f241dccc3c7d0c539ffe00ef67"},
+	}
+	for _, tt := range tests {
+		got := GenerateKeyDerivation(NewPointFromHexString(tt.R), NewScalarFromHexString(tt.k))
+		if got.String() != tt.want {
+			t.Errorf("GenerateKeyDerivation: want %v, got %v", tt.want, got)
+		}
+	}
+}
+
+func TestViewTag(t *testing.T) {
+	D := NewPointFromHexString("0fc47054f355ced4d67de73bfa12e4c78ff19089548fffa7d07a674741860f97")
+	tests := []struct {
+		outputIndex uint64
+		want        byte
+	}{
+		{0, 0x76},
+		{1, 0xd6},
+		{2, 0x87},
+		{3, 0x1b},
+		{127, 0x2c},
+		{128, 0x11},
+		{16383, 0xd0},
+		{16384, 0x7f},
+	}
+	for _, tt := range tests {
+		if got := D.ViewTag(tt.outputIndex); got != tt.want {
+			t.Errorf("ViewTag(%v): want %x, got %x", tt.outputIndex, tt.want, got)
+		}
+	}
+}
+
+func TestDeriveKeys(t *testing.T) {
+	r, kv := NewRandomScalar(), NewRandomScalar()
+	b := NewRandomScalar()
+	B := b.MultG()
+
+	// both sides of the transaction compute the same derivation
+	D := GenerateKeyDerivation(kv.MultG(), r)
+	if D.Equal(GenerateKeyDerivation(r.MultG(), kv)) == 0 {
+		t.Fatalf("GenerateKeyDerivation: sender and receiver derivations differ")
+	}
+	for _, outputIndex := range []uint64{0, 1, 300, 1 << 40} {
+		Ko := DerivePublicKey(D, outputIndex, B)
+		if DeriveSecretKey(D, outputIndex, b).MultG().Equal(Ko) == 0 {
+			t.Errorf("DeriveSecretKey(%v) does not match DerivePublicKey", outputIndex)
+		}
+		if DeriveSubaddressPublicKey(Ko, D, outputIndex).Equal(B) == 0 {
+			t.Errorf("DeriveSubaddressPublicKey(%v) does not recover the spend key", outputIndex)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"gomonero/err_msg"
+)
+
+// String returns the hex encoding of P
+func (P *Point) String() string {
+	return hex.EncodeToString(P.Bytes())
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (P *Point) MarshalText() (r []byte, err error) {
+	if P.Err != nil {
+		err = P.Err
+		return
+	}
+	r = []byte(P.String())
+	return
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (P *Point) UnmarshalText(b []byte) (err error) {
+	*P = *NewPointFromHexString(string(b))
+	err = P.Err
+	return
+}
+
+// String returns the hex encoding of s
+func (s *Scalar) String() string {
+	return hex.EncodeToString(s.Bytes())
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s *Scalar) MarshalText() (r []byte, err error) {
+	if s.Err != nil {
+		err = s.Err
+		return
+	}
+	r = []byte(s.String())
+	return
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Scalar) UnmarshalText(b []byte) (err error) {
+	*s = *NewScalarFromHexString(string(b))
+	err = s.Err
+	return
+}
+
+// String returns the hex encoding of h
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (h Hash) MarshalText() (r []byte, err error) {
+	r = []byte(h.String())
+	return
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (h *Hash) UnmarshalText(b []byte) (err error) {
+	if hex.DecodedLen(len(b)) != HashLength {
+		err = err_msg.ErrMismatchedLengths
+		return
+	}
+	_, err = hex.Decode(h[:], b)
+	return
+}
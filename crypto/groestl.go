@@ -0,0 +1,123 @@
+package crypto
+
+import "encoding/binary"
+
+// Grøstl-256, one of the four final hashes of cn_slow_hash
+//monero/src/crypto/groestl.c
+
+const groestlRows = 8
+const groestlColumns = 8
+
+// groestl state matrix, byte i of the state is row i%8 and column i/8
+type groestlState [groestlRows][groestlColumns]byte
+
+var groestlMixCoefficients = [groestlRows]byte{2, 2, 3, 4, 5, 3, 5, 7}
+
+var groestlShiftP = [groestlRows]int{0, 1, 2, 3, 4, 5, 6, 7}
+var groestlShiftQ = [groestlRows]int{1, 3, 5, 7, 0, 2, 4, 6}
+
+func gfMultiply(a, b byte) (r byte) {
+	for b != 0 {
+		if b&1 != 0 {
+			r ^= a
+		}
+		a = gfDouble(a)
+		b >>= 1
+	}
+	return
+}
+
+func groestlFromBytes(b []byte) (s groestlState) {
+	for i := 0; i < groestlRows*groestlColumns; i++ {
+		s[i%groestlRows][i/groestlRows] = b[i]
+	}
+	return
+}
+
+func (s *groestlState) bytes() (b [groestlRows * groestlColumns]byte) {
+	for i := range b {
+		b[i] = s[i%groestlRows][i/groestlRows]
+	}
+	return
+}
+
+func (s *groestlState) xor(a *groestlState) {
+	for i := range s {
+		for j := range s[i] {
+			s[i][j] ^= a[i][j]
+		}
+	}
+}
+
+// permute applies the P permutation, or the Q permutation if q is set
+func (s *groestlState) permute(q bool) {
+	shift := groestlShiftP
+	if q {
+		shift = groestlShiftQ
+	}
+	for r := 0; r < 10; r++ {
+		// AddRoundConstant
+		for j := 0; j < groestlColumns; j++ {
+			c := byte(j<<4) ^ byte(r)
+			if q {
+				for i := 0; i < groestlRows-1; i++ {
+					s[i][j] ^= 0xff
+				}
+				s[groestlRows-1][j] ^= c ^ 0xff
+			} else {
+				s[0][j] ^= c
+			}
+		}
+		// SubBytes and ShiftBytes
+		var t groestlState
+		for i := 0; i < groestlRows; i++ {
+			for j := 0; j < groestlColumns; j++ {
+				t[i][j] = aesSbox[s[i][(j+shift[i])%groestlColumns]]
+			}
+		}
+		// MixBytes
+		for j := 0; j < groestlColumns; j++ {
+			for i := 0; i < groestlRows; i++ {
+				var v byte
+				for k := 0; k < groestlRows; k++ {
+					v ^= gfMultiply(t[k][j], groestlMixCoefficients[(k-i+groestlRows)%groestlRows])
+				}
+				s[i][j] = v
+			}
+		}
+	}
+}
+
+func groestl256(data []byte) (result Hash) {
+	const blockSize = groestlRows * groestlColumns
+
+	// padding: 1 bit, zeros, then the 64 bit number of blocks
+	blocks := (len(data) + 1 + 8 + blockSize - 1) / blockSize
+	padded := make([]byte, blocks*blockSize)
+	copy(padded, data)
+	padded[len(data)] = 0x80
+	binary.BigEndian.PutUint64(padded[len(padded)-8:], uint64(blocks))
+
+	var iv [blockSize]byte
+	binary.BigEndian.PutUint64(iv[blockSize-8:], 256)
+	h := groestlFromBytes(iv[:])
+
+	// h = P(h ^ m) ^ Q(m) ^ h
+	for i := 0; i < blocks; i++ {
+		m := groestlFromBytes(padded[i*blockSize:])
+		p := h
+		p.xor(&m)
+		p.permute(false)
+		m.permute(true)
+		h.xor(&p)
+		h.xor(&m)
+	}
+
+	// output transformation: trunc(P(h) ^ h)
+	p := h
+	p.permute(false)
+	h.xor(&p)
+	b := h.bytes()
+	copy(result[:], b[blockSize-HashLength:])
+	return
+}
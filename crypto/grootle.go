@@ -0,0 +1,284 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+	"sync"
+)
+
+// GrootleProof proves knowledge of an index l and a key r such that M[l] - offset = r G, without revealing l, for a
+// reference set M of n^m keys
+// A, B and X are stored multiplied by 1/8
+//
+// seraphis_lib/src/seraphis_crypto/grootle.cpp
+type GrootleProof struct {
+	A  *Point
+	B  *Point
+	F  [][]*Scalar //m x (n-1) responses, f[j][0] is recovered by the verifier
+	X  []*Point    //m commitments to the coefficients of the index polynomials
+	ZA *Scalar
+	Z  *Scalar
+}
+
+// GrootleStatement is what a GrootleProof is verified against
+type GrootleStatement struct {
+	M       []*Point //reference set
+	Offset  *Point
+	Message Hash
+}
+
+var grootleGenerators struct {
+	mu sync.Mutex
+	H  []*Point
+}
+
+// grootleGeneratorsN returns the first count generators used to commit to the index decomposition
+func grootleGeneratorsN(count int) (H []*Point) {
+	grootleGenerators.mu.Lock()
+	defer grootleGenerators.mu.Unlock()
+	for i := len(grootleGenerators.H); i < count; i++ {
+		h := Keccak256([]byte("grootle Hi"), varint(uint64(i)))
+		grootleGenerators.H = append(grootleGenerators.H, new(Point).fromFEBytes(h[:]).MultByCofactor())
+	}
+	H = grootleGenerators.H[:count]
+	return
+}
+
+// grootleSize returns n^m, or an error if n or m are too small or M does not have n^m members
+func grootleSize(M []*Point, n, m int) (N int, err error) {
+	if n < 2 || m < 1 {
+		err = err_msg.ErrGrootleSize
+		return
+	}
+	N = 1
+	for j := 0; j < m; j++ {
+		if N > len(M)/n {
+			err = err_msg.ErrGrootleSize
+			return
+		}
+		N *= n
+	}
+	if N != len(M) {
+		err = err_msg.ErrGrootleSize
+	}
+	return
+}
+
+// decompose returns the m digits of k in base n
+func decompose(k, n, m int) (r []int) {
+	r = make([]int, m)
+	for j := 0; j < m; j++ {
+		r[j] = k % n
+		k /= n
+	}
+	return
+}
+
+// NewGrootleProof proves that s.M[l] - s.Offset = r G with the decomposition base n and exponent m, len(s.M) must be
+// n^m
+func NewGrootleProof(s *GrootleStatement, l int, r *Scalar, n, m int) (proof *GrootleProof, err error) {
+	N, err := grootleSize(s.M, n, m)
+	if err != nil {
+		return
+	}
+	if l < 0 || l >= N {
+		err = err_msg.ErrOutOfBounds
+		return
+	}
+	if s.M[l].Subtract(s.Offset).Equal(r.MultG()) == 0 {
+		err = err_msg.ErrGrootleKey
+		return
+	}
+	H := grootleGeneratorsN(2 * m * n)
+	inv8 := invEight()
+
+	// a[j][0] = -sum a[j][i] so the responses of each digit sum to the challenge
+	lDigits := decompose(l, n, m)
+	a := make([][]*Scalar, m)
+	sigma := make([][]*Scalar, m)
+	for j := 0; j < m; j++ {
+		a[j] = make([]*Scalar, n)
+		sigma[j] = make([]*Scalar, n)
+		a[j][0] = ScalarZero()
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				a[j][i] = NewRandomScalar()
+				a[j][0] = a[j][0].Subtract(a[j][i])
+			}
+			sigma[j][i] = ScalarZero()
+			if lDigits[j] == i {
+				sigma[j][i] = ScalarIdentity()
+			}
+		}
+	}
+
+	//A = rA G + sum a[j][i] H1[j][i] - a[j][i]^2 H2[j][i]
+	//B = rB G + sum sigma[j][i] H1[j][i] + a[j][i] (1 - 2 sigma[j][i]) H2[j][i]
+	rA, rB := NewRandomScalar(), NewRandomScalar()
+	two := ScalarIdentity().Add(ScalarIdentity())
+	Aterms, Bterms := newMSMBuilder(), newMSMBuilder()
+	Aterms.add(rA, PointG())
+	Bterms.add(rB, PointG())
+	for j := 0; j < m; j++ {
+		for i := 0; i < n; i++ {
+			H1, H2 := H[j*n+i], H[m*n+j*n+i]
+			Aterms.add(a[j][i], H1)
+			Aterms.add(a[j][i].Multiply(a[j][i]).Negate(), H2)
+			Bterms.add(sigma[j][i], H1)
+			Bterms.add(a[j][i].Multiply(ScalarIdentity().Subtract(two.Multiply(sigma[j][i]))), H2)
+		}
+	}
+	proof = new(GrootleProof)
+	proof.A = Aterms.secretSum().ScalarMult(inv8)
+	proof.B = Bterms.secretSum().ScalarMult(inv8)
+
+	// p[k] are the coefficients of prod_j (sigma[j][k_j] x + a[j][k_j]), only p[l] has degree m
+	// X[j] = sum p[k][j] (M[k] - offset) + rho[j] G
+	rho := make([]*Scalar, m)
+	proof.X = make([]*Point, m)
+	coefficients := make([][]*Scalar, N)
+	for k := 0; k < N; k++ {
+		coefficients[k] = grootlePolynomial(sigma, a, decompose(k, n, m))
+	}
+	for j := 0; j < m; j++ {
+		rho[j] = NewRandomScalar()
+		Xterms := newMSMBuilder()
+		Xterms.add(rho[j], PointG())
+		sum := ScalarZero()
+		for k := 0; k < N; k++ {
+			Xterms.add(coefficients[k][j], s.M[k])
+			sum = sum.Add(coefficients[k][j])
+		}
+		Xterms.add(sum.Negate(), s.Offset)
+		proof.X[j] = Xterms.secretSum().ScalarMult(inv8)
+	}
+
+	xi := grootleChallenge(s, n, m, proof)
+
+	//f[j][i] = sigma[j][i] xi + a[j][i] for i > 0
+	proof.F = make([][]*Scalar, m)
+	for j := 0; j < m; j++ {
+		proof.F[j] = make([]*Scalar, n-1)
+		for i := 1; i < n; i++ {
+			proof.F[j][i-1] = sigma[j][i].MultiplyAdd(xi, a[j][i])
+		}
+	}
+	//zA = rB xi + rA
+	proof.ZA = rB.MultiplyAdd(xi, rA)
+	//z = r xi^m - sum rho[j] xi^j
+	xiPowers := xi.PowersOfScalar(m + 1)
+	proof.Z = r.Multiply(xiPowers.slice[m])
+	for j := 0; j < m; j++ {
+		proof.Z = proof.Z.Subtract(rho[j].Multiply(xiPowers.slice[j]))
+	}
+	return
+}
+
+// grootlePolynomial returns the m+1 coefficients of prod_j (sigma[j][k[j]] x + a[j][k[j]])
+func grootlePolynomial(sigma, a [][]*Scalar, k []int) (p []*Scalar) {
+	p = []*Scalar{ScalarIdentity()}
+	for j := range k {
+		next := make([]*Scalar, len(p)+1)
+		for d := range next {
+			next[d] = ScalarZero()
+		}
+		for d, c := range p {
+			next[d] = next[d].Add(c.Multiply(a[j][k[j]]))
+			next[d+1] = next[d+1].Add(c.Multiply(sigma[j][k[j]]))
+		}
+		p = next
+	}
+	return
+}
+
+func grootleChallenge(s *GrootleStatement, n, m int, proof *GrootleProof) (xi *Scalar) {
+	data := [][]byte{[]byte("grootle challenge"), s.Message[:], varint(uint64(n)), varint(uint64(m))}
+	for _, M := range s.M {
+		data = append(data, M.Bytes())
+	}
+	data = append(data, s.Offset.Bytes(), proof.A.Bytes(), proof.B.Bytes())
+	for _, X := range proof.X {
+		data = append(data, X.Bytes())
+	}
+	xi = HashToScalar(data...)
+	return
+}
+
+// Verify returns true if proof shows that a member of s.M minus s.Offset is a multiple of G
+func (proof *GrootleProof) Verify(s *GrootleStatement, n, m int) (r bool) {
+	r = VerifyGrootleProofs([]*GrootleProof{proof}, []*GrootleStatement{s}, n, m)
+	return
+}
+
+// VerifyGrootleProofs verifies proofs against the statements at the same index with a single multi-scalar
+// multiplication, the checks of each proof are weighted with random scalars so they can not cancel out
+func VerifyGrootleProofs(proofs []*GrootleProof, statements []*GrootleStatement, n, m int) (r bool) {
+	if len(proofs) == 0 || len(proofs) != len(statements) {
+		return
+	}
+	H := grootleGeneratorsN(2 * m * n)
+	// the weights of the shared generators are accumulated over all proofs
+	gWeight := ScalarZero()
+	hWeights := make([]*Scalar, len(H))
+	for i := range hWeights {
+		hWeights[i] = ScalarZero()
+	}
+	terms := newMSMBuilder()
+
+	for p, proof := range proofs {
+		s := statements[p]
+		N, err := grootleSize(s.M, n, m)
+		if err != nil || len(proof.F) != m || len(proof.X) != m {
+			return
+		}
+		xi := grootleChallenge(s, n, m, proof)
+		w1, w2 := NewRandomScalar(), NewRandomScalar()
+
+		// f[j][0] = xi - sum f[j][i]
+		f := make([][]*Scalar, m)
+		for j := 0; j < m; j++ {
+			if len(proof.F[j]) != n-1 {
+				return
+			}
+			f[j] = make([]*Scalar, n)
+			f[j][0] = xi
+			for i := 1; i < n; i++ {
+				f[j][i] = proof.F[j][i-1]
+				f[j][0] = f[j][0].Subtract(f[j][i])
+			}
+		}
+
+		//w1 (A + xi B - zA G - sum f[j][i] H1[j][i] - f[j][i] (xi - f[j][i]) H2[j][i]) = 0
+		terms.add(w1, proof.A.MultByCofactor())
+		terms.add(w1.Multiply(xi), proof.B.MultByCofactor())
+		gWeight = gWeight.Subtract(w1.Multiply(proof.ZA))
+		for j := 0; j < m; j++ {
+			for i := 0; i < n; i++ {
+				hWeights[j*n+i] = hWeights[j*n+i].Subtract(w1.Multiply(f[j][i]))
+				fxi := f[j][i].Multiply(xi.Subtract(f[j][i]))
+				hWeights[m*n+j*n+i] = hWeights[m*n+j*n+i].Subtract(w1.Multiply(fxi))
+			}
+		}
+
+		//w2 (sum t[k] M[k] - xi^m offset - sum xi^j X[j] - z G) = 0 with t[k] = prod_j f[j][k_j]
+		for k := 0; k < N; k++ {
+			t := w2
+			for j, digit := range decompose(k, n, m) {
+				t = t.Multiply(f[j][digit])
+			}
+			terms.add(t, s.M[k])
+		}
+		xiPowers := xi.PowersOfScalar(m + 1)
+		terms.add(w2.Multiply(xiPowers.slice[m]).Negate(), s.Offset)
+		for j := 0; j < m; j++ {
+			terms.add(w2.Multiply(xiPowers.slice[j]).Negate(), proof.X[j].MultByCofactor())
+		}
+		gWeight = gWeight.Subtract(w2.Multiply(proof.Z))
+	}
+
+	terms.add(gWeight, PointG())
+	for i := range H {
+		terms.add(hWeights[i], H[i])
+	}
+	r = terms.isIdentity()
+	return
+}
@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"gomonero/err_msg"
+	"testing"
+)
+
+// newGrootleStatement returns a reference set of n^m random keys where member l minus the offset is r G
+func newGrootleStatement(n, m, l int) (s *GrootleStatement, r *Scalar) {
+	N := 1
+	for j := 0; j < m; j++ {
+		N *= n
+	}
+	s = &GrootleStatement{M: make([]*Point, N), Offset: NewRandomScalar().MultG(), Message: Keccak256([]byte("message"))}
+	for k := range s.M {
+		s.M[k] = NewRandomScalar().MultG()
+	}
+	r = NewRandomScalar()
+	s.M[l] = r.MultG().Add(s.Offset)
+	return
+}
+
+func TestGrootleProof(t *testing.T) {
+	for _, nm := range [][2]int{{2, 1}, {2, 3}, {3, 2}, {4, 2}} {
+		n, m := nm[0], nm[1]
+		s, r := newGrootleStatement(n, m, 0)
+		for l := range s.M {
+			s.M[l] = r.MultG().Add(s.Offset)
+			proof, err := NewGrootleProof(s, l, r, n, m)
+			if err != nil {
+				t.Fatalf(err.Error())
+			}
+			if !proof.Verify(s, n, m) {
+				t.Errorf("n=%d m=%d l=%d: valid proof does not verify", n, m, l)
+			}
+			s.M[l] = NewRandomScalar().MultG()
+		}
+	}
+}
+
+func TestGrootleProofInvalid(t *testing.T) {
+	n, m := 2, 3
+	s, r := newGrootleStatement(n, m, 5)
+	proof, err := NewGrootleProof(s, 5, r, n, m)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	other := *s
+	other.Message = Keccak256([]byte("other message"))
+	if proof.Verify(&other, n, m) {
+		t.Errorf("proof verifies for another message")
+	}
+	other = *s
+	other.Offset = NewRandomScalar().MultG()
+	if proof.Verify(&other, n, m) {
+		t.Errorf("proof verifies for another offset")
+	}
+	proof.Z = proof.Z.Add(ScalarIdentity())
+	if proof.Verify(s, n, m) {
+		t.Errorf("modified proof verifies")
+	}
+
+	if _, err = NewGrootleProof(s, 4, r, n, m); err != err_msg.ErrGrootleKey {
+		t.Errorf("want %v, got %v", err_msg.ErrGrootleKey, err)
+	}
+	if _, err = NewGrootleProof(s, 5, r, 3, 2); err != err_msg.ErrGrootleSize {
+		t.Errorf("want %v, got %v", err_msg.ErrGrootleSize, err)
+	}
+}
+
+func TestVerifyGrootleProofs(t *testing.T) {
+	n, m := 2, 2
+	var proofs []*GrootleProof
+	var statements []*GrootleStatement
+	for l := 0; l < 4; l++ {
+		s, r := newGrootleStatement(n, m, l)
+		proof, err := NewGrootleProof(s, l, r, n, m)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		proofs, statements = append(proofs, proof), append(statements, s)
+	}
+	if !VerifyGrootleProofs(proofs, statements, n, m) {
+		t.Errorf("valid batch does not verify")
+	}
+	// one bad proof fails the batch
+	statements[2].Message = Keccak256([]byte("other message"))
+	if VerifyGrootleProofs(proofs, statements, n, m) {
+		t.Errorf("batch with an invalid proof verifies")
+	}
+}
+
+func BenchmarkGrootleVerify(b *testing.B) {
+	n, m := 2, 7
+	s, r := newGrootleStatement(n, m, 100)
+	proof, _ := NewGrootleProof(s, 100, r, n, m)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		proof.Verify(s, n, m)
+	}
+}
@@ -0,0 +1,155 @@
+package crypto
+
+import "encoding/binary"
+
+// JH-256, one of the four final hashes of cn_slow_hash
+//monero/src/crypto/jh.c, bit sliced reference version jh_ref.h
+
+var jhSbox = [2][16]byte{
+	{9, 0, 4, 11, 13, 12, 3, 15, 1, 10, 2, 6, 7, 5, 8, 14},
+	{3, 12, 6, 13, 5, 7, 1, 9, 15, 2, 0, 4, 11, 10, 14, 8},
+}
+
+// jhRoundConstantZero is the fractional part of sqrt(2), split into 4 bit elements
+var jhRoundConstantZero = [64]byte{
+	0x6, 0xa, 0x0, 0x9, 0xe, 0x6, 0x6, 0x7, 0xf, 0x3, 0xb, 0xc, 0xc, 0x9, 0x0, 0x8,
+	0xb, 0x2, 0xf, 0xb, 0x1, 0x3, 0x6, 0x6, 0xe, 0xa, 0x9, 0x5, 0x7, 0xd, 0x3, 0xe,
+	0x3, 0xa, 0xd, 0xe, 0xc, 0x1, 0x7, 0x5, 0x1, 0x2, 0x7, 0x7, 0x5, 0x0, 0x9, 0x9,
+	0xd, 0xa, 0x2, 0xf, 0x5, 0x9, 0x0, 0xb, 0x0, 0x6, 0x6, 0x7, 0x3, 0x2, 0x2, 0xa,
+}
+
+type jhState struct {
+	h             [128]byte
+	a             [256]byte //4 bit elements
+	roundConstant [64]byte  //4 bit elements
+}
+
+// jhLinear is the MDS code L
+func jhLinear(a, b *byte) {
+	*b ^= ((*a << 1) ^ (*a >> 3) ^ ((*a >> 2) & 2)) & 0xf
+	*a ^= ((*b << 1) ^ (*b >> 3) ^ ((*b >> 2) & 2)) & 0xf
+}
+
+// jhPermute applies the permutation layer P_d to tem and writes the result to out
+func jhPermute(out, tem []byte) {
+	n := len(tem)
+	// initial swap pi
+	for i := 0; i < n; i += 4 {
+		tem[i+2], tem[i+3] = tem[i+3], tem[i+2]
+	}
+	// permutation P'
+	for i := 0; i < n/2; i++ {
+		out[i] = tem[i<<1]
+		out[i+n/2] = tem[(i<<1)+1]
+	}
+	// final swap phi
+	for i := n / 2; i < n; i += 2 {
+		out[i], out[i+1] = out[i+1], out[i]
+	}
+}
+
+// round is the round function R8 of E8
+func (s *jhState) round() {
+	var tem [256]byte
+	for i := 0; i < 256; i++ {
+		c := (s.roundConstant[i>>2] >> (3 - (i & 3))) & 1
+		tem[i] = jhSbox[c][s.a[i]]
+	}
+	for i := 0; i < 256; i += 2 {
+		jhLinear(&tem[i], &tem[i+1])
+	}
+	jhPermute(s.a[:], tem[:])
+}
+
+// updateRoundConstant generates the next round constant with R6
+func (s *jhState) updateRoundConstant() {
+	var tem [64]byte
+	for i := 0; i < 64; i++ {
+		tem[i] = jhSbox[0][s.roundConstant[i]]
+	}
+	for i := 0; i < 64; i += 2 {
+		jhLinear(&tem[i], &tem[i+1])
+	}
+	jhPermute(s.roundConstant[:], tem[:])
+}
+
+// e8 is the bijective function E8
+func (s *jhState) e8() {
+	s.roundConstant = jhRoundConstantZero
+
+	// group bits i, i+256, i+512 and i+768 of h into the 4 bit element i
+	var tem [256]byte
+	for i := 0; i < 256; i++ {
+		var t byte
+		for k := 0; k < 4; k++ {
+			bit := (s.h[(i+256*k)>>3] >> (7 - (i & 7))) & 1
+			t |= bit << (3 - k)
+		}
+		tem[i] = t
+	}
+	for i := 0; i < 128; i++ {
+		s.a[i<<1] = tem[i]
+		s.a[(i<<1)+1] = tem[i+128]
+	}
+
+	for i := 0; i < 42; i++ {
+		s.round()
+		s.updateRoundConstant()
+	}
+
+	// degroup
+	for i := 0; i < 128; i++ {
+		tem[i] = s.a[i<<1]
+		tem[i+128] = s.a[(i<<1)+1]
+	}
+	s.h = [128]byte{}
+	for i := 0; i < 256; i++ {
+		for k := 0; k < 4; k++ {
+			bit := (tem[i] >> (3 - k)) & 1
+			s.h[(i+256*k)>>3] |= bit << (7 - (i & 7))
+		}
+	}
+}
+
+// compress is the compression function F8
+func (s *jhState) compress(block []byte) {
+	for i := 0; i < 64; i++ {
+		s.h[i] ^= block[i]
+	}
+	s.e8()
+	for i := 0; i < 64; i++ {
+		s.h[i+64] ^= block[i]
+	}
+}
+
+func jh256(data []byte) (result Hash) {
+	const blockSize = 64
+	s := new(jhState)
+	s.h[0] = 256 >> 8
+	s.h[1] = 256 & 0xff
+	s.compress(make([]byte, blockSize))
+
+	length := uint64(len(data)) * 8
+	for len(data) >= blockSize {
+		s.compress(data[:blockSize])
+		data = data[blockSize:]
+	}
+
+	// padding: 1 bit, zeros, then the 128 bit message length, at least one full block of padding
+	var block [blockSize]byte
+	if len(data) == 0 {
+		block[0] = 0x80
+		binary.BigEndian.PutUint64(block[blockSize-8:], length)
+		s.compress(block[:])
+	} else {
+		copy(block[:], data)
+		block[len(data)] = 0x80
+		s.compress(block[:])
+		block = [blockSize]byte{}
+		binary.BigEndian.PutUint64(block[blockSize-8:], length)
+		s.compress(block[:])
+	}
+
+	copy(result[:], s.h[96:])
+	return
+}
@@ -20,6 +20,12 @@ func (s *PrivateKey) PublicKey() (R *PublicKey) {
 	return
 }
 
+// KeyImage returns the key image s * Hp(s * G) of the one time address private key s
+func (s *PrivateKey) KeyImage() (KI *PublicKey) {
+	KI = s.PublicKey().HashToEC().ScalarMult(s)
+	return
+}
+
 func NewKeyPair() (a *PrivateKey, A *PublicKey) {
 	a = NewRandomScalar()
 	A = a.MultG()
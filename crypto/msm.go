@@ -0,0 +1,97 @@
+package crypto
+
+import (
+	"filippo.io/edwards25519"
+	"gomonero/err_msg"
+)
+
+// MultiScalarMult returns the sum of s[i] * P[i], it runs in variable time and must not be used with secret scalars
+func MultiScalarMult(s *ScalarSlice, P []*Point) (R *Point) {
+	scalars, points, R := msmTerms(s, P)
+	if R.Err != nil {
+		return
+	}
+	R.edPoint = new(edwards25519.Point).VarTimeMultiScalarMult(scalars, points)
+	return
+}
+
+// ConstantTimeMultiScalarMult returns the sum of s[i] * P[i] in constant time, for provers with secret scalars
+func ConstantTimeMultiScalarMult(s *ScalarSlice, P []*Point) (R *Point) {
+	scalars, points, R := msmTerms(s, P)
+	if R.Err != nil {
+		return
+	}
+	// MultiScalarMult of edwards25519 adds to its receiver, which must start at the identity
+	R.edPoint = edwards25519.NewIdentityPoint().MultiScalarMult(scalars, points)
+	return
+}
+
+// msmTerms returns the scalars and points of a multi-scalar multiplication, R holds the error of the arguments
+func msmTerms(s *ScalarSlice, P []*Point) (scalars []*edwards25519.Scalar, points []*edwards25519.Point, R *Point) {
+	R = new(Point)
+	if s.err != nil {
+		R.Err = s.err
+		return
+	}
+	if len(s.slice) != len(P) {
+		R.Err = err_msg.IncompatibleSizesAB
+		return
+	}
+	scalars = make([]*edwards25519.Scalar, len(P))
+	points = make([]*edwards25519.Point, len(P))
+	for i := range P {
+		if s.slice[i].Err != nil {
+			R.Err = s.slice[i].Err
+			return
+		}
+		if P[i].Err != nil {
+			R.Err = P[i].Err
+			return
+		}
+		scalars[i], points[i] = s.slice[i].edScalar, P[i].edPoint
+	}
+	return
+}
+
+// NewScalarSliceFrom returns a ScalarSlice holding s
+func NewScalarSliceFrom(s ...*Scalar) (r *ScalarSlice) {
+	r = new(ScalarSlice)
+	r.slice = s
+	return
+}
+
+// Append returns p with s appended
+func (p *ScalarSlice) Append(s ...*Scalar) (r *ScalarSlice) {
+	r = p
+	r.slice = append(r.slice, s...)
+	return
+}
+
+// msmBuilder collects the terms of a multi-scalar multiplication
+type msmBuilder struct {
+	scalars *ScalarSlice
+	points  []*Point
+}
+
+func newMSMBuilder() (b *msmBuilder) {
+	b = &msmBuilder{scalars: NewScalarSliceFrom()}
+	return
+}
+
+func (b *msmBuilder) add(s *Scalar, P *Point) {
+	b.scalars.Append(s)
+	b.points = append(b.points, P)
+}
+
+// secretSum returns the sum of the terms in constant time, the scalars may be secret
+func (b *msmBuilder) secretSum() (R *Point) {
+	R = ConstantTimeMultiScalarMult(b.scalars, b.points)
+	return
+}
+
+// isIdentity returns true if the sum of the terms is the identity
+func (b *msmBuilder) isIdentity() (r bool) {
+	R := MultiScalarMult(b.scalars, b.points)
+	r = R.Err == nil && R.Equal(PointI()) == 1
+	return
+}
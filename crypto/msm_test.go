@@ -0,0 +1,22 @@
+package crypto
+
+import "testing"
+
+func TestMultiScalarMult(t *testing.T) {
+	s := RandomScalars(5)
+	P := make([]*Point, 5)
+	want := PointI()
+	for i := range P {
+		P[i] = NewRandomScalar().MultG()
+		want = want.Add(P[i].ScalarMult(s.slice[i]))
+	}
+	for _, msm := range []func(*ScalarSlice, []*Point) *Point{MultiScalarMult, ConstantTimeMultiScalarMult} {
+		// compare the encodings, Equal does not reject a malformed point
+		if string(msm(s, P).Bytes()) != string(want.Bytes()) {
+			t.Errorf("wrong multi-scalar multiplication")
+		}
+		if msm(s, P[:4]).Err == nil {
+			t.Errorf("mismatched lengths were not rejected")
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package crypto
+
+// Signature is a schnorr signature (c, r) of a hash by the private key of a public key
+//
+// monero/src/crypto/crypto.cpp generate_signature, check_signature
+type Signature struct {
+	C *Scalar
+	R *Scalar
+}
+
+// NewSignature signs h with the private key k of K
+// c = Hs(h || K || q G), r = q - c k
+func NewSignature(h Hash, K *PublicKey, k *PrivateKey) (sig *Signature) {
+	q := NewRandomScalar()
+	sig = &Signature{C: HashToScalar(h[:], K.Bytes(), q.MultG().Bytes())}
+	sig.R = q.Subtract(sig.C.Multiply(k))
+	return
+}
+
+// Verify returns true if sig is a signature of h by the private key of K
+func (sig *Signature) Verify(h Hash, K *PublicKey) (r bool) {
+	if sig.C == nil || sig.R == nil {
+		return
+	}
+	// q G = r G + c K
+	commitment := sig.R.DoubleScalarBaseMult(sig.C, K)
+	r = HashToScalar(h[:], K.Bytes(), commitment.Bytes()).Equal(sig.C) == 1
+	return
+}
+
+// RingSignature is a traceable ring signature of the pre RingCT transactions, one (c, r) pair per ring member, it is
+// also used to prove ownership of key images with a ring of one member
+//
+// monero/src/crypto/crypto.cpp generate_ring_signature, check_ring_signature
+type RingSignature []Signature
+
+// NewRingSignature signs h with the private key k of P[l] and its key image KI
+func NewRingSignature(h Hash, KI *PublicKey, P []*PublicKey, k *PrivateKey, l int) (sig RingSignature) {
+	sig = make(RingSignature, len(P))
+	buf := append([]byte{}, h[:]...)
+	sum := ScalarZero()
+	var q *Scalar
+	for i, Pi := range P {
+		var L, R *Point
+		if i == l {
+			q = NewRandomScalar()
+			L, R = q.MultG(), Pi.HashToEC().ScalarMult(q)
+		} else {
+			sig[i] = Signature{C: NewRandomScalar(), R: NewRandomScalar()}
+			L = sig[i].R.DoubleScalarBaseMult(sig[i].C, Pi)
+			R = Pi.HashToEC().ScalarMult(sig[i].R).Add(KI.ScalarMult(sig[i].C))
+			sum = sum.Add(sig[i].C)
+		}
+		buf = append(append(buf, L.Bytes()...), R.Bytes()...)
+	}
+	// the challenges sum to Hs(h || L_0 || R_0 || ...)
+	sig[l].C = HashToScalar(buf).Subtract(sum)
+	sig[l].R = q.Subtract(sig[l].C.Multiply(k))
+	return
+}
+
+// Verify returns true if sig is a signature of h by a member of the ring P with key image KI
+func (sig RingSignature) Verify(h Hash, KI *PublicKey, P []*PublicKey) (r bool) {
+	if len(sig) != len(P) || len(P) == 0 || KI.Equal(PointI()) == 1 {
+		return
+	}
+	buf := append([]byte{}, h[:]...)
+	sum := ScalarZero()
+	for i, Pi := range P {
+		if sig[i].C == nil || sig[i].R == nil {
+			return
+		}
+		L := sig[i].R.DoubleScalarBaseMult(sig[i].C, Pi)
+		R := Pi.HashToEC().ScalarMult(sig[i].R).Add(KI.ScalarMult(sig[i].C))
+		buf = append(append(buf, L.Bytes()...), R.Bytes()...)
+		sum = sum.Add(sig[i].C)
+	}
+	r = HashToScalar(buf).Equal(sum) == 1
+	return
+}
+
+// DLEQProof proves that K = k G and KB = k B share the private key k, B being another base point, e.g. the key
+// images of multisig signers are proven against their public keys
+type DLEQProof struct {
+	C *Scalar
+	R *Scalar
+}
+
+// NewDLEQProof proves that k G and k B have the same private key, for the hash h
+// c = Hs(h || B || K || KB || q G || q B), r = q - c k
+func NewDLEQProof(h Hash, B *Point, k *Scalar) (proof *DLEQProof) {
+	q := NewRandomScalar()
+	proof = &DLEQProof{C: HashToScalar(h[:], B.Bytes(), k.MultG().Bytes(), B.ScalarMult(k).Bytes(), q.MultG().Bytes(), B.ScalarMult(q).Bytes())}
+	proof.R = q.Subtract(proof.C.Multiply(k))
+	return
+}
+
+// Verify returns true if proof shows that K and KB have the same private key on the bases G and B
+func (proof *DLEQProof) Verify(h Hash, B, K, KB *Point) (r bool) {
+	if proof.C == nil || proof.R == nil || KB.Equal(PointI()) == 1 {
+		return
+	}
+	// q G = r G + c K, q B = r B + c KB
+	QG := proof.R.DoubleScalarBaseMult(proof.C, K)
+	QB := MultiScalarMult(NewScalarSliceFrom(proof.R, proof.C), []*Point{B, KB})
+	r = HashToScalar(h[:], B.Bytes(), K.Bytes(), KB.Bytes(), QG.Bytes(), QB.Bytes()).Equal(proof.C) == 1
+	return
+}
+
+// NewTxProof proves that R = r G, or R = r B if B is not nil, and D = r A, version 2 of the transaction proofs
+// c = Hs(h || D || X || Y || H("TXPROOF_V2") || R || A || B) with X = k G or k B and Y = k A, r' = k - c r
+//
+// monero/src/crypto/crypto.cpp generate_tx_proof, check_tx_proof
+func NewTxProof(h Hash, R, A, B, D *Point, r *Scalar) (sig *Signature) {
+	k := NewRandomScalar()
+	X := k.MultG()
+	if B != nil {
+		X = B.ScalarMult(k)
+	}
+	sig = &Signature{C: txProofChallenge(h, R, A, B, D, X, A.ScalarMult(k))}
+	sig.R = k.Subtract(sig.C.Multiply(r))
+	return
+}
+
+// VerifyTxProof returns true if sig is a proof of NewTxProof for R, A, B and D
+func VerifyTxProof(h Hash, R, A, B, D *Point, sig *Signature) (r bool) {
+	if sig == nil || sig.C == nil || sig.R == nil || R.Equal(PointI()) == 1 || D.Equal(PointI()) == 1 {
+		return
+	}
+	// X = r' G + c R or r' B + c R, Y = r' A + c D
+	X := sig.R.DoubleScalarBaseMult(sig.C, R)
+	if B != nil {
+		X = MultiScalarMult(NewScalarSliceFrom(sig.R, sig.C), []*Point{B, R})
+	}
+	Y := MultiScalarMult(NewScalarSliceFrom(sig.R, sig.C), []*Point{A, D})
+	r = txProofChallenge(h, R, A, B, D, X, Y).Equal(sig.C) == 1
+	return
+}
+
+func txProofChallenge(h Hash, R, A, B, D, X, Y *Point) (c *Scalar) {
+	separator := Keccak256([]byte("TXPROOF_V2"))
+	Bbytes := make([]byte, KeyLength)
+	if B != nil {
+		Bbytes = B.Bytes()
+	}
+	c = HashToScalar(h[:], D.Bytes(), X.Bytes(), Y.Bytes(), separator[:], R.Bytes(), A.Bytes(), Bbytes)
+	return
+}
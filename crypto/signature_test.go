@@ -0,0 +1,84 @@
+package crypto
+
+import "testing"
+
+func TestSignature(t *testing.T) {
+	h := Keccak256([]byte("message"))
+	k, K := NewKeyPair()
+	sig := NewSignature(h, K, k)
+	if !sig.Verify(h, K) {
+		t.Errorf("valid signature does not verify")
+	}
+	if sig.Verify(Keccak256([]byte("other message")), K) {
+		t.Errorf("signature verifies for another message")
+	}
+	if sig.Verify(h, K.Add(PointG())) {
+		t.Errorf("signature verifies for another key")
+	}
+}
+
+// like the ring signature tests of monero/tests/crypto
+func TestRingSignature(t *testing.T) {
+	h := Keccak256([]byte("message"))
+	for _, n := range []int{1, 4} {
+		var P []*PublicKey
+		for i := 0; i < n; i++ {
+			_, Pi := NewKeyPair()
+			P = append(P, Pi)
+		}
+		l := n - 1
+		var k *PrivateKey
+		k, P[l] = NewKeyPair()
+		KI := k.KeyImage()
+		sig := NewRingSignature(h, KI, P, k, l)
+		if !sig.Verify(h, KI, P) {
+			t.Errorf("%d members: valid signature does not verify", n)
+		}
+		if sig.Verify(Keccak256([]byte("other message")), KI, P) {
+			t.Errorf("%d members: signature verifies for another message", n)
+		}
+		if sig.Verify(h, NewRandomScalar().KeyImage(), P) {
+			t.Errorf("%d members: signature verifies for another key image", n)
+		}
+	}
+}
+
+func TestDLEQProof(t *testing.T) {
+	h := Keccak256([]byte("message"))
+	k, K := NewKeyPair()
+	B := K.HashToEC()
+	proof := NewDLEQProof(h, B, k)
+	if !proof.Verify(h, B, K, B.ScalarMult(k)) {
+		t.Errorf("valid proof does not verify")
+	}
+	if proof.Verify(Keccak256([]byte("other message")), B, K, B.ScalarMult(k)) {
+		t.Errorf("proof verifies for another message")
+	}
+	if proof.Verify(h, B, K, B.ScalarMult(NewRandomScalar())) {
+		t.Errorf("proof verifies for another private key")
+	}
+}
+
+func TestTxProof(t *testing.T) {
+	h := Keccak256([]byte("message"))
+	r := NewRandomScalar()
+	_, A := NewKeyPair()
+	_, B := NewKeyPair()
+	D := A.ScalarMult(r)
+	for _, B := range []*Point{nil, B} {
+		R := r.MultG()
+		if B != nil {
+			R = B.ScalarMult(r)
+		}
+		sig := NewTxProof(h, R, A, B, D, r)
+		if !VerifyTxProof(h, R, A, B, D, sig) {
+			t.Errorf("valid proof does not verify")
+		}
+		if VerifyTxProof(Keccak256([]byte("other message")), R, A, B, D, sig) {
+			t.Errorf("proof verifies for another message")
+		}
+		if VerifyTxProof(h, R, A, B, D.Add(PointG()), sig) {
+			t.Errorf("proof verifies for another shared secret")
+		}
+	}
+}
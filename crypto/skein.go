@@ -0,0 +1,119 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Skein-512-256 (version 1.3), one of the four final hashes of cn_slow_hash
+//monero/src/crypto/skein.c
+
+const (
+	skeinBlockSize  = 64
+	skeinWords      = 8
+	skeinParity     = 0x1bd11bdaa9fc1a22
+	skeinTypeConfig = 4
+	skeinTypeMsg    = 48
+	skeinTypeOut    = 63
+	skeinFlagFirst  = 1 << 62
+	skeinFlagFinal  = 1 << 63
+)
+
+var skeinRotations = [8][4]int{
+	{46, 36, 19, 37},
+	{33, 27, 14, 42},
+	{17, 49, 36, 39},
+	{44, 9, 54, 56},
+	{39, 30, 34, 24},
+	{13, 50, 10, 17},
+	{25, 29, 39, 43},
+	{8, 35, 56, 22},
+}
+
+var skeinPermutation = [skeinWords]int{2, 1, 4, 7, 6, 5, 0, 3}
+
+// threefish512 encrypts block with key and tweak
+func threefish512(key *[skeinWords]uint64, tweak [2]uint64, block *[skeinWords]uint64) (r [skeinWords]uint64) {
+	var k [skeinWords + 1]uint64
+	copy(k[:], key[:])
+	k[skeinWords] = skeinParity
+	for i := 0; i < skeinWords; i++ {
+		k[skeinWords] ^= k[i]
+	}
+	t := [3]uint64{tweak[0], tweak[1], tweak[0] ^ tweak[1]}
+
+	addSubkey := func(v *[skeinWords]uint64, s int) {
+		for i := 0; i < skeinWords; i++ {
+			v[i] += k[(s+i)%(skeinWords+1)]
+		}
+		v[5] += t[s%3]
+		v[6] += t[(s+1)%3]
+		v[7] += uint64(s)
+	}
+
+	v := *block
+	for d := 0; d < 72; d++ {
+		if d%4 == 0 {
+			addSubkey(&v, d/4)
+		}
+		for j := 0; j < skeinWords/2; j++ {
+			v[2*j] += v[2*j+1]
+			v[2*j+1] = bits.RotateLeft64(v[2*j+1], skeinRotations[d%8][j]) ^ v[2*j]
+		}
+		var p [skeinWords]uint64
+		for i := range p {
+			p[i] = v[skeinPermutation[i]]
+		}
+		v = p
+	}
+	addSubkey(&v, 72/4)
+	r = v
+	return
+}
+
+// skeinUBI processes msg with the unique block iteration chaining mode
+func skeinUBI(h *[skeinWords]uint64, msg []byte, blockType uint64) {
+	var position uint64
+	first := uint64(skeinFlagFirst)
+	for {
+		var block [skeinBlockSize]byte
+		n := copy(block[:], msg)
+		msg = msg[n:]
+		position += uint64(n)
+		tweak := [2]uint64{position, blockType<<56 | first}
+		if len(msg) == 0 {
+			tweak[1] |= skeinFlagFinal
+		}
+		var m [skeinWords]uint64
+		for i := range m {
+			m[i] = binary.LittleEndian.Uint64(block[8*i:])
+		}
+		c := threefish512(h, tweak, &m)
+		for i := range h {
+			h[i] = c[i] ^ m[i]
+		}
+		first = 0
+		if len(msg) == 0 {
+			return
+		}
+	}
+}
+
+func skein512256(data []byte) (result Hash) {
+	var h [skeinWords]uint64
+
+	// config block: schema "SHA3", version 1, output length in bits
+	config := make([]byte, 32)
+	copy(config, "SHA3")
+	binary.LittleEndian.PutUint16(config[4:], 1)
+	binary.LittleEndian.PutUint64(config[8:], 256)
+	skeinUBI(&h, config, skeinTypeConfig)
+
+	skeinUBI(&h, data, skeinTypeMsg)
+
+	skeinUBI(&h, make([]byte, 8), skeinTypeOut)
+	for i := 0; i < HashLength/8; i++ {
+		binary.LittleEndian.PutUint64(result[8*i:], h[i])
+	}
+	return
+}
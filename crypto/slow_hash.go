@@ -0,0 +1,278 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// CryptoNight (cn_slow_hash variant 0), used by the reference wallet to derive the chacha key from the password
+//monero/src/crypto/slow-hash.c
+
+const (
+	slowHashMemory     = 1 << 21 //2 MiB scratchpad
+	slowHashIterations = 1 << 20
+	slowHashInitSize   = 128 //bytes of the keccak state used to fill the scratchpad
+	aesBlockSize       = 16
+	aesRounds          = 10
+)
+
+// CnSlowHash returns cn_slow_hash(data) for variant 0
+func CnSlowHash(data []byte) (result Hash) {
+	var state [200]byte
+	keccak1600(data, &state)
+
+	roundKeys := aesExpandKey(state[:32])
+	var text [slowHashInitSize]byte
+	copy(text[:], state[64:64+slowHashInitSize])
+
+	scratchpad := make([]byte, slowHashMemory)
+	for i := 0; i < slowHashMemory/slowHashInitSize; i++ {
+		for j := 0; j < slowHashInitSize; j += aesBlockSize {
+			aesPseudoRound(text[j:j+aesBlockSize], &roundKeys)
+		}
+		copy(scratchpad[i*slowHashInitSize:], text[:])
+	}
+
+	var a, b, c, c1 [2]uint64
+	a[0] = binary.LittleEndian.Uint64(state[0:]) ^ binary.LittleEndian.Uint64(state[32:])
+	a[1] = binary.LittleEndian.Uint64(state[8:]) ^ binary.LittleEndian.Uint64(state[40:])
+	b[0] = binary.LittleEndian.Uint64(state[16:]) ^ binary.LittleEndian.Uint64(state[48:])
+	b[1] = binary.LittleEndian.Uint64(state[24:]) ^ binary.LittleEndian.Uint64(state[56:])
+
+	const mask = slowHashMemory - aesBlockSize
+	var block [aesBlockSize]byte
+	for i := 0; i < slowHashIterations/2; i++ {
+		// iteration 1
+		j := a[0] & mask
+		p := scratchpad[j : j+aesBlockSize]
+		binary.LittleEndian.PutUint64(block[0:], a[0])
+		binary.LittleEndian.PutUint64(block[8:], a[1])
+		aesRound(p, p, block[:])
+		c1[0] = binary.LittleEndian.Uint64(p[0:])
+		c1[1] = binary.LittleEndian.Uint64(p[8:])
+		binary.LittleEndian.PutUint64(p[0:], c1[0]^b[0])
+		binary.LittleEndian.PutUint64(p[8:], c1[1]^b[1])
+
+		// iteration 2
+		j = c1[0] & mask
+		p = scratchpad[j : j+aesBlockSize]
+		c[0] = binary.LittleEndian.Uint64(p[0:])
+		c[1] = binary.LittleEndian.Uint64(p[8:])
+		hi, lo := bits.Mul64(c1[0], c[0])
+		a[0] += hi
+		a[1] += lo
+		binary.LittleEndian.PutUint64(p[0:], a[0])
+		binary.LittleEndian.PutUint64(p[8:], a[1])
+		a[0] ^= c[0]
+		a[1] ^= c[1]
+		b = c1
+	}
+
+	copy(text[:], state[64:64+slowHashInitSize])
+	roundKeys = aesExpandKey(state[32:64])
+	for i := 0; i < slowHashMemory/slowHashInitSize; i++ {
+		for j := 0; j < slowHashInitSize; j += aesBlockSize {
+			for k := 0; k < aesBlockSize; k++ {
+				text[j+k] ^= scratchpad[i*slowHashInitSize+j+k]
+			}
+			aesPseudoRound(text[j:j+aesBlockSize], &roundKeys)
+		}
+	}
+	copy(state[64:], text[:])
+
+	var lanes [25]uint64
+	for i := range lanes {
+		lanes[i] = binary.LittleEndian.Uint64(state[i*8:])
+	}
+	keccakF1600(&lanes)
+	for i := range lanes {
+		binary.LittleEndian.PutUint64(state[i*8:], lanes[i])
+	}
+
+	switch state[0] & 3 {
+	case 0:
+		result = blake256(state[:])
+	case 1:
+		result = groestl256(state[:])
+	case 2:
+		result = jh256(state[:])
+	case 3:
+		result = skein512256(state[:])
+	}
+	return
+}
+
+//keccak
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+var keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+
+var keccakPiLanes = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+
+// keccakF1600 applies the 24 round keccak permutation to st
+func keccakF1600(st *[25]uint64) {
+	var bc [5]uint64
+	for round := 0; round < 24; round++ {
+		// theta
+		for i := 0; i < 5; i++ {
+			bc[i] = st[i] ^ st[i+5] ^ st[i+10] ^ st[i+15] ^ st[i+20]
+		}
+		for i := 0; i < 5; i++ {
+			t := bc[(i+4)%5] ^ bits.RotateLeft64(bc[(i+1)%5], 1)
+			for j := 0; j < 25; j += 5 {
+				st[j+i] ^= t
+			}
+		}
+		// rho and pi
+		t := st[1]
+		for i := 0; i < 24; i++ {
+			j := keccakPiLanes[i]
+			bc[0] = st[j]
+			st[j] = bits.RotateLeft64(t, keccakRotations[i])
+			t = bc[0]
+		}
+		// chi
+		for j := 0; j < 25; j += 5 {
+			for i := 0; i < 5; i++ {
+				bc[i] = st[j+i]
+			}
+			for i := 0; i < 5; i++ {
+				st[j+i] ^= ^bc[(i+1)%5] & bc[(i+2)%5]
+			}
+		}
+		// iota
+		st[0] ^= keccakRoundConstants[round]
+	}
+}
+
+// keccak1600 absorbs data with the keccak-256 rate and returns the whole 200 byte state
+func keccak1600(data []byte, state *[200]byte) {
+	const rate = 136
+	var st [25]uint64
+	for len(data) >= rate {
+		for i := 0; i < rate/8; i++ {
+			st[i] ^= binary.LittleEndian.Uint64(data[i*8:])
+		}
+		keccakF1600(&st)
+		data = data[rate:]
+	}
+	var last [rate]byte
+	copy(last[:], data)
+	last[len(data)] = 1
+	last[rate-1] |= 0x80
+	for i := 0; i < rate/8; i++ {
+		st[i] ^= binary.LittleEndian.Uint64(last[i*8:])
+	}
+	keccakF1600(&st)
+	for i := range st {
+		binary.LittleEndian.PutUint64(state[i*8:], st[i])
+	}
+}
+
+//aes
+
+var aesSbox [256]byte
+var aesTable [4][256]uint32
+
+func init() {
+	// the sbox is the multiplicative inverse in GF(2^8) followed by the affine transform
+	p, q := byte(1), byte(1)
+	for {
+		// p * 3
+		p = p ^ (p << 1) ^ gfReduce(p)
+		// q / 3
+		q ^= q << 1
+		q ^= q << 2
+		q ^= q << 4
+		if q&0x80 != 0 {
+			q ^= 0x09
+		}
+		x := q ^ bits.RotateLeft8(q, 1) ^ bits.RotateLeft8(q, 2) ^ bits.RotateLeft8(q, 3) ^ bits.RotateLeft8(q, 4)
+		aesSbox[p] = x ^ 0x63
+		if p == 1 {
+			break
+		}
+	}
+	aesSbox[0] = 0x63
+
+	for i := 0; i < 256; i++ {
+		s := aesSbox[i]
+		s2 := gfDouble(s)
+		s3 := s2 ^ s
+		t := uint32(s2) | uint32(s)<<8 | uint32(s)<<16 | uint32(s3)<<24
+		for k := 0; k < 4; k++ {
+			aesTable[k][i] = bits.RotateLeft32(t, 8*k)
+		}
+	}
+}
+
+func gfReduce(b byte) (r byte) {
+	if b&0x80 != 0 {
+		r = 0x1b
+	}
+	return
+}
+
+// gfDouble returns 2*b in GF(2^8)
+func gfDouble(b byte) (r byte) {
+	r = b<<1 ^ gfReduce(b)
+	return
+}
+
+// aesRound performs SubBytes, ShiftRows, MixColumns and AddRoundKey on in and writes the result to out
+func aesRound(out, in, key []byte) {
+	var col [4]uint32
+	for c := 0; c < 4; c++ {
+		col[c] = aesTable[0][in[4*c]] ^
+			aesTable[1][in[4*((c+1)%4)+1]] ^
+			aesTable[2][in[4*((c+2)%4)+2]] ^
+			aesTable[3][in[4*((c+3)%4)+3]] ^
+			binary.LittleEndian.Uint32(key[4*c:])
+	}
+	for c := 0; c < 4; c++ {
+		binary.LittleEndian.PutUint32(out[4*c:], col[c])
+	}
+}
+
+// aesPseudoRound applies ten full aes rounds to block in place
+func aesPseudoRound(block []byte, roundKeys *[aesRounds][aesBlockSize]byte) {
+	for i := 0; i < aesRounds; i++ {
+		aesRound(block, block, roundKeys[i][:])
+	}
+}
+
+// aesExpandKey returns the first ten round keys of the aes-256 key schedule
+func aesExpandKey(key []byte) (roundKeys [aesRounds][aesBlockSize]byte) {
+	const nk = 8
+	var w [aesRounds * 4][4]byte
+	for i := 0; i < nk; i++ {
+		copy(w[i][:], key[4*i:])
+	}
+	rcon := byte(1)
+	for i := nk; i < len(w); i++ {
+		t := w[i-1]
+		if i%nk == 0 {
+			t = [4]byte{aesSbox[t[1]] ^ rcon, aesSbox[t[2]], aesSbox[t[3]], aesSbox[t[0]]}
+			rcon = gfDouble(rcon)
+		} else if i%nk == 4 {
+			t = [4]byte{aesSbox[t[0]], aesSbox[t[1]], aesSbox[t[2]], aesSbox[t[3]]}
+		}
+		for k := 0; k < 4; k++ {
+			w[i][k] = w[i-nk][k] ^ t[k]
+		}
+	}
+	for i := range roundKeys {
+		for k := 0; k < 4; k++ {
+			copy(roundKeys[i][4*k:], w[4*i+k][:])
+		}
+	}
+	return
+}
@@ -0,0 +1,65 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCnSlowHash(t *testing.T) {
+	//monero/tests/hash/tests-slow.txt
+	tests := []struct {
+		data string
+		hash string
+	}{
+		{"This is a test", "a084f01d1437a09c6985401b60d43554ae105802c5f5d8a9b3253649c0be6605"},
+		{"de omnibus dubitandum", "2f8e3df40bd11f9ac90c743ca8e32bb391da4fb98612aa3b6cdc639ee00b31f5"},
+		{"abundans cautela non nocet", "722fa8ccd594d40e4a41f3822734304c8d5eff7e1b528408e2229da38ba553c4"},
+		{"caveat emptor", "bbec2cacf69866a8e740380fe7b818fc78f8571221742d729d9d02d7f8989b87"},
+		{"ex nihilo nihil fit", "b1257de4efc5ce28c6b40ceb1c6c8f812a64634eb3e81c5220bee9b2b76a6f05"},
+	}
+	for _, test := range tests {
+		got := CnSlowHash([]byte(test.data))
+		if hex.EncodeToString(got[:]) != test.hash {
+			t.Errorf("%s: want: %s, got: %x", test.data, test.hash, got)
+		}
+	}
+}
+
+func TestSlowHashFinalHashes(t *testing.T) {
+	// digests of the empty message
+	tests := []struct {
+		name string
+		f    func([]byte) Hash
+		hash string
+	}{
+		{"blake256", blake256, "716f6e863f744b9ac22c97ec7b76ea5f5908bc5b2f67c61510bfc4751384ea7a"},
+		{"groestl256", groestl256, "1a52d11d550039be16107f9c58db9ebcc417f16f736adb2502567119f0083467"},
+		{"jh256", jh256, "46e64619c18bb0a92a5e87185a47eef83ca747b8fcc8e1412921357e326df434"},
+		{"skein512256", skein512256, "39ccc4554a8b31853b9de7a1fe638a24cce6b35a55f2431009e18780335d2621"},
+	}
+	for _, test := range tests {
+		got := test.f(nil)
+		if hex.EncodeToString(got[:]) != test.hash {
+			t.Errorf("%s: want: %s, got: %x", test.name, test.hash, got)
+		}
+	}
+}
+
+func TestKeccak1600(t *testing.T) {
+	var state [200]byte
+	// longer than the 136 byte rate
+	data := bytes.Repeat([]byte("gomonero"), 30)
+	keccak1600(data, &state)
+	want := Keccak256(data)
+	if hex.EncodeToString(state[:HashLength]) != hex.EncodeToString(want[:]) {
+		t.Errorf("want: %x, got: %x", want, state[:HashLength])
+	}
+}
+
+func BenchmarkCnSlowHash(b *testing.B) {
+	data := []byte("This is a test")
+	for n := 0; n < b.N; n++ {
+		CnSlowHash(data)
+	}
+}
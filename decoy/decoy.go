@@ -0,0 +1,187 @@
+package decoy
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"gomonero/err_msg"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// decoy selection of the reference wallet, the ages of spent outputs follow a gamma distribution in log seconds
+//
+// monero/src/wallet/wallet2.cpp gamma_picker, get_outs
+const (
+	GammaShape        = 19.28
+	GammaScale        = 1 / 1.61
+	DefaultRingSize   = 16
+	difficultyTarget  = 120                             //DIFFICULTY_TARGET_V2, seconds
+	spendableAge      = 10                              //CRYPTONOTE_DEFAULT_TX_SPENDABLE_AGE, blocks
+	defaultUnlockTime = spendableAge * difficultyTarget //seconds before a new output can be spent
+	recentSpendWindow = 15 * difficultyTarget           //seconds, spends younger than the unlock time are spread over it
+	blocksInAYear     = 86400 * 365 / difficultyTarget
+	maxPickAttempts   = 100 //picks per ring member before giving up
+)
+
+// Source provides the output distribution of the blockchain
+type Source interface {
+	// RCTOffsets returns, for each block from the genesis block, the number of RingCT outputs in the blockchain up to
+	// and including that block (get_output_distribution with cumulative set)
+	RCTOffsets() ([]uint64, error)
+}
+
+// StaticSource is a fixed output distribution
+type StaticSource []uint64
+
+func (s StaticSource) RCTOffsets() (offsets []uint64, err error) {
+	offsets = s
+	return
+}
+
+// cryptoSource is a rand.Source reading crypto/rand
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() (r uint64) {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	r = binary.LittleEndian.Uint64(b[:])
+	return
+}
+
+func (s cryptoSource) Int63() (r int64) {
+	r = int64(s.Uint64() >> 1)
+	return
+}
+
+// Seed does nothing, crypto/rand can not be seeded
+func (cryptoSource) Seed(int64) {}
+
+// Picker picks ring members from the outputs of the blockchain
+type Picker struct {
+	rng               *rand.Rand
+	offsets           []uint64
+	numOutputs        uint64  //outputs in unlocked blocks
+	averageOutputTime float64 //seconds between outputs over the last year
+}
+
+// NewPicker returns a picker over the distribution of source, rng reads crypto/rand if nil, a seeded rng is only for
+// tests as an observer recovering its state would predict the decoys and find the real spends
+// blocks younger than the spendable age are never picked
+func NewPicker(source Source, rng *rand.Rand) (p *Picker, err error) {
+	offsets, err := source.RCTOffsets()
+	if err != nil {
+		return
+	}
+	if len(offsets) <= spendableAge {
+		err = err_msg.ErrDecoyDistribution
+		return
+	}
+	blocksToConsider := len(offsets)
+	if blocksToConsider > blocksInAYear {
+		blocksToConsider = blocksInAYear
+	}
+	outputsToConsider := offsets[len(offsets)-1]
+	if blocksToConsider < len(offsets) {
+		outputsToConsider -= offsets[len(offsets)-blocksToConsider-1]
+	}
+	unlocked := offsets[:len(offsets)-spendableAge]
+	if unlocked[len(unlocked)-1] == 0 || outputsToConsider == 0 {
+		err = err_msg.ErrDecoyDistribution
+		return
+	}
+	if rng == nil {
+		rng = rand.New(cryptoSource{})
+	}
+	p = &Picker{
+		rng:               rng,
+		offsets:           unlocked,
+		numOutputs:        unlocked[len(unlocked)-1],
+		averageOutputTime: float64(difficultyTarget*blocksToConsider) / float64(outputsToConsider),
+	}
+	return
+}
+
+// NumOutputs returns the number of outputs which can be picked
+func (p *Picker) NumOutputs() (n uint64) {
+	n = p.numOutputs
+	return
+}
+
+// Pick returns the global index of a random output, ok is false if the age picked is older than the blockchain or
+// falls in a block without outputs, the caller picks again
+func (p *Picker) Pick() (index uint64, ok bool) {
+	x := math.Exp(p.gamma())
+	if x > defaultUnlockTime {
+		x -= defaultUnlockTime
+	} else {
+		x = float64(p.rng.Int63n(recentSpendWindow))
+	}
+	outputIndex := uint64(x / p.averageOutputTime)
+	if outputIndex >= p.numOutputs {
+		return
+	}
+	outputIndex = p.numOutputs - 1 - outputIndex
+
+	// the block containing the output, a random output of that block is picked
+	block := sort.Search(len(p.offsets), func(i int) bool { return p.offsets[i] >= outputIndex })
+	var first uint64
+	if block > 0 {
+		first = p.offsets[block-1]
+	}
+	n := p.offsets[block] - first
+	if n == 0 {
+		return
+	}
+	index = first + uint64(p.rng.Int63n(int64(n)))
+	ok = true
+	return
+}
+
+// gamma returns a sample of the gamma distribution with GammaShape and GammaScale
+// Marsaglia and Tsang, A Simple Method for Generating Gamma Variables, shape >= 1
+func (p *Picker) gamma() (r float64) {
+	d := GammaShape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := p.rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := p.rng.Float64()
+		if u < 1-0.0331*x*x*x*x || math.Log(u) < 0.5*x*x+d*(1-v+math.Log(v)) {
+			r = d * v * GammaScale
+			return
+		}
+	}
+}
+
+// Ring returns the sorted global indices of a ring of ringSize members containing real, decoys for which usable
+// returns false are skipped, usable may be nil
+func (p *Picker) Ring(real uint64, ringSize int, usable func(index uint64) bool) (ring []uint64, err error) {
+	if ringSize <= 0 || uint64(ringSize) > p.numOutputs || real >= p.numOutputs {
+		err = err_msg.ErrDecoyRingSize
+		return
+	}
+	picked := map[uint64]bool{real: true}
+	ring = append(ring, real)
+	for attempts := 0; len(ring) < ringSize; attempts++ {
+		if attempts >= maxPickAttempts*ringSize {
+			ring = nil
+			err = err_msg.ErrDecoySelection
+			return
+		}
+		index, ok := p.Pick()
+		if !ok || picked[index] || (usable != nil && !usable(index)) {
+			continue
+		}
+		picked[index] = true
+		ring = append(ring, index)
+	}
+	sort.Slice(ring, func(a, b int) bool { return ring[a] < ring[b] })
+	return
+}
@@ -0,0 +1,168 @@
+package decoy
+
+import (
+	"gomonero/err_msg"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// newTestSource returns a blockchain of blocks blocks with outputsPerBlock outputs each
+func newTestSource(blocks int, outputsPerBlock uint64) (s StaticSource) {
+	s = make(StaticSource, blocks)
+	for i := range s {
+		s[i] = uint64(i+1) * outputsPerBlock
+	}
+	return
+}
+
+// gammaCDF returns the regularized lower incomplete gamma function P(a, x), the cdf of the gamma distribution with
+// shape a and scale 1 at x
+func gammaCDF(a, x float64) (r float64) {
+	lgamma, _ := math.Lgamma(a + 1)
+	term := math.Exp(a*math.Log(x) - x - lgamma)
+	for n := 1; n < 1000 && term > 1e-15; n++ {
+		r += term
+		term *= x / (a + float64(n))
+	}
+	return
+}
+
+func TestGamma(t *testing.T) {
+	p, err := NewPicker(newTestSource(100, 1), rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const samples = 100000
+	var sum, sumSquares float64
+	for i := 0; i < samples; i++ {
+		x := p.gamma()
+		sum += x
+		sumSquares += x * x
+	}
+	mean := sum / samples
+	variance := sumSquares/samples - mean*mean
+	if want := GammaShape * GammaScale; math.Abs(mean-want) > 0.05 {
+		t.Errorf("want mean %f, got %f", want, mean)
+	}
+	if want := GammaShape * GammaScale * GammaScale; math.Abs(variance-want) > 0.2 {
+		t.Errorf("want variance %f, got %f", want, variance)
+	}
+}
+
+// the ages of picked outputs follow the reference distribution, e^gamma seconds minus the unlock time
+func TestPickDistribution(t *testing.T) {
+	const outputsPerBlock = 10
+	p, err := NewPicker(newTestSource(300000, outputsPerBlock), rand.New(rand.NewSource(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := float64(difficultyTarget) / outputsPerBlock; p.averageOutputTime != want {
+		t.Errorf("want average output time %f, got %f", want, p.averageOutputTime)
+	}
+
+	quantiles := []float64{8.5, 10, 11, 12, 13, 14, 15}
+	counts := make([]int, len(quantiles))
+	const samples = 200000
+	picked := 0
+	for picked < samples {
+		index, ok := p.Pick()
+		if !ok {
+			continue
+		}
+		picked++
+		age := float64(p.NumOutputs()-1-index) * p.averageOutputTime
+		for n, q := range quantiles {
+			if math.Log(age+defaultUnlockTime) <= q {
+				counts[n]++
+			}
+		}
+	}
+	// ages older than the blockchain are picked again
+	oldest := math.Log(float64(p.NumOutputs())*p.averageOutputTime + defaultUnlockTime)
+	for n, q := range quantiles {
+		want := gammaCDF(GammaShape, q/GammaScale) / gammaCDF(GammaShape, oldest/GammaScale)
+		got := float64(counts[n]) / samples
+		if math.Abs(got-want) > 0.01 {
+			t.Errorf("P(log age <= %.1f): want %f, got %f", q, want, got)
+		}
+	}
+}
+
+func TestRing(t *testing.T) {
+	source := newTestSource(5000, 3)
+	p, err := NewPicker(source, rand.New(rand.NewSource(3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := source[len(source)-1-spendableAge]; p.NumOutputs() != want {
+		t.Errorf("want %d unlocked outputs, got %d", want, p.NumOutputs())
+	}
+	odd := func(index uint64) bool { return index%2 == 1 }
+	ring, err := p.Ring(1000, DefaultRingSize, odd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ring) != DefaultRingSize {
+		t.Fatalf("want %d members, got %d", DefaultRingSize, len(ring))
+	}
+	real := false
+	for n, index := range ring {
+		if n > 0 && index <= ring[n-1] {
+			t.Errorf("ring is not sorted or has duplicates: %v", ring)
+		}
+		if index == 1000 {
+			real = true
+		} else if !odd(index) {
+			t.Errorf("unusable decoy %d picked", index)
+		}
+		if index >= p.NumOutputs() {
+			t.Errorf("locked output %d picked", index)
+		}
+	}
+	if !real {
+		t.Errorf("real output not in ring %v", ring)
+	}
+
+	// the same seed picks the same rings
+	for seed := int64(0); seed < 3; seed++ {
+		p1, _ := NewPicker(source, rand.New(rand.NewSource(seed)))
+		p2, _ := NewPicker(source, rand.New(rand.NewSource(seed)))
+		r1, _ := p1.Ring(10, DefaultRingSize, nil)
+		r2, _ := p2.Ring(10, DefaultRingSize, nil)
+		for n := range r1 {
+			if r1[n] != r2[n] {
+				t.Errorf("seed %d: rings differ %v %v", seed, r1, r2)
+				break
+			}
+		}
+	}
+}
+
+func TestRingErrors(t *testing.T) {
+	if _, err := NewPicker(newTestSource(spendableAge, 5), nil); err != err_msg.ErrDecoyDistribution {
+		t.Errorf("want %v, got %v", err_msg.ErrDecoyDistribution, err)
+	}
+	p, err := NewPicker(newTestSource(spendableAge+1, 5), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Ring(0, 6, nil); err != err_msg.ErrDecoyRingSize {
+		t.Errorf("want %v, got %v", err_msg.ErrDecoyRingSize, err)
+	}
+	if _, err = p.Ring(0, 3, func(uint64) bool { return false }); err != err_msg.ErrDecoySelection {
+		t.Errorf("want %v, got %v", err_msg.ErrDecoySelection, err)
+	}
+}
+
+func TestCryptoSource(t *testing.T) {
+	var s cryptoSource
+	if s.Uint64() == s.Uint64() {
+		t.Errorf("crypto/rand source repeats")
+	}
+	for i := 0; i < 64; i++ {
+		if s.Int63() < 0 {
+			t.Fatalf("Int63 is negative")
+		}
+	}
+}
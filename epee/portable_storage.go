@@ -0,0 +1,380 @@
+// Package epee implements the binary portable storage format used by the monero reference implementation
+// to serialize key-value data such as the account keys in a wallet .keys file
+//
+// monero/contrib/epee/include/storages/portable_storage_to_bin.h
+// monero/contrib/epee/include/storages/portable_storage_from_bin.h
+package epee
+
+import (
+	"bytes"
+	"encoding/binary"
+	"gomonero/err_msg"
+	"math"
+	"sort"
+)
+
+const (
+	signatureA    = 0x01011101
+	signatureB    = 0x01020101
+	formatVersion = 1
+
+	maxDepth = 100
+)
+
+// storage entry types
+const (
+	TypeInt64   = 1
+	TypeInt32   = 2
+	TypeInt16   = 3
+	TypeInt8    = 4
+	TypeUint64  = 5
+	TypeUint32  = 6
+	TypeUint16  = 7
+	TypeUint8   = 8
+	TypeDouble  = 9
+	TypeString  = 10
+	TypeBool    = 11
+	TypeObject  = 12
+	TypeArray   = 13
+	FlagArray   = 0x80
+	typeInvalid = 0
+)
+
+// Section is a portable storage object
+//
+// values are int64, int32, int16, int8, uint64, uint32, uint16, uint8, float64, []byte (string), bool, Section,
+// or a homogeneous []interface{} of one of those types
+// entries are serialized in key order, like the std::map used by the reference implementation
+type Section map[string]interface{}
+
+// Bytes returns the string entry key
+func (s Section) Bytes(key string) (r []byte, ok bool) {
+	r, ok = s[key].([]byte)
+	return
+}
+
+// Section returns the object entry key
+func (s Section) Section(key string) (r Section, ok bool) {
+	r, ok = s[key].(Section)
+	return
+}
+
+// Uint64 returns the unsigned integer entry key, widened to 64 bits
+func (s Section) Uint64(key string) (r uint64, ok bool) {
+	ok = true
+	switch v := s[key].(type) {
+	case uint64:
+		r = v
+	case uint32:
+		r = uint64(v)
+	case uint16:
+		r = uint64(v)
+	case uint8:
+		r = uint64(v)
+	default:
+		ok = false
+	}
+	return
+}
+
+// Marshal returns the portable storage serialization of s
+func Marshal(s Section) (r []byte, err error) {
+	buf := new(bytes.Buffer)
+	var header [9]byte
+	binary.LittleEndian.PutUint32(header[0:], signatureA)
+	binary.LittleEndian.PutUint32(header[4:], signatureB)
+	header[8] = formatVersion
+	buf.Write(header[:])
+	if err = writeSection(buf, s); err != nil {
+		return
+	}
+	r = buf.Bytes()
+	return
+}
+
+// Unmarshal parses the portable storage serialization in b
+func Unmarshal(b []byte) (s Section, err error) {
+	if len(b) < 9 || binary.LittleEndian.Uint32(b[0:]) != signatureA || binary.LittleEndian.Uint32(b[4:]) != signatureB {
+		err = err_msg.ErrPortableStorageSignature
+		return
+	}
+	if b[8] != formatVersion {
+		err = err_msg.ErrPortableStorageVersion
+		return
+	}
+	r := &reader{b: b[9:]}
+	s, err = r.section(0)
+	return
+}
+
+// PutVarint returns the portable storage varint encoding of v, the two low bits mark the size
+func PutVarint(v uint64) (r []byte) {
+	switch {
+	case v < 1<<6:
+		r = []byte{byte(v << 2)}
+	case v < 1<<14:
+		r = make([]byte, 2)
+		binary.LittleEndian.PutUint16(r, uint16(v<<2|1))
+	case v < 1<<30:
+		r = make([]byte, 4)
+		binary.LittleEndian.PutUint32(r, uint32(v<<2|2))
+	default:
+		r = make([]byte, 8)
+		binary.LittleEndian.PutUint64(r, v<<2|3)
+	}
+	return
+}
+
+func writeSection(buf *bytes.Buffer, s Section) (err error) {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		if len(k) > math.MaxUint8 {
+			err = err_msg.ErrPortableStorageType
+			return
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf.Write(PutVarint(uint64(len(keys))))
+	for _, k := range keys {
+		buf.WriteByte(byte(len(k)))
+		buf.WriteString(k)
+		if err = writeEntry(buf, s[k]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func valueType(v interface{}) (t byte) {
+	switch v.(type) {
+	case int64:
+		t = TypeInt64
+	case int32:
+		t = TypeInt32
+	case int16:
+		t = TypeInt16
+	case int8:
+		t = TypeInt8
+	case uint64:
+		t = TypeUint64
+	case uint32:
+		t = TypeUint32
+	case uint16:
+		t = TypeUint16
+	case uint8:
+		t = TypeUint8
+	case float64:
+		t = TypeDouble
+	case []byte:
+		t = TypeString
+	case bool:
+		t = TypeBool
+	case Section:
+		t = TypeObject
+	default:
+		t = typeInvalid
+	}
+	return
+}
+
+func writeEntry(buf *bytes.Buffer, v interface{}) (err error) {
+	if a, ok := v.([]interface{}); ok {
+		if len(a) == 0 {
+			// the element type of an empty array is irrelevant
+			buf.WriteByte(FlagArray | TypeString)
+			buf.Write(PutVarint(0))
+			return
+		}
+		t := valueType(a[0])
+		if t == typeInvalid {
+			err = err_msg.ErrPortableStorageType
+			return
+		}
+		buf.WriteByte(FlagArray | t)
+		buf.Write(PutVarint(uint64(len(a))))
+		for _, e := range a {
+			if valueType(e) != t {
+				err = err_msg.ErrPortableStorageType
+				return
+			}
+			if err = writeValue(buf, e); err != nil {
+				return
+			}
+		}
+		return
+	}
+	t := valueType(v)
+	if t == typeInvalid {
+		err = err_msg.ErrPortableStorageType
+		return
+	}
+	buf.WriteByte(t)
+	err = writeValue(buf, v)
+	return
+}
+
+func writeValue(buf *bytes.Buffer, v interface{}) (err error) {
+	switch v := v.(type) {
+	case []byte:
+		buf.Write(PutVarint(uint64(len(v))))
+		buf.Write(v)
+	case bool:
+		if v {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case Section:
+		err = writeSection(buf, v)
+	default:
+		err = binary.Write(buf, binary.LittleEndian, v)
+	}
+	return
+}
+
+type reader struct {
+	b []byte
+}
+
+func (r *reader) read(n uint64) (b []byte, err error) {
+	if uint64(len(r.b)) < n {
+		err = err_msg.ErrPortableStorageTruncated
+		return
+	}
+	b = r.b[:n]
+	r.b = r.b[n:]
+	return
+}
+
+func (r *reader) varint() (v uint64, err error) {
+	if len(r.b) == 0 {
+		err = err_msg.ErrPortableStorageTruncated
+		return
+	}
+	size := uint64(1) << (r.b[0] & 3)
+	b, err := r.read(size)
+	if err != nil {
+		return
+	}
+	var raw [8]byte
+	copy(raw[:], b)
+	v = binary.LittleEndian.Uint64(raw[:]) >> 2
+	return
+}
+
+func (r *reader) section(depth int) (s Section, err error) {
+	if depth > maxDepth {
+		err = err_msg.ErrPortableStorageDepth
+		return
+	}
+	n, err := r.varint()
+	if err != nil {
+		return
+	}
+	s = make(Section)
+	for i := uint64(0); i < n; i++ {
+		var l, name, t []byte
+		if l, err = r.read(1); err != nil {
+			return
+		}
+		if name, err = r.read(uint64(l[0])); err != nil {
+			return
+		}
+		if t, err = r.read(1); err != nil {
+			return
+		}
+		if s[string(name)], err = r.entry(t[0], depth); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r *reader) entry(t byte, depth int) (v interface{}, err error) {
+	if t&FlagArray == 0 {
+		v, err = r.value(t, depth)
+		return
+	}
+	t &^= FlagArray
+	n, err := r.varint()
+	if err != nil {
+		return
+	}
+	// every element takes at least one byte
+	if n > uint64(len(r.b)) {
+		err = err_msg.ErrPortableStorageTruncated
+		return
+	}
+	a := make([]interface{}, n)
+	for i := range a {
+		if a[i], err = r.value(t, depth); err != nil {
+			return
+		}
+	}
+	v = a
+	return
+}
+
+func (r *reader) value(t byte, depth int) (v interface{}, err error) {
+	var b []byte
+	switch t {
+	case TypeInt64, TypeUint64, TypeDouble:
+		b, err = r.read(8)
+	case TypeInt32, TypeUint32:
+		b, err = r.read(4)
+	case TypeInt16, TypeUint16:
+		b, err = r.read(2)
+	case TypeInt8, TypeUint8, TypeBool:
+		b, err = r.read(1)
+	case TypeString:
+		var n uint64
+		if n, err = r.varint(); err != nil {
+			return
+		}
+		if b, err = r.read(n); err != nil {
+			return
+		}
+		v = append([]byte{}, b...)
+		return
+	case TypeObject:
+		v, err = r.section(depth + 1)
+		return
+	case TypeArray:
+		var et []byte
+		if et, err = r.read(1); err != nil {
+			return
+		}
+		v, err = r.entry(et[0]|FlagArray, depth+1)
+		return
+	default:
+		err = err_msg.ErrPortableStorageType
+		return
+	}
+	if err != nil {
+		return
+	}
+	switch t {
+	case TypeInt64:
+		v = int64(binary.LittleEndian.Uint64(b))
+	case TypeUint64:
+		v = binary.LittleEndian.Uint64(b)
+	case TypeDouble:
+		v = math.Float64frombits(binary.LittleEndian.Uint64(b))
+	case TypeInt32:
+		v = int32(binary.LittleEndian.Uint32(b))
+	case TypeUint32:
+		v = binary.LittleEndian.Uint32(b)
+	case TypeInt16:
+		v = int16(binary.LittleEndian.Uint16(b))
+	case TypeUint16:
+		v = binary.LittleEndian.Uint16(b)
+	case TypeInt8:
+		v = int8(b[0])
+	case TypeUint8:
+		v = b[0]
+	case TypeBool:
+		v = b[0] != 0
+	}
+	return
+}
@@ -0,0 +1,80 @@
+package epee
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"gomonero/err_msg"
+	"reflect"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	s := Section{
+		"b": []byte{0xde, 0xad},
+		"a": uint8(5),
+	}
+	want := "011101010101020101" + // signatures and version
+		"08" + // 2 entries
+		"016108" + "05" + // "a" uint8 5
+		"01620a" + "08dead" // "b" string of 2 bytes
+	got, err := Marshal(s)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if hex.EncodeToString(got) != want {
+		t.Errorf("want: %s, got: %x", want, got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	s := Section{
+		"m_keys": Section{
+			"m_account_address": Section{
+				"m_spend_public_key": bytes.Repeat([]byte{1}, 32),
+				"m_view_public_key":  bytes.Repeat([]byte{2}, 32),
+			},
+			"m_spend_secret_key": bytes.Repeat([]byte{3}, 32),
+			"m_multisig_keys":    []byte{},
+		},
+		"m_creation_timestamp": uint64(1650000000),
+		"int64":                int64(-7),
+		"double":               1.5,
+		"flag":                 true,
+		"array":                []interface{}{uint32(1), uint32(1 << 20), uint32(1 << 31)},
+		"sections":             []interface{}{Section{"x": uint16(1)}, Section{"x": uint16(2)}},
+		"long":                 bytes.Repeat([]byte{4}, 20000),
+	}
+	b, err := Marshal(s)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	got, err := Unmarshal(b)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("round trip mismatch: want: %v got: %v", s, got)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	b, _ := Marshal(Section{"key": []byte("value")})
+	if _, err := Unmarshal(b[:len(b)-1]); !errors.Is(err, err_msg.ErrPortableStorageTruncated) {
+		t.Errorf("want: %s, got: %v", err_msg.ErrPortableStorageTruncated, err)
+	}
+	b[0] ^= 1
+	if _, err := Unmarshal(b); !errors.Is(err, err_msg.ErrPortableStorageSignature) {
+		t.Errorf("want: %s, got: %v", err_msg.ErrPortableStorageSignature, err)
+	}
+}
+
+func TestPutVarint(t *testing.T) {
+	for _, v := range []uint64{0, 63, 64, 16383, 16384, 1<<30 - 1, 1 << 30, 1<<62 - 1} {
+		r := &reader{b: PutVarint(v)}
+		got, err := r.varint()
+		if err != nil || got != v || len(r.b) != 0 {
+			t.Errorf("want: %d, got: %d (%v)", v, got, err)
+		}
+	}
+}
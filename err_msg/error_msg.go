@@ -17,14 +17,114 @@ var AddressTypeError = errors.New("wrong address type")
 //jamtis
 
 var ErrViewTag = errors.New("view tag does not match")
-var ErrLookup = errors.New("address not found in lookup table")
+var ErrAddressTag = errors.New("address tag does not match")
 var ErrJanus = errors.New("possible Janus attack")
+var ErrDummyAmount = errors.New("dummy outputs must have a zero amount")
 
 //crypto
 
 var InvalidPowersOfScalarN = errors.New("PowersOfScalar: n must be 1 or greater")
+var ErrCompositionProofKeys = errors.New("composition proof keys do not open the address or are zero")
+var ErrGrootleSize = errors.New("grootle reference set size must be n^m with n > 1 and m > 0")
+var ErrGrootleKey = errors.New("grootle key does not open the reference set member")
+var ErrBulletproofPlusSize = errors.New("range proofs cover 1 to 16 values")
+var ErrCLSAGRing = errors.New("CLSAG ring is empty or the signer index is out of range")
+var ErrCLSAGKeys = errors.New("CLSAG keys do not open the ring member")
+var ErrCLSAGNonce = errors.New("missing or malformed multisig CLSAG nonce")
+var ErrMultisigCLSAG = errors.New("partial responses do not combine into a valid CLSAG")
 
 //keySlice
 
 var IncompatibleSizesAB = errors.New("incompatible sizes of a and b")
 var InvalidScalarSliceN = errors.New("NewScalarSlice: n must be 1 or greater")
+
+//keys file
+
+var ErrKdf = errors.New("unsupported key derivation parameters")
+var ErrKeysFileVersion = errors.New("unsupported keys file version")
+var ErrKeysFileKind = errors.New("keys file is for a different wallet type")
+var ErrPassword = errors.New("invalid password or corrupted keys file")
+var ErrKeysFileWallet = errors.New("keys file is for another wallet")
+
+//epee
+
+var ErrPortableStorageSignature = errors.New("portable storage signature mismatch")
+var ErrPortableStorageVersion = errors.New("unsupported portable storage format version")
+var ErrPortableStorageType = errors.New("unsupported portable storage entry type")
+var ErrPortableStorageTruncated = errors.New("portable storage data is truncated")
+var ErrPortableStorageDepth = errors.New("portable storage nesting is too deep")
+
+//legacy keys file
+
+var ErrLegacyKeysFile = errors.New("malformed monero-wallet-cli keys file")
+var ErrLegacyJSON = errors.New("malformed json in monero-wallet-cli keys file")
+var ErrLegacyUnsupported = errors.New("multisig and hardware wallet keys files are not supported")
+var ErrWatchOnly = errors.New("operation requires the private spend key")
+
+//output store
+
+var ErrKeyImage = errors.New("unknown or missing key image")
+var ErrDuplicateOutput = errors.New("output with the same key image or one time address already recorded")
+var ErrPendingOutput = errors.New("not an output waiting for its key image")
+var ErrOneTimeAddress = errors.New("one time address does not belong to the wallet")
+
+//cold signing
+
+var ErrColdSigningFile = errors.New("malformed cold signing file or wrong view key")
+var ErrColdSigningVersion = errors.New("unsupported cold signing file version")
+var ErrColdSigningWallet = errors.New("cold signing file belongs to another wallet")
+var ErrKeyImageSignature = errors.New("key image signature does not verify")
+
+//message signing
+
+var ErrMessageSigningKey = errors.New("messages are signed with the spend or the view key")
+var ErrMessageSignatureFormat = errors.New("malformed message signature")
+var ErrMessageSignature = errors.New("message signature does not verify")
+
+//payment proofs
+
+var ErrTxKey = errors.New("unknown transaction private keys")
+var ErrTxProofFormat = errors.New("malformed payment proof")
+var ErrTxProof = errors.New("payment proof does not verify")
+
+//multisig
+
+var ErrMultisigParameters = errors.New("multisig wallets have 2 to 16 signers and a threshold of 1 to the number of signers")
+var ErrMultisigRound = errors.New("multisig message of another key exchange round")
+var ErrMultisigMessage = errors.New("malformed or badly signed multisig key exchange message")
+var ErrMultisigSigners = errors.New("multisig messages or signers do not match the participants")
+var ErrMultisigNotReady = errors.New("multisig key exchange is not complete")
+var ErrMultisigKeyImage = errors.New("partial key image proof does not verify")
+
+//ringct
+
+var ErrEcdhInfo = errors.New("malformed ecdhInfo")
+
+//transactions
+
+var ErrNoDestinations = errors.New("transaction has no destinations")
+var ErrInsufficientFunds = errors.New("inputs do not cover the destinations and the fee")
+var ErrTooManyOutputs = errors.New("transaction has more than 16 outputs")
+var ErrPaymentIDs = errors.New("transaction pays more than one integrated address")
+var ErrRingMember = errors.New("spent output is not a member of its ring")
+var ErrTxSignature = errors.New("ring signature does not verify")
+var ErrTxFee = errors.New("transaction fee is above the estimate at the maximum base fee")
+var ErrTxRejected = errors.New("transaction rejected before signing")
+
+//decoy
+
+var ErrDecoyDistribution = errors.New("output distribution has no unlocked outputs")
+var ErrDecoyRingSize = errors.New("ring size exceeds the unlocked outputs or the real output is not unlocked")
+var ErrDecoySelection = errors.New("not enough usable decoys picked")
+
+//seraphis
+
+var ErrSeraphisSerialization = errors.New("malformed seraphis serialization")
+var ErrEnoteVariant = errors.New("unknown enote variant")
+var ErrLedgerTooSmall = errors.New("ledger has fewer enotes than a reference set")
+var ErrTxSemantics = errors.New("transaction is malformed")
+var ErrKeyImageSpent = errors.New("key image already spent")
+var ErrTxBalance = errors.New("transaction amounts do not balance")
+var ErrTxRangeProof = errors.New("range proof does not verify")
+var ErrTxImageProof = errors.New("composition proof does not verify")
+var ErrTxMembershipProof = errors.New("membership proof does not verify")
@@ -5,4 +5,5 @@ go 1.16
 require (
 	filippo.io/edwards25519 v1.0.0-rc.1
 	github.com/ebfe/keccak v0.0.0-20150115210727-5cc570678d1b
+	golang.org/x/crypto v0.0.0-20220525230936-793ad666bf5e
 )
@@ -0,0 +1,204 @@
+package seraphis
+
+import (
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// EnoteCore is the part of an enote which is spent by transaction inputs
+//
+// seraphis_lib/src/seraphis_core/sp_core_types.h SpEnoteCore
+type EnoteCore struct {
+	Ko *crypto.Point //one time address
+	C  *crypto.Point //amount commitment
+}
+
+// CoinbaseEnoteCore is the core of a coinbase enote, its amount is public
+//
+// seraphis_lib/src/seraphis_core/sp_core_types.h SpCoinbaseEnoteCore
+type CoinbaseEnoteCore struct {
+	Ko     *crypto.Point //one time address
+	Amount uint64
+}
+
+// Commitment returns the amount commitment of a coinbase enote, which has a blinding factor of 1
+// C = 1 G + a H
+func (e *CoinbaseEnoteCore) Commitment() (C *crypto.Point) {
+	var a [8]byte
+	putAmount(a[:], e.Amount)
+	C = crypto.ScalarIdentity().DoubleScalarBaseMult(crypto.NewScalarFromAmount(a), crypto.PointH())
+	return
+}
+
+// EnoteVariant is an Enote or a CoinbaseEnote
+type EnoteVariant interface {
+	OnetimeAddress() *crypto.Point
+	AmountCommitment() *crypto.Point
+	EncryptedAddressTag() [address.JamtisAddressTagLength]byte
+	ViewTag() byte
+	MarshalBinary() ([]byte, error)
+}
+
+const (
+	enoteVariantEnote byte = iota
+	enoteVariantCoinbase
+)
+
+// Enote is an output of a seraphis transaction
+//
+// seraphis_lib/src/seraphis_main/tx_component_types.h SpEnoteV1
+type Enote struct {
+	Core          EnoteCore
+	EncodedAmount [8]byte                              //amount encrypted for the receiver
+	AddressTag    [address.JamtisAddressTagLength]byte //encrypted address tag
+	Tag           byte                                 //view tag
+}
+
+func (e *Enote) OnetimeAddress() (r *crypto.Point) {
+	r = e.Core.Ko
+	return
+}
+
+func (e *Enote) AmountCommitment() (r *crypto.Point) {
+	r = e.Core.C
+	return
+}
+
+func (e *Enote) ViewTag() (r byte) {
+	r = e.Tag
+	return
+}
+
+func (e *Enote) EncryptedAddressTag() (r [address.JamtisAddressTagLength]byte) {
+	r = e.AddressTag
+	return
+}
+
+func (e *Enote) MarshalBinary() (r []byte, err error) {
+	s := new(serializer)
+	s.point(e.Core.Ko)
+	s.point(e.Core.C)
+	s.bytes(e.EncodedAmount[:])
+	s.bytes(e.AddressTag[:])
+	s.bytes([]byte{e.Tag})
+	r = s.b
+	return
+}
+
+func (e *Enote) UnmarshalBinary(data []byte) (err error) {
+	d := &deserializer{b: data}
+	e.unmarshal(d)
+	err = d.finish()
+	return
+}
+
+func (e *Enote) unmarshal(d *deserializer) {
+	e.Core.Ko = d.point()
+	e.Core.C = d.point()
+	copy(e.EncodedAmount[:], d.bytes(len(e.EncodedAmount)))
+	copy(e.AddressTag[:], d.bytes(len(e.AddressTag)))
+	e.Tag = d.byte()
+}
+
+// CoinbaseEnote is an output of a coinbase transaction
+//
+// seraphis_lib/src/seraphis_main/tx_component_types.h SpCoinbaseEnoteV1
+type CoinbaseEnote struct {
+	Core       CoinbaseEnoteCore
+	AddressTag [address.JamtisAddressTagLength]byte //encrypted address tag
+	Tag        byte                                 //view tag
+}
+
+func (e *CoinbaseEnote) OnetimeAddress() (r *crypto.Point) {
+	r = e.Core.Ko
+	return
+}
+
+func (e *CoinbaseEnote) AmountCommitment() (r *crypto.Point) {
+	r = e.Core.Commitment()
+	return
+}
+
+func (e *CoinbaseEnote) ViewTag() (r byte) {
+	r = e.Tag
+	return
+}
+
+func (e *CoinbaseEnote) EncryptedAddressTag() (r [address.JamtisAddressTagLength]byte) {
+	r = e.AddressTag
+	return
+}
+
+func (e *CoinbaseEnote) MarshalBinary() (r []byte, err error) {
+	s := new(serializer)
+	s.point(e.Core.Ko)
+	s.uint64(e.Core.Amount)
+	s.bytes(e.AddressTag[:])
+	s.bytes([]byte{e.Tag})
+	r = s.b
+	return
+}
+
+func (e *CoinbaseEnote) UnmarshalBinary(data []byte) (err error) {
+	d := &deserializer{b: data}
+	e.unmarshal(d)
+	err = d.finish()
+	return
+}
+
+func (e *CoinbaseEnote) unmarshal(d *deserializer) {
+	e.Core.Ko = d.point()
+	e.Core.Amount = d.uint64()
+	copy(e.AddressTag[:], d.bytes(len(e.AddressTag)))
+	e.Tag = d.byte()
+}
+
+// enoteVariant writes the variant type of e followed by e
+func (s *serializer) enoteVariant(e EnoteVariant) (err error) {
+	var variant byte
+	switch e.(type) {
+	case *Enote:
+		variant = enoteVariantEnote
+	case *CoinbaseEnote:
+		variant = enoteVariantCoinbase
+	default:
+		err = err_msg.ErrEnoteVariant
+		return
+	}
+	b, err := e.MarshalBinary()
+	if err != nil {
+		return
+	}
+	s.bytes([]byte{variant})
+	s.bytes(b)
+	return
+}
+
+func (d *deserializer) enoteVariant() (e EnoteVariant) {
+	switch d.byte() {
+	case enoteVariantEnote:
+		enote := new(Enote)
+		enote.unmarshal(d)
+		e = enote
+	case enoteVariantCoinbase:
+		enote := new(CoinbaseEnote)
+		enote.unmarshal(d)
+		e = enote
+	default:
+		if d.err == nil {
+			d.err = err_msg.ErrEnoteVariant
+		}
+	}
+	return
+}
+
+// OutputProposal is an enote with its ephemeral key and the secrets the transaction builder needs
+//
+// seraphis_lib/src/seraphis_main/tx_builder_types.h SpOutputProposalV1
+type OutputProposal struct {
+	Enote       Enote
+	Ke          *crypto.Point //enote ephemeral key
+	Amount      uint64
+	AmountBlind *crypto.Scalar //blinding factor of the amount commitment
+}
@@ -0,0 +1,47 @@
+package seraphis
+
+import (
+	"gomonero/crypto"
+)
+
+// EnoteImage is the image of an enote spent by a transaction input, the squashed one time address and amount
+// commitment are masked so the enote is hidden among the members of the input's reference set
+//
+// seraphis_lib/src/seraphis_core/sp_core_types.h SpEnoteImageCore
+type EnoteImage struct {
+	MaskedAddress    *crypto.Point //K" = tk G + Hn(Ko, C) Ko
+	MaskedCommitment *crypto.Point //C" = tc G + C
+	KeyImage         *crypto.Point //KI = (z/y) U
+}
+
+// NewEnoteImage returns the image of the enote with one time address Ko, amount commitment C and key image KI
+// masked with random tk and tc
+//
+// seraphis_lib/src/seraphis_core/sp_core_enote_utils.cpp make_seraphis_enote_image_masked_keys
+func NewEnoteImage(Ko, C, KI *crypto.Point) (image *EnoteImage, tk, tc *crypto.Scalar) {
+	tk, tc = crypto.NewRandomScalar(), crypto.NewRandomScalar()
+	image = &EnoteImage{
+		MaskedAddress:    tk.MultG().Add(Ko.ScalarMult(SquashPrefix(Ko, C))),
+		MaskedCommitment: tc.MultG().Add(C),
+		KeyImage:         KI,
+	}
+	return
+}
+
+func (e *EnoteImage) MarshalBinary() (r []byte, err error) {
+	s := new(serializer)
+	s.point(e.MaskedAddress)
+	s.point(e.MaskedCommitment)
+	s.point(e.KeyImage)
+	r = s.b
+	return
+}
+
+func (e *EnoteImage) UnmarshalBinary(data []byte) (err error) {
+	d := &deserializer{b: data}
+	e.MaskedAddress = d.point()
+	e.MaskedCommitment = d.point()
+	e.KeyImage = d.point()
+	err = d.finish()
+	return
+}
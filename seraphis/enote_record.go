@@ -0,0 +1,129 @@
+package seraphis
+
+import (
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// EnoteType is the jamtis type of an enote received by a wallet
+type EnoteType byte
+
+const (
+	EnoteTypePlain     EnoteType = iota //sent by another wallet
+	EnoteTypeDummy                      //zero amount self-send of transactions which have no change
+	EnoteTypeChange                     //self-send returning the change of a transaction paying other wallets
+	EnoteTypeSelfSpend                  //self-send of a transaction paying the wallet itself
+)
+
+// BasicEnoteRecord is an enote whose view tag matched, found with the find-received key only
+//
+// seraphis_lib/src/seraphis_main/enote_record_types.h SpBasicEnoteRecordV1
+type BasicEnoteRecord struct {
+	Enote             EnoteVariant
+	Ke                *crypto.Point                        //enote ephemeral key
+	NominalAddressTag [address.JamtisAddressTagLength]byte //address tag decrypted with the plain sender-receiver secret
+}
+
+func (r *BasicEnoteRecord) MarshalBinary() (data []byte, err error) {
+	s := new(serializer)
+	if err = s.enoteVariant(r.Enote); err != nil {
+		return
+	}
+	s.point(r.Ke)
+	s.bytes(r.NominalAddressTag[:])
+	data = s.b
+	return
+}
+
+func (r *BasicEnoteRecord) UnmarshalBinary(data []byte) (err error) {
+	d := &deserializer{b: data}
+	r.Enote = d.enoteVariant()
+	r.Ke = d.point()
+	copy(r.NominalAddressTag[:], d.bytes(len(r.NominalAddressTag)))
+	err = d.finish()
+	return
+}
+
+// IntermediateEnoteRecord is a plain enote with its amount and address index, recovered without the spend key
+//
+// seraphis_lib/src/seraphis_main/enote_record_types.h SpIntermediateEnoteRecordV1
+type IntermediateEnoteRecord struct {
+	Enote        EnoteVariant
+	Ke           *crypto.Point //enote ephemeral key
+	Amount       uint64
+	AmountBlind  *crypto.Scalar //blinding factor of the amount commitment
+	AddressIndex [address.JamtisAddressIndexLength]byte
+}
+
+func (r *IntermediateEnoteRecord) MarshalBinary() (data []byte, err error) {
+	s := new(serializer)
+	if err = s.enoteVariant(r.Enote); err != nil {
+		return
+	}
+	s.point(r.Ke)
+	s.uint64(r.Amount)
+	s.scalar(r.AmountBlind)
+	s.bytes(r.AddressIndex[:])
+	data = s.b
+	return
+}
+
+func (r *IntermediateEnoteRecord) UnmarshalBinary(data []byte) (err error) {
+	d := &deserializer{b: data}
+	r.Enote = d.enoteVariant()
+	r.Ke = d.point()
+	r.Amount = d.uint64()
+	r.AmountBlind = d.scalar()
+	copy(r.AddressIndex[:], d.bytes(len(r.AddressIndex)))
+	err = d.finish()
+	return
+}
+
+// EnoteRecord is an enote owned by the wallet with everything needed to spend it
+// Ko = (ViewExtension + kvb) X + km U
+//
+// seraphis_lib/src/seraphis_main/enote_record_types.h SpEnoteRecordV1
+type EnoteRecord struct {
+	Enote         EnoteVariant
+	Ke            *crypto.Point  //enote ephemeral key
+	ViewExtension *crypto.Scalar //X component of the one time address private key without kvb
+	Amount        uint64
+	AmountBlind   *crypto.Scalar //blinding factor of the amount commitment
+	AddressIndex  [address.JamtisAddressIndexLength]byte
+	KeyImage      *crypto.Point
+	Type          EnoteType
+}
+
+func (r *EnoteRecord) MarshalBinary() (data []byte, err error) {
+	s := new(serializer)
+	if err = s.enoteVariant(r.Enote); err != nil {
+		return
+	}
+	s.point(r.Ke)
+	s.scalar(r.ViewExtension)
+	s.uint64(r.Amount)
+	s.scalar(r.AmountBlind)
+	s.bytes(r.AddressIndex[:])
+	s.point(r.KeyImage)
+	s.bytes([]byte{byte(r.Type)})
+	data = s.b
+	return
+}
+
+func (r *EnoteRecord) UnmarshalBinary(data []byte) (err error) {
+	d := &deserializer{b: data}
+	r.Enote = d.enoteVariant()
+	r.Ke = d.point()
+	r.ViewExtension = d.scalar()
+	r.Amount = d.uint64()
+	r.AmountBlind = d.scalar()
+	copy(r.AddressIndex[:], d.bytes(len(r.AddressIndex)))
+	r.KeyImage = d.point()
+	r.Type = EnoteType(d.byte())
+	if d.err == nil && r.Type > EnoteTypeSelfSpend {
+		d.err = err_msg.ErrSeraphisSerialization
+	}
+	err = d.finish()
+	return
+}
@@ -0,0 +1,88 @@
+package seraphis
+
+import (
+	"bytes"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"testing"
+)
+
+func newTestEnote() (e *Enote) {
+	e = &Enote{Core: EnoteCore{Ko: crypto.NewRandomScalar().MultG(), C: crypto.NewRandomScalar().MultG()}}
+	e.EncodedAmount[0] = 1
+	e.AddressTag[17] = 2
+	e.Tag = 3
+	return
+}
+
+func TestEnoteSerialization(t *testing.T) {
+	e := newTestEnote()
+	data, err := e.MarshalBinary()
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(data) != 2*crypto.KeyLength+8+18+1 {
+		t.Errorf("wrong enote size %d", len(data))
+	}
+	got := new(Enote)
+	if err = got.UnmarshalBinary(data); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got.Core.Ko.Equal(e.Core.Ko) == 0 || got.Core.C.Equal(e.Core.C) == 0 || got.EncodedAmount != e.EncodedAmount ||
+		got.AddressTag != e.AddressTag || got.Tag != e.Tag {
+		t.Errorf("enote changed by serialization")
+	}
+	if err = got.UnmarshalBinary(data[:len(data)-1]); err != err_msg.ErrSeraphisSerialization {
+		t.Errorf("want %v, got %v", err_msg.ErrSeraphisSerialization, err)
+	}
+}
+
+func TestEnoteRecordSerialization(t *testing.T) {
+	coinbase := &CoinbaseEnote{Core: CoinbaseEnoteCore{Ko: crypto.NewRandomScalar().MultG(), Amount: 600}, Tag: 9}
+	for _, e := range []EnoteVariant{newTestEnote(), coinbase} {
+		r := &EnoteRecord{
+			Enote:         e,
+			Ke:            crypto.NewRandomScalar().MultG(),
+			ViewExtension: crypto.NewRandomScalar(),
+			Amount:        600,
+			AmountBlind:   crypto.NewRandomScalar(),
+			KeyImage:      crypto.NewRandomScalar().MultU(),
+			Type:          EnoteTypeChange,
+		}
+		r.AddressIndex[0] = 7
+		data, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		got := new(EnoteRecord)
+		if err = got.UnmarshalBinary(data); err != nil {
+			t.Fatalf(err.Error())
+		}
+		again, _ := got.MarshalBinary()
+		if !bytes.Equal(data, again) {
+			t.Errorf("record changed by serialization")
+		}
+		if got.Enote.AmountCommitment().Equal(e.AmountCommitment()) == 0 {
+			t.Errorf("wrong enote variant")
+		}
+	}
+}
+
+func TestEnoteImage(t *testing.T) {
+	ko, z := crypto.NewRandomScalar(), crypto.NewRandomScalar()
+	Ko, C := ko.MultG(), z.MultG()
+	KI := crypto.NewRandomScalar().MultU()
+
+	image, tk, tc := NewEnoteImage(Ko, C, KI)
+	if tk.Add(ko.Multiply(SquashPrefix(Ko, C))).MultG().Equal(image.MaskedAddress) == 0 || tc.Add(z).MultG().Equal(image.MaskedCommitment) == 0 {
+		t.Errorf("wrong masked keys")
+	}
+	data, _ := image.MarshalBinary()
+	got := new(EnoteImage)
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got.MaskedAddress.Equal(image.MaskedAddress) == 0 || got.KeyImage.Equal(KI) == 0 {
+		t.Errorf("image changed by serialization")
+	}
+}
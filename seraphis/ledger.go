@@ -0,0 +1,135 @@
+package seraphis
+
+import (
+	"crypto/rand"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"math/big"
+	"sync"
+)
+
+// Ledger gives access to the enotes and spent key images of the blockchain
+type Ledger interface {
+	// NumEnotes returns the number of enotes in the ledger
+	NumEnotes() uint64
+	// EnoteAt returns the enote at ledger index
+	EnoteAt(index uint64) (EnoteCore, error)
+	// EnoteIndex returns the ledger index of the enote with one time address Ko
+	EnoteIndex(Ko *crypto.Point) (index uint64, ok bool)
+	// KeyImageSpent returns true if a transaction in the ledger spends the enote with key image KI
+	KeyImageSpent(KI *crypto.Point) bool
+}
+
+// MemoryLedger is a Ledger held in memory, it is safe for concurrent use
+type MemoryLedger struct {
+	mu        sync.RWMutex
+	enotes    []EnoteCore
+	indices   map[[32]byte]uint64
+	keyImages map[[32]byte]bool
+}
+
+func NewMemoryLedger() (l *MemoryLedger) {
+	l = &MemoryLedger{indices: make(map[[32]byte]uint64), keyImages: make(map[[32]byte]bool)}
+	return
+}
+
+func (l *MemoryLedger) NumEnotes() (n uint64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	n = uint64(len(l.enotes))
+	return
+}
+
+func (l *MemoryLedger) EnoteAt(index uint64) (e EnoteCore, err error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if index >= uint64(len(l.enotes)) {
+		err = err_msg.ErrOutOfBounds
+		return
+	}
+	e = l.enotes[index]
+	return
+}
+
+func (l *MemoryLedger) EnoteIndex(Ko *crypto.Point) (index uint64, ok bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	index, ok = l.indices[Ko.Byte32()]
+	return
+}
+
+func (l *MemoryLedger) KeyImageSpent(KI *crypto.Point) (r bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	r = l.keyImages[KI.Byte32()]
+	return
+}
+
+// AddEnote appends an enote to the ledger and returns its index
+func (l *MemoryLedger) AddEnote(e EnoteVariant) (index uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	index = l.addEnote(e)
+	return
+}
+
+func (l *MemoryLedger) addEnote(e EnoteVariant) (index uint64) {
+	index = uint64(len(l.enotes))
+	l.enotes = append(l.enotes, EnoteCore{Ko: e.OnetimeAddress(), C: e.AmountCommitment()})
+	l.indices[e.OnetimeAddress().Byte32()] = index
+	return
+}
+
+// AddTx validates tx and adds its key images and outputs to the ledger
+func (l *MemoryLedger) AddTx(tx *Tx, params TxParams) (err error) {
+	if err = ValidateTx(tx, l, params); err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// the ledger may have changed since the validation
+	for _, image := range tx.Images {
+		if l.keyImages[image.KeyImage.Byte32()] {
+			err = err_msg.ErrKeyImageSpent
+			return
+		}
+	}
+	for _, image := range tx.Images {
+		l.keyImages[image.KeyImage.Byte32()] = true
+	}
+	for i := range tx.Outputs {
+		l.addEnote(&tx.Outputs[i])
+	}
+	return
+}
+
+// SampleReferenceSet returns size distinct ledger indices chosen uniformly which include index at position l
+func SampleReferenceSet(ledger Ledger, index uint64, size int) (referenceSet []uint64, l int, err error) {
+	n := ledger.NumEnotes()
+	if size < 1 || n < uint64(size) || index >= n {
+		err = err_msg.ErrLedgerTooSmall
+		return
+	}
+	chosen := map[uint64]bool{index: true}
+	referenceSet = []uint64{index}
+	for len(referenceSet) < size {
+		k := randomIndex(n)
+		if chosen[k] {
+			continue
+		}
+		chosen[k] = true
+		referenceSet = append(referenceSet, k)
+	}
+	// the real enote is placed at a random position
+	l = int(randomIndex(uint64(size)))
+	referenceSet[0], referenceSet[l] = referenceSet[l], referenceSet[0]
+	return
+}
+
+// randomIndex returns a uniform random integer in [0, n)
+func randomIndex(n uint64) (r uint64) {
+	max := new(big.Int).SetUint64(n)
+	v, _ := rand.Int(rand.Reader, max)
+	r = v.Uint64()
+	return
+}
@@ -0,0 +1,31 @@
+package seraphis
+
+import (
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"testing"
+)
+
+func TestSampleReferenceSet(t *testing.T) {
+	ledger := NewMemoryLedger()
+	for i := 0; i < 20; i++ {
+		ledger.AddEnote(&CoinbaseEnote{Core: CoinbaseEnoteCore{Ko: crypto.NewRandomScalar().MultG(), Amount: 1}})
+	}
+	referenceSet, l, err := SampleReferenceSet(ledger, 13, 8)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(referenceSet) != 8 || referenceSet[l] != 13 {
+		t.Errorf("real enote is not in the reference set")
+	}
+	seen := make(map[uint64]bool)
+	for _, index := range referenceSet {
+		if seen[index] || index >= 20 {
+			t.Errorf("invalid reference set %v", referenceSet)
+		}
+		seen[index] = true
+	}
+	if _, _, err = SampleReferenceSet(ledger, 13, 32); err != err_msg.ErrLedgerTooSmall {
+		t.Errorf("want %v, got %v", err_msg.ErrLedgerTooSmall, err)
+	}
+}
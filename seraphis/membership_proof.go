@@ -0,0 +1,84 @@
+package seraphis
+
+import (
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// SquashPrefix returns Hn(Ko, C), the one time address of an enote is multiplied by it in its squashed form
+//
+// seraphis_lib/src/seraphis_core/sp_core_enote_utils.cpp make_seraphis_squash_prefix
+func SquashPrefix(Ko, C *crypto.Point) (r *crypto.Scalar) {
+	r = crypto.HashToScalar([]byte("sp_squashed_enote"), Ko.Bytes(), C.Bytes())
+	return
+}
+
+// Squashed returns the squashed enote Q = Hn(Ko, C) Ko + C, membership proofs are made over squashed enotes
+func (e *EnoteCore) Squashed() (Q *crypto.Point) {
+	Q = e.Ko.ScalarMult(SquashPrefix(e.Ko, e.C)).Add(e.C)
+	return
+}
+
+// MembershipProof proves that an enote image masks one of the enotes of a reference set
+//
+// seraphis_lib/src/seraphis_main/tx_component_types.h SpMembershipProofV1
+type MembershipProof struct {
+	Grootle      *crypto.GrootleProof
+	ReferenceSet []uint64 //ledger indices of the reference set members
+}
+
+// membershipStatement returns the grootle statement of image over members
+// Q[l] - (K" + C") = -(tk + tc) G
+func membershipStatement(members []EnoteCore, image *EnoteImage, message crypto.Hash) (s *crypto.GrootleStatement) {
+	s = &crypto.GrootleStatement{
+		M:       make([]*crypto.Point, len(members)),
+		Offset:  image.MaskedAddress.Add(image.MaskedCommitment),
+		Message: message,
+	}
+	for k := range members {
+		s.M[k] = members[k].Squashed()
+	}
+	return
+}
+
+// NewMembershipProof proves that image, masked with tk and tc, is the image of members[l], the enote at ledger
+// index referenceSet[l], len(members) must be n^m
+func NewMembershipProof(members []EnoteCore, referenceSet []uint64, l int, image *EnoteImage, tk, tc *crypto.Scalar,
+	n, m int, message crypto.Hash) (proof *MembershipProof, err error) {
+	if len(referenceSet) != len(members) {
+		err = err_msg.ErrMismatchedLengths
+		return
+	}
+	s := membershipStatement(members, image, message)
+	grootle, err := crypto.NewGrootleProof(s, l, tk.Add(tc).Negate(), n, m)
+	if err != nil {
+		return
+	}
+	proof = &MembershipProof{Grootle: grootle, ReferenceSet: referenceSet}
+	return
+}
+
+// Verify returns true if proof shows that image is the image of one of members, the enotes of the reference set
+func (proof *MembershipProof) Verify(members []EnoteCore, image *EnoteImage, n, m int, message crypto.Hash) (r bool) {
+	r = VerifyMembershipProofs([]*MembershipProof{proof}, [][]EnoteCore{members}, []*EnoteImage{image}, n, m, message)
+	return
+}
+
+// VerifyMembershipProofs batch verifies the membership proofs of the images over the reference sets at the same index
+func VerifyMembershipProofs(proofs []*MembershipProof, members [][]EnoteCore, images []*EnoteImage, n, m int,
+	message crypto.Hash) (r bool) {
+	if len(members) != len(proofs) || len(images) != len(proofs) {
+		return
+	}
+	grootles := make([]*crypto.GrootleProof, len(proofs))
+	statements := make([]*crypto.GrootleStatement, len(proofs))
+	for i, proof := range proofs {
+		if len(proof.ReferenceSet) != len(members[i]) {
+			return
+		}
+		grootles[i] = proof.Grootle
+		statements[i] = membershipStatement(members[i], images[i], message)
+	}
+	r = crypto.VerifyGrootleProofs(grootles, statements, n, m)
+	return
+}
@@ -0,0 +1,107 @@
+package seraphis
+
+import (
+	"encoding/binary"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// the binary encodings follow the field order of the seraphis_lib serializable types, points and scalars are
+// 32 bytes, amounts are 8 bytes little endian
+
+// serializer appends the fields of a type to its buffer
+type serializer struct {
+	b []byte
+}
+
+func (s *serializer) point(P *crypto.Point) {
+	s.b = append(s.b, P.Bytes()...)
+}
+
+func (s *serializer) scalar(k *crypto.Scalar) {
+	s.b = append(s.b, k.Bytes()...)
+}
+
+func (s *serializer) uint64(n uint64) {
+	var b [8]byte
+	putAmount(b[:], n)
+	s.b = append(s.b, b[:]...)
+}
+
+func (s *serializer) bytes(b []byte) {
+	s.b = append(s.b, b...)
+}
+
+// deserializer reads the fields of a type from its buffer, the first error is kept and later reads return zero
+// values
+type deserializer struct {
+	b   []byte
+	err error
+}
+
+func (d *deserializer) bytes(n int) (r []byte) {
+	if d.err != nil {
+		return
+	}
+	if len(d.b) < n {
+		d.err = err_msg.ErrSeraphisSerialization
+		return
+	}
+	r, d.b = d.b[:n], d.b[n:]
+	return
+}
+
+func (d *deserializer) point() (P *crypto.Point) {
+	b := d.bytes(crypto.KeyLength)
+	if d.err != nil {
+		return
+	}
+	if P = crypto.NewPointFromBytes(b); P.Err != nil {
+		d.err = err_msg.ErrSeraphisSerialization
+		P = nil
+	}
+	return
+}
+
+func (d *deserializer) scalar() (k *crypto.Scalar) {
+	b := d.bytes(crypto.KeyLength)
+	if d.err != nil {
+		return
+	}
+	if k = crypto.NewScalarFromBytes(b); k.Err != nil {
+		d.err = err_msg.ErrSeraphisSerialization
+		k = nil
+	}
+	return
+}
+
+func (d *deserializer) uint64() (n uint64) {
+	b := d.bytes(8)
+	if d.err != nil {
+		return
+	}
+	n = binary.LittleEndian.Uint64(b)
+	return
+}
+
+func (d *deserializer) byte() (r byte) {
+	b := d.bytes(1)
+	if d.err != nil {
+		return
+	}
+	r = b[0]
+	return
+}
+
+// finish returns the error of the reads, trailing bytes are an error
+func (d *deserializer) finish() (err error) {
+	if d.err == nil && len(d.b) != 0 {
+		d.err = err_msg.ErrSeraphisSerialization
+	}
+	err = d.err
+	return
+}
+
+func putAmount(b []byte, amount uint64) {
+	binary.LittleEndian.PutUint64(b, amount)
+}
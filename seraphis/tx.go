@@ -0,0 +1,106 @@
+package seraphis
+
+import (
+	"bytes"
+	"gomonero/crypto"
+	"sort"
+)
+
+// TxParams are the consensus parameters of seraphis transactions
+type TxParams struct {
+	RefSetN int //decomposition base of the membership proofs
+	RefSetM int //decomposition exponent, reference sets have RefSetN^RefSetM members
+}
+
+// DefaultTxParams returns reference sets of 2^7 members
+func DefaultTxParams() (p TxParams) {
+	p = TxParams{RefSetN: 2, RefSetM: 7}
+	return
+}
+
+// RefSetSize returns the number of members of reference sets
+func (p TxParams) RefSetSize() (N int) {
+	N = 1
+	for j := 0; j < p.RefSetM; j++ {
+		N *= p.RefSetN
+	}
+	return
+}
+
+// BalanceProof shows that the amounts of a transaction balance and are in range
+// sum C" = sum C + fee H + RemainderBlind G
+//
+// seraphis_lib/src/seraphis_main/tx_component_types.h SpBalanceProofV1
+type BalanceProof struct {
+	RangeProof     *crypto.BulletproofPlus //over the masked commitments of the inputs and the output commitments
+	RemainderBlind *crypto.Scalar
+}
+
+// Tx is a seraphis transaction spending squashed enotes
+// the inputs are sorted by key image and the outputs by one time address
+//
+// seraphis_lib/src/seraphis_main/txtype_squashed_v1.h SpTxSquashedV1
+type Tx struct {
+	Images           []*EnoteImage
+	Outputs          []Enote
+	EphemeralKeys    []*crypto.Point //enote ephemeral keys of the outputs
+	BalanceProof     *BalanceProof
+	ImageProofs      []*crypto.CompositionProof
+	MembershipProofs []*MembershipProof
+	Fee              uint64
+}
+
+// ProposalMessage returns the message signed by the image and membership proofs of a transaction
+//
+// seraphis_lib/src/seraphis_main/tx_builders_mixed.cpp make_tx_proposal_prefix_v1
+func ProposalMessage(images []*EnoteImage, outputs []Enote, ephemeralKeys []*crypto.Point, fee uint64) (m crypto.Hash) {
+	s := new(serializer)
+	s.bytes([]byte("seraphis tx proposal prefix"))
+	for _, image := range images {
+		s.point(image.KeyImage)
+	}
+	for i := range outputs {
+		b, _ := outputs[i].MarshalBinary()
+		s.bytes(b)
+	}
+	for _, Ke := range ephemeralKeys {
+		s.point(Ke)
+	}
+	s.uint64(fee)
+	m = crypto.Keccak256(s.b)
+	return
+}
+
+// Message returns the proposal message of tx
+func (tx *Tx) Message() (m crypto.Hash) {
+	m = ProposalMessage(tx.Images, tx.Outputs, tx.EphemeralKeys, tx.Fee)
+	return
+}
+
+// SortOutputs sorts the outputs of proposals by one time address
+func SortOutputs(proposals []*OutputProposal) {
+	sort.Slice(proposals, func(a, b int) bool {
+		return bytes.Compare(proposals[a].Enote.Core.Ko.Bytes(), proposals[b].Enote.Core.Ko.Bytes()) < 0
+	})
+}
+
+// TxWeight returns the weight of a transaction with numInputs and numOutputs, the fee is paid per unit of weight
+func TxWeight(numInputs, numOutputs int, params TxParams) (w uint64) {
+	const key = crypto.KeyLength
+	image := 3 * key
+	composition := 5 * key
+	membership := 2*key + params.RefSetM*(params.RefSetN-1)*key + params.RefSetM*key + 2*key + params.RefSetSize()*8
+	enote := 2*key + 8 + 18 + 1
+	// the range proof covers the inputs and outputs, it grows with the log of the padded number of values
+	M := 1
+	for M < numInputs+numOutputs {
+		M *= 2
+	}
+	rounds := 0
+	for n := M * crypto.BulletproofPlusBits; n > 1; n /= 2 {
+		rounds++
+	}
+	rangeProof := (3+2*rounds)*key + 3*key
+	w = uint64(numInputs*(image+composition+membership) + numOutputs*(enote+key) + rangeProof + key + 8)
+	return
+}
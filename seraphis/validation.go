@@ -0,0 +1,117 @@
+package seraphis
+
+import (
+	"bytes"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// ValidateTx checks the semantics of tx, that its key images are not spent in the ledger and its proofs
+//
+// seraphis_lib/src/seraphis_main/tx_validators.cpp
+func ValidateTx(tx *Tx, ledger Ledger, params TxParams) (err error) {
+	if err = validateSemantics(tx, params); err != nil {
+		return
+	}
+	if err = validateKeyImages(tx, ledger); err != nil {
+		return
+	}
+	if err = validateBalance(tx); err != nil {
+		return
+	}
+
+	m := tx.Message()
+	for i, image := range tx.Images {
+		if !tx.ImageProofs[i].Verify(m, image.MaskedAddress, image.KeyImage) {
+			err = err_msg.ErrTxImageProof
+			return
+		}
+	}
+
+	members := make([][]EnoteCore, len(tx.Images))
+	for i, proof := range tx.MembershipProofs {
+		members[i] = make([]EnoteCore, len(proof.ReferenceSet))
+		for k, index := range proof.ReferenceSet {
+			if members[i][k], err = ledger.EnoteAt(index); err != nil {
+				return
+			}
+		}
+	}
+	if !VerifyMembershipProofs(tx.MembershipProofs, members, tx.Images, params.RefSetN, params.RefSetM, m) {
+		err = err_msg.ErrTxMembershipProof
+	}
+	return
+}
+
+// validateSemantics checks the number and order of the components of tx
+func validateSemantics(tx *Tx, params TxParams) (err error) {
+	err = err_msg.ErrTxSemantics
+	if len(tx.Images) == 0 || len(tx.Outputs) < 2 || len(tx.EphemeralKeys) != len(tx.Outputs) {
+		return
+	}
+	if len(tx.Images)+len(tx.Outputs) > crypto.BulletproofPlusMaxValues {
+		return
+	}
+	if len(tx.ImageProofs) != len(tx.Images) || len(tx.MembershipProofs) != len(tx.Images) || tx.BalanceProof == nil {
+		return
+	}
+	for _, proof := range tx.MembershipProofs {
+		if len(proof.ReferenceSet) != params.RefSetSize() {
+			return
+		}
+	}
+	// sorted key images are also unique
+	for i := 1; i < len(tx.Images); i++ {
+		if bytes.Compare(tx.Images[i-1].KeyImage.Bytes(), tx.Images[i].KeyImage.Bytes()) >= 0 {
+			return
+		}
+	}
+	for i := 1; i < len(tx.Outputs); i++ {
+		if bytes.Compare(tx.Outputs[i-1].Core.Ko.Bytes(), tx.Outputs[i].Core.Ko.Bytes()) >= 0 {
+			return
+		}
+	}
+	err = nil
+	return
+}
+
+// validateKeyImages checks the key images of tx are valid and not spent in the ledger, a key image with a torsion
+// component would be a second key image of the same enote
+func validateKeyImages(tx *Tx, ledger Ledger) (err error) {
+	for _, image := range tx.Images {
+		if image.KeyImage.Equal(crypto.PointI()) == 1 || !image.KeyImage.InPrimeSubgroup() {
+			err = err_msg.ErrTxSemantics
+			return
+		}
+		if ledger.KeyImageSpent(image.KeyImage) {
+			err = err_msg.ErrKeyImageSpent
+			return
+		}
+	}
+	return
+}
+
+// validateBalance checks the range proof and that sum C" = sum C + fee H + remainder G
+func validateBalance(tx *Tx) (err error) {
+	V := make([]*crypto.Point, 0, len(tx.Images)+len(tx.Outputs))
+	sum := crypto.PointI()
+	for _, image := range tx.Images {
+		V = append(V, image.MaskedCommitment)
+		sum = sum.Add(image.MaskedCommitment)
+	}
+	for i := range tx.Outputs {
+		V = append(V, tx.Outputs[i].Core.C)
+		sum = sum.Subtract(tx.Outputs[i].Core.C)
+	}
+	var fee [8]byte
+	putAmount(fee[:], tx.Fee)
+	sum = sum.Subtract(crypto.NewScalarFromAmount(fee).MultH())
+	if sum.Equal(tx.BalanceProof.RemainderBlind.MultG()) == 0 {
+		err = err_msg.ErrTxBalance
+		return
+	}
+	if !tx.BalanceProof.RangeProof.Verify(V) {
+		err = err_msg.ErrTxRangeProof
+	}
+	return
+}
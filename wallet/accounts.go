@@ -0,0 +1,125 @@
+package wallet
+
+import (
+	"gomonero/err_msg"
+)
+
+// accounts group the subaddresses with the same major index, the label of an account is the label of its
+// subaddress {major, 0}
+//monero/src/wallet/wallet2.cpp create_account, add_subaddress, set_subaddress_label
+
+// SubAddressInfo describes a subaddress created in the wallet
+type SubAddressInfo struct {
+	Index   SubAddressIndex
+	Address string //base58 address, the standard address for SubAddressIndex{0, 0}
+	Label   string
+	Used    bool //the subaddress has received an output
+}
+
+// numSubAddresses returns the number of subaddresses created in each account, the caller must hold lookupMu
+func (w *Wallet) numSubAddresses() (r []uint32) {
+	if w.accounts == nil {
+		// every wallet has the account 0 with the standard address
+		w.accounts = []uint32{1}
+	}
+	r = w.accounts
+	return
+}
+
+// NumAccounts returns the number of accounts in the wallet
+func (w *Wallet) NumAccounts() (n uint32) {
+	w.lookupMu.Lock()
+	defer w.lookupMu.Unlock()
+	n = uint32(len(w.numSubAddresses()))
+	return
+}
+
+// NumSubAddresses returns the number of subaddresses created in account major
+func (w *Wallet) NumSubAddresses(major uint32) (n uint32) {
+	w.lookupMu.Lock()
+	defer w.lookupMu.Unlock()
+	accounts := w.numSubAddresses()
+	if major < uint32(len(accounts)) {
+		n = accounts[major]
+	}
+	return
+}
+
+// CreateAccount adds an account labeled label and returns its major index
+func (w *Wallet) CreateAccount(label string) (major uint32) {
+	w.lookupMu.Lock()
+	major = uint32(len(w.numSubAddresses()))
+	w.accounts = append(w.accounts, 1)
+	w.lookupMu.Unlock()
+
+	w.SetLabel(SubAddressIndex{major, 0}, label)
+	w.expandSubAddressLookup(SubAddressIndex{major, 0})
+	return
+}
+
+// CreateAddress adds a subaddress labeled label to account major and returns its index
+func (w *Wallet) CreateAddress(major uint32, label string) (i SubAddressIndex, err error) {
+	w.lookupMu.Lock()
+	accounts := w.numSubAddresses()
+	if major >= uint32(len(accounts)) {
+		w.lookupMu.Unlock()
+		err = err_msg.ErrOutOfBounds
+		return
+	}
+	i = SubAddressIndex{major, accounts[major]}
+	accounts[major]++
+	w.lookupMu.Unlock()
+
+	w.SetLabel(i, label)
+	w.expandSubAddressLookup(i)
+	return
+}
+
+// markSubAddressCreated creates the accounts and subaddresses up to i, used when an output to i is received
+func (w *Wallet) markSubAddressCreated(i SubAddressIndex) {
+	w.lookupMu.Lock()
+	defer w.lookupMu.Unlock()
+	accounts := w.numSubAddresses()
+	for uint32(len(accounts)) <= i.Major {
+		accounts = append(accounts, 1)
+	}
+	if accounts[i.Major] <= i.Minor {
+		accounts[i.Major] = i.Minor + 1
+	}
+	w.accounts = accounts
+}
+
+// Addresses lists the subaddresses created in account major
+func (w *Wallet) Addresses(major uint32) (r []SubAddressInfo, err error) {
+	n := w.NumSubAddresses(major)
+	if n == 0 {
+		err = err_msg.ErrOutOfBounds
+		return
+	}
+	used := make(map[SubAddressIndex]bool)
+	for _, o := range w.outputs.Outputs() {
+		used[o.SubAddress] = true
+	}
+	for minor := uint32(0); minor < n; minor++ {
+		i := SubAddressIndex{major, minor}
+		info := SubAddressInfo{Index: i, Label: w.Label(i), Used: used[i]}
+		if i == (SubAddressIndex{}) {
+			info.Address = w.address.Base58()
+		} else {
+			info.Address = w.SubAddress(i).Base58()
+		}
+		r = append(r, info)
+	}
+	return
+}
+
+// IsUsed returns true if subaddress i has received an output
+func (w *Wallet) IsUsed(i SubAddressIndex) (r bool) {
+	for _, o := range w.outputs.Outputs() {
+		if o.SubAddress == i {
+			r = true
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,106 @@
+package wallet
+
+import (
+	"gomonero/address"
+	"path/filepath"
+	"testing"
+)
+
+func TestAccounts(t *testing.T) {
+	w := NewWallet()
+	if w.NumAccounts() != 1 || w.NumSubAddresses(0) != 1 {
+		t.Fatalf("new wallet: want 1 account with 1 address, got %v %v", w.NumAccounts(), w.NumSubAddresses(0))
+	}
+
+	major := w.CreateAccount("savings")
+	if major != 1 || w.NumAccounts() != 2 {
+		t.Errorf("CreateAccount: want account 1 of 2, got %v of %v", major, w.NumAccounts())
+	}
+	i, err := w.CreateAddress(major, "donations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != (SubAddressIndex{1, 1}) {
+		t.Errorf("CreateAddress: want {1 1}, got %v", i)
+	}
+	if _, err = w.CreateAddress(5, ""); err == nil {
+		t.Errorf("CreateAddress accepted an account which does not exist")
+	}
+	w.SetLabel(i, "tips")
+
+	// outputs to created subaddresses are recognized and mark them used
+	Ko, Ke, viewTag, _ := w.SubAddress(i).OneTimeAddress(0)
+	if _, ok := w.ScanOutputForSubAddress(Ko, Ke, 0, &viewTag); !ok {
+		t.Fatalf("output to created subaddress not recognized")
+	}
+	if err = w.AddOutput(&Output{Ko: Ko, TxPublicKey: Ke, SubAddress: i, Amount: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	addresses, err := w.Addresses(major)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 2 {
+		t.Fatalf("Addresses: want 2, got %v", len(addresses))
+	}
+	if addresses[0].Label != "savings" || addresses[0].Used || addresses[0].Address != w.SubAddress(SubAddressIndex{1, 0}).Base58() {
+		t.Errorf("Addresses: wrong account address %+v", addresses[0])
+	}
+	if addresses[1].Label != "tips" || !addresses[1].Used || !w.IsUsed(i) {
+		t.Errorf("Addresses: wrong subaddress %+v", addresses[1])
+	}
+	main, _ := w.Addresses(0)
+	if main[0].Address != w.address.Base58() {
+		t.Errorf("Addresses: account 0 does not start with the standard address")
+	}
+
+	// receiving to a subaddress which was not created creates it
+	j := SubAddressIndex{1, 4}
+	Ko, Ke, _, _ = w.SubAddress(j).OneTimeAddress(0)
+	if err = w.AddOutput(&Output{Ko: Ko, TxPublicKey: Ke, SubAddress: j}); err != nil {
+		t.Fatal(err)
+	}
+	if w.NumSubAddresses(1) != 5 {
+		t.Errorf("want 5 subaddresses in account 1, got %v", w.NumSubAddresses(1))
+	}
+
+	path := filepath.Join(t.TempDir(), "wallet.keys")
+	if err = w.SaveKeys(path, nil); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadWallet(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.NumAccounts() != 2 || loaded.NumSubAddresses(1) != 5 || loaded.Label(SubAddressIndex{1, 0}) != "savings" {
+		t.Errorf("accounts were not persisted")
+	}
+	if _, ok := loaded.SubAddressLookup(w.SubAddressPublicSpendKey(j)); !ok {
+		t.Errorf("created subaddresses are not in the lookup table of the loaded wallet")
+	}
+}
+
+func TestAddressesNetwork(t *testing.T) {
+	for _, test := range []struct {
+		network, subaddress int
+	}{
+		{address.MainNetwork, address.MainNetworkSubAddress},
+		{address.TestNetwork, address.TestNetworkSubAddress},
+		{address.StageNetwork, address.StageNetworkSubAddress},
+	} {
+		w := NewWallet()
+		w.address.Network = test.network
+		if _, err := w.CreateAddress(0, ""); err != nil {
+			t.Fatal(err)
+		}
+		addresses, err := w.Addresses(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := address.NewSubaddress(addresses[1].Address)
+		if err != nil || a.Network != test.subaddress {
+			t.Errorf("%d: want subaddress prefix %d, got %v %v", test.network, test.subaddress, a, err)
+		}
+	}
+}
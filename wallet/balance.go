@@ -0,0 +1,170 @@
+package wallet
+
+import (
+	"gomonero/crypto"
+	"sort"
+	"time"
+)
+
+// heights passed to the functions below are blockchain heights, the number of blocks in the chain
+
+// Transfer is an incoming or outgoing transaction of the wallet
+type Transfer struct {
+	TxHash        crypto.Hash
+	Height        uint64
+	Amount        uint64 //for outgoing transfers the amount spent minus the change
+	Incoming      bool
+	SubAddress    SubAddressIndex   //the receiving subaddress, or for outgoing transfers the subaddress of the first input
+	SubAddresses  []SubAddressIndex //the receiving subaddress, or for outgoing transfers those of all the inputs
+	Confirmations uint64
+}
+
+// hasSubAddress returns whether i received t or, for outgoing transfers, paid one of its inputs
+func (t *Transfer) hasSubAddress(i SubAddressIndex) (r bool) {
+	for _, j := range t.SubAddresses {
+		if j == i {
+			r = true
+			return
+		}
+	}
+	return
+}
+
+// the balances and the transfers include the pending outputs of view only wallets, whose key images have not been
+// imported yet and which are therefore not known to be spent, as wallet2 does for view only wallets
+
+// Balance returns the sum of the unspent outputs of the wallet
+func (w *Wallet) Balance() (r uint64) {
+	r, _ = w.balance(func(o *Output) bool { return true }, 0)
+	return
+}
+
+// UnlockedBalance returns the sum of the unspent outputs of the wallet which can be spent at height
+func (w *Wallet) UnlockedBalance(height uint64) (r uint64) {
+	_, r = w.balance(func(o *Output) bool { return true }, height)
+	return
+}
+
+// SubAddressBalance returns the balance and unlocked balance of subaddress i
+func (w *Wallet) SubAddressBalance(i SubAddressIndex, height uint64) (balance, unlocked uint64) {
+	balance, unlocked = w.balance(func(o *Output) bool { return o.SubAddress == i }, height)
+	return
+}
+
+// AccountBalance returns the balance and unlocked balance of all the subaddresses with major index major
+func (w *Wallet) AccountBalance(major uint32, height uint64) (balance, unlocked uint64) {
+	balance, unlocked = w.balance(func(o *Output) bool { return o.SubAddress.Major == major }, height)
+	return
+}
+
+// BalancePerSubAddress returns the balance of every subaddress of account major holding unspent outputs
+func (w *Wallet) BalancePerSubAddress(major uint32) (r map[uint32]uint64) {
+	r = make(map[uint32]uint64)
+	for _, o := range w.allOutputs() {
+		if !o.Spent && o.SubAddress.Major == major {
+			r[o.SubAddress.Minor] += o.Amount
+		}
+	}
+	return
+}
+
+func (w *Wallet) balance(include func(o *Output) bool, height uint64) (balance, unlocked uint64) {
+	now := time.Now()
+	for _, o := range w.allOutputs() {
+		if o.Spent || !include(o) {
+			continue
+		}
+		balance += o.Amount
+		if o.IsUnlocked(height, now) {
+			unlocked += o.Amount
+		}
+	}
+	return
+}
+
+// allOutputs returns the outputs of the wallet followed by its pending outputs
+func (w *Wallet) allOutputs() (r []*Output) {
+	r = append(w.outputs.Outputs(), w.outputs.Pending()...)
+	return
+}
+
+// Transfers returns the transfer history of the wallet ordered by height
+// if subaddresses are given, only transfers involving one of them are returned, an outgoing transfer involves all the
+// subaddresses of its inputs
+//
+// like monero/src/wallet/wallet2.cpp process_new_transaction a transaction spending outputs of the wallet is
+// outgoing, the outputs it sends back to the wallet are change and not listed as incoming transfers
+func (w *Wallet) Transfers(height uint64, subaddresses ...SubAddressIndex) (r []*Transfer) {
+	outputs := w.allOutputs()
+
+	outgoing := make(map[crypto.Hash]*Transfer)
+	var spent []*Transfer
+	for _, o := range outputs {
+		if !o.Spent {
+			continue
+		}
+		t, ok := outgoing[o.SpentTxHash]
+		if !ok {
+			t = &Transfer{TxHash: o.SpentTxHash, Height: o.SpentHeight, SubAddress: o.SubAddress}
+			outgoing[o.SpentTxHash] = t
+			spent = append(spent, t)
+		}
+		if !t.hasSubAddress(o.SubAddress) {
+			t.SubAddresses = append(t.SubAddresses, o.SubAddress)
+		}
+		t.Amount += o.Amount
+	}
+
+	type incomingKey struct {
+		txHash crypto.Hash
+		index  SubAddressIndex
+	}
+	incoming := make(map[incomingKey]*Transfer)
+	for _, o := range outputs {
+		if t, ok := outgoing[o.TxHash]; ok {
+			// change
+			if t.Amount >= o.Amount {
+				t.Amount -= o.Amount
+			} else {
+				t.Amount = 0
+			}
+			continue
+		}
+		key := incomingKey{o.TxHash, o.SubAddress}
+		t, ok := incoming[key]
+		if !ok {
+			t = &Transfer{
+				TxHash:       o.TxHash,
+				Height:       o.Height,
+				Incoming:     true,
+				SubAddress:   o.SubAddress,
+				SubAddresses: []SubAddressIndex{o.SubAddress},
+			}
+			incoming[key] = t
+			r = append(r, t)
+		}
+		t.Amount += o.Amount
+	}
+	r = append(r, spent...)
+
+	if len(subaddresses) > 0 {
+		filtered := r[:0]
+		for _, t := range r {
+			for _, i := range subaddresses {
+				if t.hasSubAddress(i) {
+					filtered = append(filtered, t)
+					break
+				}
+			}
+		}
+		r = filtered
+	}
+
+	for _, t := range r {
+		if height > t.Height {
+			t.Confirmations = height - t.Height
+		}
+	}
+	sort.SliceStable(r, func(a, b int) bool { return r[a].Height < r[b].Height })
+	return
+}
@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"gomonero/crypto"
+	"testing"
+)
+
+// equalTransfers returns whether a and b are the same transfer
+func equalTransfers(a, b *Transfer) (r bool) {
+	if len(a.SubAddresses) != len(b.SubAddresses) {
+		return
+	}
+	for i := range a.SubAddresses {
+		if a.SubAddresses[i] != b.SubAddresses[i] {
+			return
+		}
+	}
+	r = a.TxHash == b.TxHash && a.Height == b.Height && a.Amount == b.Amount && a.Incoming == b.Incoming &&
+		a.SubAddress == b.SubAddress && a.Confirmations == b.Confirmations
+	return
+}
+
+func TestWalletBalanceAndTransfers(t *testing.T) {
+	w := NewWallet()
+	txA := crypto.Keccak256([]byte("a"))
+	txB := crypto.Keccak256([]byte("b"))
+	txC := crypto.Keccak256([]byte("c"))
+
+	main := SubAddressIndex{}
+	sub := SubAddressIndex{Major: 0, Minor: 1}
+	account := SubAddressIndex{Major: 1, Minor: 0}
+
+	spentOutput := newTestOutput(100, 10)
+	spentOutput.TxHash = txA
+	outputs := []*Output{spentOutput}
+	for _, o := range []struct {
+		tx     crypto.Hash
+		height uint64
+		amount uint64
+		index  SubAddressIndex
+	}{
+		{txA, 100, 20, sub},
+		{txB, 120, 5, account},
+		{txC, 130, 3, main}, //change of the transaction spending spentOutput
+	} {
+		output := newTestOutput(o.height, o.amount)
+		output.TxHash = o.tx
+		output.SubAddress = o.index
+		outputs = append(outputs, output)
+	}
+	for _, o := range outputs {
+		if err := w.OutputStore().Add(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.MarkSpent(spentOutput.KeyImage, 130, txC); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := w.Balance(); got != 28 {
+		t.Errorf("Balance: want 28, got %v", got)
+	}
+	if got := w.UnlockedBalance(135); got != 25 {
+		t.Errorf("UnlockedBalance: want 25, got %v", got)
+	}
+	if balance, unlocked := w.AccountBalance(0, 135); balance != 23 || unlocked != 20 {
+		t.Errorf("AccountBalance: want 23 20, got %v %v", balance, unlocked)
+	}
+	if balance, unlocked := w.SubAddressBalance(account, 125); balance != 5 || unlocked != 0 {
+		t.Errorf("SubAddressBalance: want 5 0, got %v %v", balance, unlocked)
+	}
+	perSubAddress := w.BalancePerSubAddress(0)
+	if len(perSubAddress) != 2 || perSubAddress[0] != 3 || perSubAddress[1] != 20 {
+		t.Errorf("BalancePerSubAddress: got %v", perSubAddress)
+	}
+
+	transfers := w.Transfers(135)
+	want := []Transfer{
+		{TxHash: txA, Height: 100, Amount: 10, Incoming: true, SubAddress: main, SubAddresses: []SubAddressIndex{main}, Confirmations: 35},
+		{TxHash: txA, Height: 100, Amount: 20, Incoming: true, SubAddress: sub, SubAddresses: []SubAddressIndex{sub}, Confirmations: 35},
+		{TxHash: txB, Height: 120, Amount: 5, Incoming: true, SubAddress: account, SubAddresses: []SubAddressIndex{account}, Confirmations: 15},
+		{TxHash: txC, Height: 130, Amount: 7, Incoming: false, SubAddress: main, SubAddresses: []SubAddressIndex{main}, Confirmations: 5},
+	}
+	if len(transfers) != len(want) {
+		t.Fatalf("Transfers: want %v transfers, got %v", len(want), len(transfers))
+	}
+	for i := range want {
+		if !equalTransfers(transfers[i], &want[i]) {
+			t.Errorf("Transfers: want %+v, got %+v", want[i], *transfers[i])
+		}
+	}
+
+	filtered := w.Transfers(135, account, sub)
+	if len(filtered) != 2 || filtered[0].SubAddress != sub || filtered[1].SubAddress != account {
+		t.Errorf("Transfers filtered by subaddress: got %v", filtered)
+	}
+
+	// an outgoing transfer spending outputs of two subaddresses involves both of them
+	txD := crypto.Keccak256([]byte("d"))
+	for _, o := range outputs[1:] {
+		if o.TxHash == txA || o.TxHash == txC {
+			if err := w.MarkSpent(o.KeyImage, 140, txD); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	filtered = w.Transfers(145, sub)
+	wantD := Transfer{TxHash: txD, Height: 140, Amount: 23, SubAddress: sub, SubAddresses: []SubAddressIndex{sub, main}, Confirmations: 5}
+	if len(filtered) != 2 || !equalTransfers(filtered[1], &wantD) {
+		t.Errorf("Transfers filtered by a subaddress of a second input: want %+v, got %v", wantD, filtered)
+	}
+	if filtered = w.Transfers(145, main); len(filtered) != 3 || filtered[2].TxHash != txD {
+		t.Errorf("Transfers filtered by a subaddress of the first input: got %v", filtered)
+	}
+}
+
+func TestWalletBalancePendingOutputs(t *testing.T) {
+	w := NewWallet()
+	tx := crypto.Keccak256([]byte("pending"))
+	account := SubAddressIndex{Major: 1, Minor: 2}
+
+	o := newTestOutput(100, 10)
+	o.Ko, o.KeyImage, o.TxHash, o.SubAddress = crypto.NewRandomScalar().MultG(), nil, tx, account
+	if err := w.OutputStore().AddPending(o); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Balance(); got != 10 {
+		t.Errorf("Balance: want 10, got %v", got)
+	}
+	if got := w.UnlockedBalance(110); got != 10 {
+		t.Errorf("UnlockedBalance: want 10, got %v", got)
+	}
+	if balance, unlocked := w.AccountBalance(1, 105); balance != 10 || unlocked != 0 {
+		t.Errorf("AccountBalance: want 10 0, got %v %v", balance, unlocked)
+	}
+	transfers := w.Transfers(110, account)
+	want := Transfer{TxHash: tx, Height: 100, Amount: 10, Incoming: true, SubAddress: account, SubAddresses: []SubAddressIndex{account}, Confirmations: 10}
+	if len(transfers) != 1 || !equalTransfers(transfers[0], &want) {
+		t.Errorf("Transfers: want %+v, got %v", want, transfers)
+	}
+}
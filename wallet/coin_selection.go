@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"gomonero/decoy"
+	"gomonero/err_msg"
+	"sort"
+	"time"
+)
+
+// DefaultDustThreshold is the amount below which outputs cost more in fees to spend than they are worth
+//
+// monero/src/cryptonote_config.h DEFAULT_DUST_THRESHOLD
+const DefaultDustThreshold = 2000000000
+
+// CoinSelector picks the outputs spent by a transfer
+type CoinSelector interface {
+	// Select returns outputs of candidates whose amounts sum to at least amount, err_msg.ErrInsufficientFunds if
+	// there are none, candidates are unspent and unlocked outputs of a single account
+	Select(candidates []*Output, amount uint64) (selected []*Output, err error)
+}
+
+// MinimumInputs spends as few outputs as possible, the last one being the smallest that covers the rest of the
+// amount to keep the change small
+type MinimumInputs struct{}
+
+func (MinimumInputs) Select(candidates []*Output, amount uint64) (selected []*Output, err error) {
+	remaining := sortOutputs(candidates, func(a, b *Output) bool { return a.Amount < b.Amount })
+	for len(remaining) > 0 {
+		// the smallest output covering what is left ends the selection, otherwise the largest one is spent
+		n := sort.Search(len(remaining), func(i int) bool { return remaining[i].Amount >= amount })
+		if n < len(remaining) {
+			selected = append(selected, remaining[n])
+			return
+		}
+		largest := remaining[len(remaining)-1]
+		selected = append(selected, largest)
+		amount -= largest.Amount
+		remaining = remaining[:len(remaining)-1]
+	}
+	selected = nil
+	err = err_msg.ErrInsufficientFunds
+	return
+}
+
+// OldestFirst spends the outputs received first, so that old outputs do not stay unspent forever
+type OldestFirst struct{}
+
+func (OldestFirst) Select(candidates []*Output, amount uint64) (selected []*Output, err error) {
+	selected, err = accumulate(sortOutputs(candidates, func(a, b *Output) bool {
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+		return a.GlobalIndex < b.GlobalIndex
+	}), amount)
+	return
+}
+
+// SweepAll spends every candidate, the amount is only checked
+type SweepAll struct{}
+
+func (SweepAll) Select(candidates []*Output, amount uint64) (selected []*Output, err error) {
+	selected, err = sweep(candidates, amount, func(*Output) bool { return true })
+	return
+}
+
+// SweepBelow spends every candidate smaller than Threshold, consolidating small outputs
+//
+// monero/src/wallet/wallet2.cpp create_transactions_all with below
+type SweepBelow struct {
+	Threshold uint64
+}
+
+func (s SweepBelow) Select(candidates []*Output, amount uint64) (selected []*Output, err error) {
+	selected, err = sweep(candidates, amount, func(o *Output) bool { return o.Amount < s.Threshold })
+	return
+}
+
+// SingleSubaddress pays from the outputs of a single subaddress with Selector when possible, choosing the
+// subaddress needing the fewest inputs, and from the whole account otherwise
+type SingleSubaddress struct {
+	Selector CoinSelector
+}
+
+func (s SingleSubaddress) Select(candidates []*Output, amount uint64) (selected []*Output, err error) {
+	bySubaddress := make(map[SubAddressIndex][]*Output)
+	var indices []SubAddressIndex
+	for _, o := range candidates {
+		if _, ok := bySubaddress[o.SubAddress]; !ok {
+			indices = append(indices, o.SubAddress)
+		}
+		bySubaddress[o.SubAddress] = append(bySubaddress[o.SubAddress], o)
+	}
+	sort.Slice(indices, func(a, b int) bool { return indices[a].Minor < indices[b].Minor })
+	for _, i := range indices {
+		r, e := s.Selector.Select(bySubaddress[i], amount)
+		if e == nil && (selected == nil || len(r) < len(selected)) {
+			selected = r
+		}
+	}
+	if selected == nil {
+		selected, err = s.Selector.Select(candidates, amount)
+	}
+	return
+}
+
+// SelectOutputs returns the outputs paying destinations and the fee picked by selector from the unspent outputs of
+// account major that are unlocked at height, and the fee of EstimateFee at the per byte baseFee for a transaction
+// spending them with rings of decoy.DefaultRingSize members, outputs of other accounts are never spent together to
+// keep the accounts unlinkable and outputs below dust are not spent
+// every input raises the fee, so the selection is repeated with the fee of the selected inputs until it covers it
+//
+// monero/src/wallet/wallet2.cpp create_transactions_2
+func (w *Wallet) SelectOutputs(major uint32, destinations []TxDestination, change SubAddressIndex, height uint64, selector CoinSelector, dust, baseFee uint64) (selected []*Output, fee uint64, err error) {
+	now := time.Now()
+	var candidates []*Output
+	for _, o := range w.outputs.Outputs() {
+		if o.Spent || o.SubAddress.Major != major || o.Amount < dust || !o.IsUnlocked(height, now) {
+			continue
+		}
+		candidates = append(candidates, o)
+	}
+	var amount uint64
+	for _, d := range destinations {
+		if amount+d.Amount < amount {
+			err = err_msg.ErrInsufficientFunds
+			return
+		}
+		amount += d.Amount
+	}
+	// the fee only depends on the number of inputs and grows with it, so the loop ends
+	fee = EstimateFee(destinations, change, 1, decoy.DefaultRingSize, baseFee)
+	for {
+		if amount+fee < amount {
+			selected, fee, err = nil, 0, err_msg.ErrInsufficientFunds
+			return
+		}
+		if selected, err = selector.Select(candidates, amount+fee); err != nil {
+			fee = 0
+			return
+		}
+		needed := EstimateFee(destinations, change, len(selected), decoy.DefaultRingSize, baseFee)
+		if needed <= fee {
+			fee = needed
+			return
+		}
+		fee = needed
+	}
+}
+
+// sortOutputs returns a sorted copy of outputs
+func sortOutputs(outputs []*Output, less func(a, b *Output) bool) (r []*Output) {
+	r = append([]*Output{}, outputs...)
+	sort.SliceStable(r, func(a, b int) bool { return less(r[a], r[b]) })
+	return
+}
+
+// accumulate returns the first outputs whose amounts sum to at least amount
+func accumulate(outputs []*Output, amount uint64) (selected []*Output, err error) {
+	var sum uint64
+	for _, o := range outputs {
+		if sum >= amount && len(selected) > 0 {
+			return
+		}
+		selected = append(selected, o)
+		sum += o.Amount
+	}
+	if sum < amount || len(selected) == 0 {
+		selected = nil
+		err = err_msg.ErrInsufficientFunds
+	}
+	return
+}
+
+// sweep returns the outputs included by include if they sum to at least amount
+func sweep(outputs []*Output, amount uint64, include func(o *Output) bool) (selected []*Output, err error) {
+	var sum uint64
+	for _, o := range outputs {
+		if include(o) {
+			selected = append(selected, o)
+			sum += o.Amount
+		}
+	}
+	if sum < amount || len(selected) == 0 {
+		selected = nil
+		err = err_msg.ErrInsufficientFunds
+	}
+	return
+}
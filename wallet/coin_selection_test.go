@@ -0,0 +1,132 @@
+package wallet
+
+import (
+	"gomonero/decoy"
+	"gomonero/err_msg"
+	"testing"
+)
+
+func selectedAmounts(outputs []*Output) (r []uint64) {
+	for _, o := range outputs {
+		r = append(r, o.Amount)
+	}
+	return
+}
+
+func equalAmounts(a, b []uint64) (r bool) {
+	if len(a) != len(b) {
+		return
+	}
+	for n := range a {
+		if a[n] != b[n] {
+			return
+		}
+	}
+	r = true
+	return
+}
+
+func TestCoinSelectors(t *testing.T) {
+	var candidates []*Output
+	for n, amount := range []uint64{50, 10, 200, 30, 80} {
+		o := newTestOutput(uint64(100-n), amount)
+		o.SubAddress = SubAddressIndex{Minor: uint32(n % 2)}
+		candidates = append(candidates, o)
+	}
+
+	for _, test := range []struct {
+		name     string
+		selector CoinSelector
+		amount   uint64
+		want     []uint64
+	}{
+		{"minimum inputs, single output", MinimumInputs{}, 60, []uint64{80}},
+		{"minimum inputs, several outputs", MinimumInputs{}, 260, []uint64{200, 80}},
+		{"minimum inputs, all outputs", MinimumInputs{}, 370, []uint64{200, 80, 50, 30, 10}},
+		{"oldest first", OldestFirst{}, 100, []uint64{80, 30}},
+		{"sweep all", SweepAll{}, 0, []uint64{50, 10, 200, 30, 80}},
+		{"sweep below", SweepBelow{Threshold: 60}, 0, []uint64{50, 10, 30}},
+		{"single subaddress", SingleSubaddress{MinimumInputs{}}, 240, []uint64{200, 50}},
+		{"single subaddress fallback", SingleSubaddress{MinimumInputs{}}, 340, []uint64{200, 80, 50, 10}},
+	} {
+		selected, err := test.selector.Select(candidates, test.amount)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := selectedAmounts(selected); !equalAmounts(got, test.want) {
+			t.Errorf("%s: want %v, got %v", test.name, test.want, got)
+		}
+	}
+
+	for _, selector := range []CoinSelector{MinimumInputs{}, OldestFirst{}, SweepAll{}, SweepBelow{Threshold: 60}, SingleSubaddress{OldestFirst{}}} {
+		if _, err := selector.Select(candidates, 1000); err != err_msg.ErrInsufficientFunds {
+			t.Errorf("%T: want %v, got %v", selector, err_msg.ErrInsufficientFunds, err)
+		}
+	}
+}
+
+func TestSelectOutputs(t *testing.T) {
+	w := NewWallet()
+	for _, o := range []struct {
+		height uint64
+		amount uint64
+		index  SubAddressIndex
+	}{
+		{100, 1000, SubAddressIndex{0, 0}},
+		{110, 2000, SubAddressIndex{0, 1}},
+		{120, 5, SubAddressIndex{0, 2}},    //dust
+		{195, 4000, SubAddressIndex{0, 0}}, //locked
+		{130, 8000, SubAddressIndex{1, 0}}, //other account
+	} {
+		output := newTestOutput(o.height, o.amount)
+		output.SubAddress = o.index
+		if err := w.OutputStore().Add(output); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pay := func(amount uint64) []TxDestination {
+		return []TxDestination{{Address: &NewWallet().address, Amount: amount}}
+	}
+	selected, fee, err := w.SelectOutputs(0, pay(2500), SubAddressIndex{}, 200, SweepAll{}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := selectedAmounts(selected); !equalAmounts(got, []uint64{1000, 2000}) || fee != 0 {
+		t.Errorf("want [1000 2000] and no fee, got %v %v", got, fee)
+	}
+	if _, _, err = w.SelectOutputs(0, pay(3500), SubAddressIndex{}, 200, MinimumInputs{}, 10, 0); err != err_msg.ErrInsufficientFunds {
+		t.Errorf("want %v, got %v", err_msg.ErrInsufficientFunds, err)
+	}
+	selected, _, err = w.SelectOutputs(1, pay(3500), SubAddressIndex{}, 200, MinimumInputs{}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := selectedAmounts(selected); !equalAmounts(got, []uint64{8000}) {
+		t.Errorf("want [8000], got %v", got)
+	}
+}
+
+func TestSelectOutputsFee(t *testing.T) {
+	w := NewWallet()
+	destinations := []TxDestination{{Address: &NewWallet().address, Amount: 1000000}}
+	fee1 := EstimateFee(destinations, SubAddressIndex{}, 1, decoy.DefaultRingSize, 100)
+	fee2 := EstimateFee(destinations, SubAddressIndex{}, 2, decoy.DefaultRingSize, 100)
+	// the larger output pays the amount but not its fee, a second input raises the fee
+	for _, amount := range []uint64{1000000 + fee1 - 1, fee2} {
+		if err := w.OutputStore().Add(newTestOutput(100, amount)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	selected, fee, err := w.SelectOutputs(0, destinations, SubAddressIndex{}, 200, MinimumInputs{}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 || fee != fee2 || fee2 <= fee1 {
+		t.Errorf("want 2 inputs and fee %v, got %v inputs and fee %v", fee2, len(selected), fee)
+	}
+	if _, _, err = w.SelectOutputs(0, destinations, SubAddressIndex{}, 200, MinimumInputs{}, 0, 10000); err != err_msg.ErrInsufficientFunds {
+		t.Errorf("want %v, got %v", err_msg.ErrInsufficientFunds, err)
+	}
+}
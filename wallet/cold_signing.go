@@ -0,0 +1,429 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// cold signing, a view only wallet exports its outputs and unsigned transactions to an offline wallet holding the
+// private spend key, which returns key images and signed transactions
+//
+// the files start with a magic followed by
+// iv || chacha20(key = cn_slow_hash(kv), iv, payload) || signature of the previous bytes by kv
+// the payloads are versioned json documents starting with the public keys of the wallet, they are not the boost
+// archives of the reference wallet, so the magics differ from its own and neither wallet mistakes the files of the
+// other for its own
+//
+// monero/src/wallet/wallet2.cpp encrypt_with_view_secret_key, export_outputs, export_key_images,
+// save_tx, sign_tx, load_tx
+
+const (
+	unsignedTxMagic     = "gomonero unsigned tx set\x01"
+	signedTxMagic       = "gomonero signed tx set\x01"
+	outputExportMagic   = "gomonero output export\x01"
+	keyImageExportMagic = "gomonero key image export\x01"
+	coldSigningVersion  = 1
+)
+
+// coldSigningHeader identifies the wallet a file belongs to
+type coldSigningHeader struct {
+	Version  int
+	SpendKey *crypto.PublicKey
+	ViewKey  *crypto.PublicKey
+}
+
+type outputExport struct {
+	coldSigningHeader
+	Outputs []*Output
+}
+
+type exportedKeyImage struct {
+	Ko        *crypto.PublicKey //one time address of the output
+	KeyImage  *crypto.PublicKey
+	Signature crypto.RingSignature //ring signature of the key image by the output key, a ring of one member
+}
+
+type keyImageExport struct {
+	coldSigningHeader
+	KeyImages []exportedKeyImage
+}
+
+type exportedDestination struct {
+	Network    int
+	SpendKey   *crypto.PublicKey
+	ViewKey    *crypto.PublicKey
+	Subaddress bool
+	PaymentID  *[address.PaymentIDLength]byte `json:",omitempty"` //set for integrated addresses
+	Amount     uint64
+}
+
+type unsignedTx struct {
+	coldSigningHeader
+	Destinations []exportedDestination
+	Sources      []TxSource
+	Fee          uint64
+	Change       SubAddressIndex
+}
+
+type signedTx struct {
+	coldSigningHeader
+	Tx               *RingCTTx
+	TxKey            *crypto.PrivateKey //private keys of Tx, recorded by the online wallet for its payment proofs
+	AdditionalTxKeys []*crypto.PrivateKey
+	Destinations     []exportedDestination //destinations of the unsigned transaction, to check the private keys
+	Change           SubAddressIndex
+}
+
+// Broadcaster relays transactions to the network, e.g. with send_raw_transaction of a daemon
+type Broadcaster interface {
+	Broadcast(tx []byte) error
+}
+
+func (w *Wallet) coldSigningHeader() (h coldSigningHeader) {
+	h = coldSigningHeader{Version: coldSigningVersion, SpendKey: w.address.Ks, ViewKey: w.address.Kv}
+	return
+}
+
+// check returns an error if the file was not written by a wallet with the keys of w
+func (h *coldSigningHeader) check(w *Wallet) (err error) {
+	if h.Version != coldSigningVersion {
+		err = err_msg.ErrColdSigningVersion
+		return
+	}
+	if h.SpendKey == nil || h.ViewKey == nil || h.SpendKey.Equal(w.address.Ks) == 0 || h.ViewKey.Equal(w.address.Kv) == 0 {
+		err = err_msg.ErrColdSigningWallet
+	}
+	return
+}
+
+// encryptWithViewKey returns magic followed by payload encrypted and signed with the private view key
+func (w *Wallet) encryptWithViewKey(magic string, payload []byte) (r []byte, err error) {
+	iv := make([]byte, legacyIVLength)
+	if _, err = rand.Read(iv); err != nil {
+		return
+	}
+	ciphertext, err := legacyChacha20(payload, legacyChachaKey(w.kv.Bytes(), legacyKdfRounds), iv)
+	if err != nil {
+		return
+	}
+	r = append(append([]byte(magic), iv...), ciphertext...)
+	sig := crypto.NewSignature(crypto.Keccak256(r[len(magic):]), w.address.Kv, w.kv)
+	r = append(append(r, sig.C.Bytes()...), sig.R.Bytes()...)
+	return
+}
+
+// decryptWithViewKey returns the payload of data written by encryptWithViewKey with the view key of w
+func (w *Wallet) decryptWithViewKey(magic string, data []byte) (payload []byte, err error) {
+	if !bytes.HasPrefix(data, []byte(magic)) || len(data) < len(magic)+legacyIVLength+2*crypto.KeyLength {
+		err = err_msg.ErrColdSigningFile
+		return
+	}
+	data = data[len(magic):]
+	signed, sigBytes := data[:len(data)-2*crypto.KeyLength], data[len(data)-2*crypto.KeyLength:]
+	sig := &crypto.Signature{
+		C: crypto.NewScalarFromBytes(sigBytes[:crypto.KeyLength]),
+		R: crypto.NewScalarFromBytes(sigBytes[crypto.KeyLength:]),
+	}
+	if sig.C.Err != nil || sig.R.Err != nil || !sig.Verify(crypto.Keccak256(signed), w.address.Kv) {
+		err = err_msg.ErrColdSigningFile
+		return
+	}
+	payload, err = legacyChacha20(signed[legacyIVLength:], legacyChachaKey(w.kv.Bytes(), legacyKdfRounds), signed[:legacyIVLength])
+	return
+}
+
+// ExportOutputs returns the outputs received by the wallet, including those of a view only wallet waiting for their
+// key images, for ImportOutputs of the wallet holding the private spend key
+func (w *Wallet) ExportOutputs() (r []byte, err error) {
+	e := outputExport{coldSigningHeader: w.coldSigningHeader()}
+	e.Outputs = append(w.outputs.Outputs(), w.outputs.Pending()...)
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	r, err = w.encryptWithViewKey(outputExportMagic, payload)
+	return
+}
+
+// ImportOutputs records the outputs exported by ExportOutputs of the view only wallet, it returns the number of
+// outputs which were not already recorded
+func (w *Wallet) ImportOutputs(data []byte) (n int, err error) {
+	payload, err := w.decryptWithViewKey(outputExportMagic, data)
+	if err != nil {
+		return
+	}
+	e := new(outputExport)
+	if err = json.Unmarshal(payload, e); err != nil {
+		return
+	}
+	if err = e.check(w); err != nil {
+		return
+	}
+	for _, o := range e.Outputs {
+		if err = w.AddOutput(o); err == err_msg.ErrDuplicateOutput {
+			err = nil
+			continue
+		} else if err != nil {
+			return
+		}
+		n++
+	}
+	return
+}
+
+// ExportKeyImages returns the key images of the outputs of the wallet, each signed with its output key, for
+// ImportKeyImages of the view only wallet
+func (w *Wallet) ExportKeyImages() (r []byte, err error) {
+	if w.IsViewOnly() {
+		err = err_msg.ErrWatchOnly
+		return
+	}
+	e := keyImageExport{coldSigningHeader: w.coldSigningHeader()}
+	for _, o := range w.outputs.Outputs() {
+		ko := w.outputPrivateKey(o)
+		e.KeyImages = append(e.KeyImages, exportedKeyImage{
+			Ko:        o.Ko,
+			KeyImage:  o.KeyImage,
+			Signature: crypto.NewRingSignature(o.KeyImage.Byte32(), o.KeyImage, []*crypto.PublicKey{o.Ko}, ko, 0),
+		})
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	r, err = w.encryptWithViewKey(keyImageExportMagic, payload)
+	return
+}
+
+// ImportKeyImages records the key images exported by ExportKeyImages for the outputs waiting for them, it returns
+// the number of outputs which received their key image
+func (w *Wallet) ImportKeyImages(data []byte) (n int, err error) {
+	payload, err := w.decryptWithViewKey(keyImageExportMagic, data)
+	if err != nil {
+		return
+	}
+	e := new(keyImageExport)
+	if err = json.Unmarshal(payload, e); err != nil {
+		return
+	}
+	if err = e.check(w); err != nil {
+		return
+	}
+	for _, ki := range e.KeyImages {
+		if ki.Ko == nil || ki.KeyImage == nil || !ki.Signature.Verify(ki.KeyImage.Byte32(), ki.KeyImage, []*crypto.PublicKey{ki.Ko}) {
+			err = err_msg.ErrKeyImageSignature
+			return
+		}
+	}
+	for _, o := range w.outputs.Pending() {
+		for _, ki := range e.KeyImages {
+			if ki.Ko.Equal(o.Ko) == 1 {
+				if err = w.outputs.SetKeyImage(o.Ko, ki.KeyImage); err != nil {
+					return
+				}
+				n++
+				break
+			}
+		}
+	}
+	return
+}
+
+// exportDestinations returns destinations encodable with encoding/json
+func exportDestinations(destinations []TxDestination) (r []exportedDestination) {
+	for _, d := range destinations {
+		e := exportedDestination{
+			SpendKey:   d.Address.SpendKey(),
+			ViewKey:    d.Address.ViewKey(),
+			Subaddress: d.Address.IsSubaddress(),
+			Amount:     d.Amount,
+		}
+		switch a := d.Address.(type) {
+		case *address.StandardAddress:
+			e.Network = a.Network
+		case *address.Subaddress:
+			e.Network = a.Network
+		case *address.IntegratedAddress:
+			e.Network = a.Network
+			paymentID := a.PaymentID
+			e.PaymentID = &paymentID
+		}
+		r = append(r, e)
+	}
+	return
+}
+
+// importDestinations returns the destinations of exportDestinations
+func importDestinations(exported []exportedDestination) (destinations []TxDestination, err error) {
+	for _, e := range exported {
+		if e.SpendKey == nil || e.ViewKey == nil {
+			destinations, err = nil, err_msg.ErrTxSemantics
+			return
+		}
+		var a address.Destination
+		switch {
+		case e.Subaddress:
+			a = &address.Subaddress{Network: e.Network, Ksi: e.SpendKey, Kvi: e.ViewKey}
+		case e.PaymentID != nil:
+			a = &address.IntegratedAddress{Network: e.Network, Ks: e.SpendKey, Kv: e.ViewKey, PaymentID: *e.PaymentID}
+		default:
+			a = &address.StandardAddress{Network: e.Network, Ks: e.SpendKey, Kv: e.ViewKey}
+		}
+		destinations = append(destinations, TxDestination{Address: a, Amount: e.Amount})
+	}
+	return
+}
+
+// ExportUnsignedTx returns the transaction BuildTx would build from the arguments, for SignTx of the wallet holding
+// the private spend key
+func (w *Wallet) ExportUnsignedTx(destinations []TxDestination, sources []TxSource, fee uint64, change SubAddressIndex) (r []byte, err error) {
+	u := unsignedTx{
+		coldSigningHeader: w.coldSigningHeader(),
+		Destinations:      exportDestinations(destinations),
+		Sources:           sources,
+		Fee:               fee,
+		Change:            change,
+	}
+	payload, err := json.Marshal(u)
+	if err != nil {
+		return
+	}
+	r, err = w.encryptWithViewKey(unsignedTxMagic, payload)
+	return
+}
+
+// SignTx builds and signs the transaction exported by ExportUnsignedTx, the result is imported by ImportSignedTx
+// the online wallet chooses the destinations and the fee, so the fee must not exceed the estimate at maxBaseFee and
+// accept must approve the summary of the transaction, a nil accept signs without confirmation
+func (w *Wallet) SignTx(unsigned []byte, maxBaseFee uint64, accept TxAcceptFunc) (r []byte, err error) {
+	payload, err := w.decryptWithViewKey(unsignedTxMagic, unsigned)
+	if err != nil {
+		return
+	}
+	u := new(unsignedTx)
+	if err = json.Unmarshal(payload, u); err != nil {
+		return
+	}
+	if err = u.check(w); err != nil {
+		return
+	}
+	destinations, err := importDestinations(u.Destinations)
+	if err != nil {
+		return
+	}
+	summary, err := newTxSummary(destinations, u.Sources, u.Fee, u.Change)
+	if err != nil {
+		return
+	}
+	if err = checkTxFee(destinations, u.Sources, u.Fee, u.Change, maxBaseFee); err != nil {
+		return
+	}
+	if accept != nil && !accept(summary) {
+		err = err_msg.ErrTxRejected
+		return
+	}
+	tx, err := w.BuildTx(destinations, u.Sources, u.Fee, u.Change)
+	if err != nil {
+		return
+	}
+	txKey, additional, _ := w.TxKey(tx.Hash())
+	payload, err = json.Marshal(signedTx{
+		coldSigningHeader: w.coldSigningHeader(),
+		Tx:                tx,
+		TxKey:             txKey,
+		AdditionalTxKeys:  additional,
+		Destinations:      u.Destinations,
+		Change:            u.Change,
+	})
+	if err != nil {
+		return
+	}
+	r, err = w.encryptWithViewKey(signedTxMagic, payload)
+	return
+}
+
+// ImportSignedTx returns the transaction signed by SignTx, ready to be broadcast, and records its private keys
+func (w *Wallet) ImportSignedTx(signed []byte) (tx *RingCTTx, err error) {
+	payload, err := w.decryptWithViewKey(signedTxMagic, signed)
+	if err != nil {
+		return
+	}
+	s := new(signedTx)
+	if err = json.Unmarshal(payload, s); err != nil {
+		return
+	}
+	if err = s.check(w); err != nil {
+		return
+	}
+	if s.Tx == nil || s.Tx.BulletproofPlus == nil {
+		err = err_msg.ErrTxSemantics
+		return
+	}
+	if err = s.checkTxKeys(w); err != nil {
+		return
+	}
+	if err = w.txKeys.Add(s.Tx.Hash(), s.TxKey, s.AdditionalTxKeys); err != nil {
+		return
+	}
+	tx = s.Tx
+	return
+}
+
+// checkTxKeys checks that the private keys of s are those of its transaction, R = r G or R = r Ksi for a single
+// subaddress destination, and the same for each additional key, before the online wallet records them
+func (s *signedTx) checkTxKeys(w *Wallet) (err error) {
+	destinations, err := importDestinations(s.Destinations)
+	if err != nil {
+		return
+	}
+	// the change is a destination too, paid to a subaddress unless it goes to the main address
+	var subaddresses []*crypto.PublicKey
+	for _, d := range destinations {
+		if d.Address.IsSubaddress() {
+			subaddresses = append(subaddresses, d.Address.SpendKey())
+		}
+	}
+	if s.Change != (SubAddressIndex{}) {
+		subaddresses = append(subaddresses, w.SubAddress(s.Change).Ksi)
+	}
+	if len(s.AdditionalTxKeys) != len(s.Tx.AdditionalKeys) || !isTxPublicKey(s.Tx.R, s.TxKey, subaddresses) {
+		err = err_msg.ErrTxKey
+		return
+	}
+	for i, K := range s.Tx.AdditionalKeys {
+		if !isTxPublicKey(K, s.AdditionalTxKeys[i], subaddresses) {
+			err = err_msg.ErrTxKey
+			return
+		}
+	}
+	return
+}
+
+// isTxPublicKey returns whether K = k G or K = k Ksi for one of the subaddress spend keys
+func isTxPublicKey(K *crypto.PublicKey, k *crypto.PrivateKey, subaddresses []*crypto.PublicKey) (r bool) {
+	if K == nil || k == nil {
+		return
+	}
+	b := K.Bytes()
+	r = bytes.Equal(k.MultG().Bytes(), b)
+	for _, Ksi := range subaddresses {
+		r = r || bytes.Equal(Ksi.ScalarMult(k).Bytes(), b)
+	}
+	return
+}
+
+// BroadcastSignedTx imports the transaction signed by SignTx and relays it with b, the outputs it spends are marked
+// spent once it is found in a block
+func (w *Wallet) BroadcastSignedTx(signed []byte, b Broadcaster) (tx *RingCTTx, err error) {
+	if tx, err = w.ImportSignedTx(signed); err != nil {
+		return
+	}
+	if err = b.Broadcast(tx.Bytes()); err != nil {
+		tx = nil
+	}
+	return
+}
@@ -0,0 +1,266 @@
+package wallet
+
+import (
+	"encoding/json"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"path/filepath"
+	"testing"
+)
+
+type testBroadcaster struct {
+	txs [][]byte
+}
+
+func (b *testBroadcaster) Broadcast(tx []byte) (err error) {
+	b.txs = append(b.txs, tx)
+	return
+}
+
+func TestColdSigning(t *testing.T) {
+	cold := NewWallet()
+	hot := NewViewOnlyWallet(cold.kv, cold.address.Ks, cold.address.Network)
+	if !hot.IsViewOnly() || cold.IsViewOnly() {
+		t.Fatal("wrong view only wallet")
+	}
+	if _, err := hot.SubAddressPrivateSpendKey(SubAddressIndex{0, 1}); err != err_msg.ErrWatchOnly {
+		t.Errorf("want %v, got %v", err_msg.ErrWatchOnly, err)
+	}
+	if _, err := hot.StandardAddressOneTimeAddressPrivateKey(cold.address.Kv, 0); err != err_msg.ErrWatchOnly {
+		t.Errorf("want %v, got %v", err_msg.ErrWatchOnly, err)
+	}
+	if _, err := hot.SubaddressOutputPrivateKey(cold.address.Kv, 0, SubAddressIndex{0, 1}); err != err_msg.ErrWatchOnly {
+		t.Errorf("want %v, got %v", err_msg.ErrWatchOnly, err)
+	}
+	sources := newTestSources(t, hot, 3000, 5000)
+	for _, s := range sources {
+		if s.Output.KeyImage != nil {
+			t.Fatal("view only wallet computed a key image")
+		}
+		if err := hot.AddOutput(s.Output); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outputs, err := hot.ExportOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := cold.ImportOutputs(outputs); err != nil || n != 2 {
+		t.Fatalf("want 2 outputs imported, got %d: %v", n, err)
+	}
+	if n, err := cold.ImportOutputs(outputs); err != nil || n != 0 {
+		t.Fatalf("want no output imported twice, got %d: %v", n, err)
+	}
+	if _, err = hot.ExportKeyImages(); err != err_msg.ErrWatchOnly {
+		t.Errorf("want %v, got %v", err_msg.ErrWatchOnly, err)
+	}
+	keyImages, err := cold.ExportKeyImages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := hot.ImportKeyImages(keyImages); err != nil || n != 2 {
+		t.Fatalf("want 2 key images imported, got %d: %v", n, err)
+	}
+	for _, o := range hot.OutputStore().Outputs() {
+		if o.KeyImage.Equal(cold.outputPrivateKey(o).KeyImage()) == 0 {
+			t.Error("wrong key image imported")
+		}
+	}
+
+	other := NewWallet()
+	other.InitializeSubAddressLookup(1, 2)
+	destinations := []TxDestination{{Address: other.SubAddress(SubAddressIndex{0, 1}), Amount: 1000}}
+	if _, err = hot.BuildTx(destinations, sources, 100, SubAddressIndex{}); err != err_msg.ErrWatchOnly {
+		t.Errorf("want %v, got %v", err_msg.ErrWatchOnly, err)
+	}
+	unsigned, err := hot.ExportUnsignedTx(destinations, sources, 100, SubAddressIndex{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cold.SignTx(unsigned, 0, nil); err != err_msg.ErrTxFee {
+		t.Errorf("fee above the estimate: want %v, got %v", err_msg.ErrTxFee, err)
+	}
+	reject := func(*TxSummary) bool { return false }
+	if _, err = cold.SignTx(unsigned, 1, reject); err != err_msg.ErrTxRejected {
+		t.Errorf("rejected: want %v, got %v", err_msg.ErrTxRejected, err)
+	}
+	var summary *TxSummary
+	signed, err := cold.SignTx(unsigned, 1, func(s *TxSummary) bool {
+		summary = s
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary == nil || len(summary.Destinations) != 1 || summary.Destinations[0].Amount != 1000 ||
+		summary.Destinations[0].Address.SpendKey().Equal(destinations[0].Address.SpendKey()) == 0 ||
+		summary.InputAmount != 8000 || summary.Fee != 100 || summary.ChangeAmount != 6900 {
+		t.Errorf("wrong summary %+v", summary)
+	}
+	b := new(testBroadcaster)
+	tx, err := hot.BroadcastSignedTx(signed, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range sources {
+		s.Output.KeyImage = cold.outputPrivateKey(s.Output).KeyImage()
+	}
+	if err = tx.Verify(sourceRings(tx, sources)); err != nil {
+		t.Error(err)
+	}
+	if len(b.txs) != 1 || string(b.txs[0]) != string(tx.Bytes()) {
+		t.Error("transaction not broadcast")
+	}
+	if got := receivedAmount(t, other, tx); got != 1000 {
+		t.Errorf("want 1000 received, got %d", got)
+	}
+	// the online wallet proves the payment with the keys of the offline wallet
+	want, _, _ := cold.TxKey(tx.Hash())
+	if r, _, ok := hot.TxKey(tx.Hash()); !ok || r.Equal(want) == 0 {
+		t.Error("transaction keys not imported")
+	}
+
+	// the online wallet does not record keys which are not those of the transaction
+	payload, err := hot.decryptWithViewKey(signedTxMagic, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := new(signedTx)
+	if err = json.Unmarshal(payload, st); err != nil {
+		t.Fatal(err)
+	}
+	st.TxKey = crypto.NewRandomScalar()
+	if payload, err = json.Marshal(st); err != nil {
+		t.Fatal(err)
+	}
+	if signed, err = hot.encryptWithViewKey(signedTxMagic, payload); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = hot.ImportSignedTx(signed); err != err_msg.ErrTxKey {
+		t.Errorf("wrong transaction key: want %v, got %v", err_msg.ErrTxKey, err)
+	}
+}
+
+func TestColdSigningFiles(t *testing.T) {
+	w := NewWallet()
+	sources := newTestSources(t, w, 3000)
+	if err := w.AddOutput(sources[0].Output); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := w.ExportOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := append([]byte{}, outputs...)
+	tampered[len(outputExportMagic)+legacyIVLength] ^= 1
+	if _, err = w.ImportOutputs(tampered); err != err_msg.ErrColdSigningFile {
+		t.Errorf("tampered: want %v, got %v", err_msg.ErrColdSigningFile, err)
+	}
+	if _, err = w.ImportKeyImages(outputs); err != err_msg.ErrColdSigningFile {
+		t.Errorf("wrong magic: want %v, got %v", err_msg.ErrColdSigningFile, err)
+	}
+	if _, err = NewWallet().ImportOutputs(outputs); err != err_msg.ErrColdSigningFile {
+		t.Errorf("wrong view key: want %v, got %v", err_msg.ErrColdSigningFile, err)
+	}
+	// same view key, other spend key
+	twin := NewViewOnlyWallet(w.kv, crypto.NewRandomScalar().MultG(), address.MainNetwork)
+	if _, err = twin.ImportOutputs(outputs); err != err_msg.ErrColdSigningWallet {
+		t.Errorf("wrong wallet: want %v, got %v", err_msg.ErrColdSigningWallet, err)
+	}
+}
+
+func TestImportKeyImagesFailure(t *testing.T) {
+	cold := NewWallet()
+	hot := NewViewOnlyWallet(cold.kv, cold.address.Ks, cold.address.Network)
+	sources := newTestSources(t, hot, 1000, 2000, 3000)
+	for _, s := range sources {
+		if err := hot.AddOutput(s.Output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputs, err := hot.ExportOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cold.ImportOutputs(outputs); err != nil {
+		t.Fatal(err)
+	}
+	keyImages, err := cold.ExportKeyImages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second output can not be stored
+	blocking := newTestOutput(0, 1)
+	blocking.KeyImage = cold.outputPrivateKey(hot.outputs.Pending()[1]).KeyImage()
+	if err = hot.outputs.Add(blocking); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = hot.ImportKeyImages(keyImages); err != err_msg.ErrDuplicateOutput {
+		t.Fatalf("want %v, got %v", err_msg.ErrDuplicateOutput, err)
+	}
+	pending := hot.outputs.Pending()
+	if len(pending) != 2 || pending[0].Ko.Equal(sources[1].Output.Ko) == 0 || pending[1].Ko.Equal(sources[2].Output.Ko) == 0 {
+		t.Fatalf("want the outputs which were not imported pending, got %d", len(pending))
+	}
+	for _, o := range pending {
+		if o.KeyImage != nil {
+			t.Errorf("pending output modified by the failed import")
+		}
+	}
+	if len(hot.OutputStore().Outputs()) != 2 {
+		t.Errorf("want the first output imported")
+	}
+}
+
+func TestPendingOutputsPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outputs")
+	cold := NewWallet()
+	hot := NewViewOnlyWallet(cold.kv, cold.address.Ks, cold.address.Network)
+	s, err := NewFileOutputStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hot.SetOutputStore(s)
+	sources := newTestSources(t, hot, 1000, 2000)
+	for _, source := range sources {
+		if err = hot.AddOutput(source.Output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outputs, err := hot.ExportOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cold.ImportOutputs(outputs); err != nil {
+		t.Fatal(err)
+	}
+	keyImages, err := cold.ExportKeyImages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the view only wallet restarts before the key images are imported
+	restarted := NewViewOnlyWallet(cold.kv, cold.address.Ks, cold.address.Network)
+	if s, err = NewFileOutputStore(path); err != nil {
+		t.Fatal(err)
+	}
+	restarted.SetOutputStore(s)
+	if len(s.Pending()) != 2 || len(s.Outputs()) != 0 {
+		t.Fatalf("want 2 pending outputs after a restart, got %d", len(s.Pending()))
+	}
+	if err = restarted.AddOutput(sources[0].Output); err != err_msg.ErrDuplicateOutput {
+		t.Errorf("want %v, got %v", err_msg.ErrDuplicateOutput, err)
+	}
+	if n, err := restarted.ImportKeyImages(keyImages); err != nil || n != 2 {
+		t.Fatalf("want 2 key images imported, got %d: %v", n, err)
+	}
+	if s, err = NewFileOutputStore(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Pending()) != 0 || s.Balance() != 3000 {
+		t.Errorf("want the imported outputs in the file, got %d pending and a balance of %d", len(s.Pending()), s.Balance())
+	}
+}
@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"gomonero/crypto"
 	"gomonero/err_msg"
 )
@@ -16,6 +17,17 @@ func (a amount64) Scalar() (r *crypto.Scalar) {
 	return
 }
 
+// newAmount64 returns amount encoded little endian
+func newAmount64(amount uint64) (a amount64) {
+	binary.LittleEndian.PutUint64(a[:], amount)
+	return
+}
+
+func (a amount64) Uint64() (r uint64) {
+	r = binary.LittleEndian.Uint64(a[:])
+	return
+}
+
 // newRandomAmount returns a random amount for use in testing
 func newRandomAmount() (a amount64) {
 	aSlice := make([]byte, 8)
@@ -0,0 +1,214 @@
+package wallet
+
+import (
+	"gomonero/address"
+	"math/bits"
+)
+
+// fee estimation from the per byte base fee of the daemon
+//
+// monero/src/cryptonote_core/blockchain.cpp get_dynamic_base_fee, get_dynamic_base_fee_estimate_2021_scaling
+// monero/src/wallet/wallet2.cpp estimate_rct_tx_size, estimate_tx_weight, calculate_fee_from_weight
+
+// monero/src/cryptonote_config.h
+const (
+	feeReferenceTxWeight  = 3000   //DYNAMIC_FEE_REFERENCE_TRANSACTION_WEIGHT
+	minBlockWeight        = 300000 //CRYPTONOTE_BLOCK_GRANTED_FULL_REWARD_ZONE_V5
+	FeeQuantizationMask   = 10000  //10^(CRYPTONOTE_DISPLAY_DECIMAL_POINT - PER_KB_FEE_QUANTIZATION_DECIMALS)
+	bulletproofPlusFields = 6      //A, A1, B, r1, s1, d1
+	feeRoundingDigits     = 2      //CRYPTONOTE_SCALING_2021_FEE_ROUNDING_PLACES
+)
+
+// FeePriority is the priority of a transaction, higher priorities pay more to be mined sooner
+type FeePriority int
+
+const (
+	PriorityLow FeePriority = 1 + iota
+	PriorityNormal
+	PriorityElevated
+	PriorityHighest
+)
+
+// FeeMultiplier returns the multiplier of the base fee for priority, priorities out of range are normal
+//
+// monero/src/wallet/wallet2.cpp get_fee_multiplier
+func FeeMultiplier(priority FeePriority) (m uint64) {
+	multipliers := [4]uint64{1, 5, 25, 1000}
+	if priority < PriorityLow || priority > PriorityHighest {
+		priority = PriorityNormal
+	}
+	m = multipliers[priority-1]
+	return
+}
+
+// DynamicBaseFee returns the per byte base fee for the block reward and median block weight
+// fee = 0.95 * reward * 3000 / median^2
+func DynamicBaseFee(blockReward, medianWeight uint64) (fee uint64) {
+	if medianWeight < minBlockWeight {
+		medianWeight = minBlockWeight
+	}
+	fee = mulDiv(blockReward, feeReferenceTxWeight, medianWeight, medianWeight)
+	fee -= fee / 20
+	if fee == 0 {
+		fee = 1
+	}
+	return
+}
+
+// DynamicBaseFees returns the per byte fees of the four priorities, from the block reward, the median block weight
+// and the long term effective median block weight, rounded up to 2 significant digits
+//
+// https://github.com/ArticMine/Monero-Documents/blob/master/MoneroScaling2021-02.pdf
+func DynamicBaseFees(blockReward, medianWeight, longTermWeight uint64) (fees [4]uint64) {
+	Mnw := medianWeight
+	if Mnw < minBlockWeight {
+		Mnw = minBlockWeight
+	}
+	Mfw := Mnw
+	if longTermWeight < Mfw {
+		Mfw = longTermWeight
+	}
+	if Mfw < minBlockWeight {
+		Mfw = minBlockWeight
+	}
+	// Fl = R Wr / Mfw^2, Fn = 4 Fl
+	fees[0] = mulDiv(blockReward, feeReferenceTxWeight, Mfw, Mfw)
+	fees[1] = 4 * fees[0]
+	// Fm = 16 R Wr / (Zm Mfw)
+	fees[2] = mulDiv(blockReward, 16*feeReferenceTxWeight, minBlockWeight, Mfw)
+	// Fh = 4 Fm max(1, Mfw / (32 Wr Mnw / Zm))
+	fees[3] = 4 * fees[2]
+	if Mfw*minBlockWeight > 32*feeReferenceTxWeight*Mnw {
+		fees[3] = mulDiv(fees[3]*minBlockWeight, Mfw, 32*feeReferenceTxWeight, Mnw)
+	}
+	for n := range fees {
+		fees[n] = roundMoneyUp(fees[n], feeRoundingDigits)
+	}
+	return
+}
+
+// roundMoneyUp rounds amount up to digits significant digits
+//
+// monero/src/cryptonote_basic/cryptonote_format_utils.cpp round_money_up
+func roundMoneyUp(amount uint64, digits int) (r uint64) {
+	unit := uint64(1)
+	for n := amount; n >= 10; n /= 10 {
+		if digits > 1 {
+			digits--
+		} else {
+			unit *= 10
+		}
+	}
+	r = (amount + unit - 1) / unit * unit
+	return
+}
+
+// BaseFee returns the per byte fee of priority from the fees of DynamicBaseFees
+func BaseFee(fees [4]uint64, priority FeePriority) (fee uint64) {
+	if priority < PriorityLow || priority > PriorityHighest {
+		priority = PriorityNormal
+	}
+	fee = fees[priority-1]
+	return
+}
+
+// FeeFromWeight returns the fee of a transaction of weight, rounded up to a multiple of quantizationMask
+func FeeFromWeight(weight, baseFee, quantizationMask uint64) (fee uint64) {
+	fee = weight * baseFee
+	if quantizationMask > 1 {
+		fee = (fee + quantizationMask - 1) / quantizationMask * quantizationMask
+	}
+	return
+}
+
+// bulletproofClawback returns the weight added to transactions with more than two outputs, so that the weight of
+// their aggregated range proof grows linearly with the number of outputs like the verification time
+//
+// monero/src/cryptonote_basic/cryptonote_format_utils.cpp get_transaction_weight_clawback
+func bulletproofClawback(outputs int) (clawback uint64) {
+	if outputs <= 2 {
+		return
+	}
+	paddedOutputs, logPadded := 1, 0
+	for paddedOutputs < outputs {
+		paddedOutputs <<= 1
+		logPadded++
+	}
+	// notional size of a 2 output proof divided by 2
+	const base = 32 * (bulletproofPlusFields + 7*2) / 2
+	size := 32 * (bulletproofPlusFields + 2*(6+logPadded))
+	clawback = uint64(base*paddedOutputs-size) * 4 / 5
+	return
+}
+
+// TxExtraSize returns the size of the extra of a transaction built by BuildTx paying destinations and change to
+// subaddress change of the wallet
+func TxExtraSize(destinations []TxDestination, change SubAddressIndex) (size int) {
+	size = 1 + 32 //transaction public key
+	// the change is not counted to choose the keys
+	subaddresses, outputs := 0, len(destinations)+1
+	integrated := false
+	for _, d := range destinations {
+		if d.Address.IsSubaddress() {
+			subaddresses++
+		}
+		if _, ok := d.Address.(*address.IntegratedAddress); ok {
+			integrated = true
+		}
+	}
+	if subaddresses > 0 && (subaddresses < len(destinations) || subaddresses > 1) {
+		size += 1 + 1 + 32*outputs //additional public keys
+	}
+	// encrypted payment id, a dummy one is added with a single destination
+	if integrated || len(destinations) == 1 {
+		size += 1 + 1 + 1 + address.PaymentIDLength
+	}
+	return
+}
+
+// EstimateTxSize returns an upper bound on the size of a transaction with inputs rings of ringSize members,
+// outputs outputs and extraSize bytes of extra
+func EstimateTxSize(inputs, ringSize, outputs, extraSize int) (size uint64) {
+	n := 1 + 6                              //version and unlock time
+	n += inputs * (1 + 6 + ringSize*2 + 32) //key offsets and key images
+	n += outputs * (6 + 32 + 1)             //one time addresses and view tags
+	n += extraSize + 1                      //extra and type
+	logPadded := 0
+	for 1<<logPadded < outputs {
+		logPadded++
+	}
+	n += (2*(6+logPadded)+bulletproofPlusFields)*32 + 3 //range proof
+	n += inputs * (32*ringSize + 64)                    //CLSAGs
+	n += 32 * inputs                                    //pseudo outputs
+	n += (8 + 32) * outputs                             //encrypted amounts and commitments
+	n += 4                                              //fee
+	size = uint64(n)
+	return
+}
+
+// EstimateTxWeight returns an upper bound on the weight of a transaction with inputs rings of ringSize members,
+// outputs outputs and extraSize bytes of extra
+func EstimateTxWeight(inputs, ringSize, outputs, extraSize int) (weight uint64) {
+	weight = EstimateTxSize(inputs, ringSize, outputs, extraSize) + bulletproofClawback(outputs)
+	return
+}
+
+// EstimateFee returns the fee of a transaction spending inputs outputs with rings of ringSize members to pay
+// destinations and change, at the per byte baseFee, so that it can be quoted before signing
+func EstimateFee(destinations []TxDestination, change SubAddressIndex, inputs, ringSize int, baseFee uint64) (fee uint64) {
+	weight := EstimateTxWeight(inputs, ringSize, len(destinations)+1, TxExtraSize(destinations, change))
+	fee = FeeFromWeight(weight, baseFee, FeeQuantizationMask)
+	return
+}
+
+// mulDiv returns a * b / c / d with a 128 bit intermediate product, the result saturates on overflow
+func mulDiv(a, b, c, d uint64) (r uint64) {
+	hi, lo := bits.Mul64(a, b)
+	if hi >= c {
+		r = ^uint64(0)
+		return
+	}
+	r, _ = bits.Div64(hi, lo, c)
+	r /= d
+	return
+}
@@ -0,0 +1,101 @@
+package wallet
+
+import (
+	"gomonero/address"
+	"testing"
+)
+
+func TestDynamicBaseFees(t *testing.T) {
+	const reward = 600000000000
+	if fee := DynamicBaseFee(reward, 100000); fee != 19000 {
+		t.Errorf("want 19000, got %d", fee)
+	}
+	if fee := DynamicBaseFee(reward, 600000); fee != 4750 {
+		t.Errorf("want 4750, got %d", fee)
+	}
+	for _, test := range []struct {
+		reward, median, longTerm uint64
+		fees                     [4]uint64
+	}{
+		{reward, 300000, 400000, [4]uint64{20000, 80000, 320000, 4000000}},
+		{reward, 100000, 100000, [4]uint64{20000, 80000, 320000, 4000000}},
+		{612345678901, 300000, 300000, [4]uint64{21000, 82000, 330000, 4100000}},
+		{reward, 1000000, 2000000, [4]uint64{1800, 7200, 96000, 1200000}},
+	} {
+		if fees := DynamicBaseFees(test.reward, test.median, test.longTerm); fees != test.fees {
+			t.Errorf("reward %d, medians %d %d: want %v, got %v", test.reward, test.median, test.longTerm, test.fees, fees)
+		}
+	}
+	fees := DynamicBaseFees(reward, 300000, 400000)
+	if fee := BaseFee(fees, PriorityElevated); fee != 320000 {
+		t.Errorf("want 320000, got %d", fee)
+	}
+	for _, test := range [][2]uint64{{0, 0}, {7, 7}, {20411, 21000}, {20000, 20000}, {995, 1000}, {1001, 1100}} {
+		if got := roundMoneyUp(test[0], feeRoundingDigits); got != test[1] {
+			t.Errorf("round %d: want %d, got %d", test[0], test[1], got)
+		}
+	}
+	if m := FeeMultiplier(PriorityHighest); m != 1000 {
+		t.Errorf("want 1000, got %d", m)
+	}
+	if m := FeeMultiplier(0); m != 5 {
+		t.Errorf("want the normal multiplier 5, got %d", m)
+	}
+	if fee := FeeFromWeight(1501, 19000, FeeQuantizationMask); fee != 28520000 {
+		t.Errorf("want 28520000, got %d", fee)
+	}
+}
+
+func TestBulletproofClawback(t *testing.T) {
+	for _, test := range []struct {
+		outputs  int
+		clawback uint64
+	}{
+		{1, 0}, {2, 0}, {3, 460}, {4, 460}, {5, 1433}, {16, 3430},
+	} {
+		if got := bulletproofClawback(test.outputs); got != test.clawback {
+			t.Errorf("%d outputs: want %d, got %d", test.outputs, test.clawback, got)
+		}
+	}
+}
+
+// the estimates are upper bounds close to the weight of built transactions
+func TestEstimateTxWeight(t *testing.T) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(2, 5)
+	other := NewWallet()
+	other.InitializeSubAddressLookup(2, 5)
+	for _, test := range []struct {
+		name         string
+		destinations []address.Destination
+		change       SubAddressIndex
+	}{
+		{"standard address", []address.Destination{&other.address}, SubAddressIndex{}},
+		{"subaddress change", []address.Destination{&other.address}, SubAddressIndex{1, 1}},
+		{"subaddress", []address.Destination{other.SubAddress(SubAddressIndex{1, 2})}, SubAddressIndex{1, 1}},
+		{"several addresses", []address.Destination{&other.address, other.SubAddress(SubAddressIndex{0, 3}), &other.address}, SubAddressIndex{}},
+	} {
+		sources := newTestSources(t, w, 3000000000, 5000000000)
+		var destinations []TxDestination
+		for _, d := range test.destinations {
+			destinations = append(destinations, TxDestination{Address: d, Amount: 1000000000})
+		}
+		const baseFee = 20000
+		fee := EstimateFee(destinations, test.change, len(sources), testRingSize, baseFee)
+		tx, err := w.BuildTx(destinations, sources, fee, test.change)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := len(tx.Extra()); got != TxExtraSize(destinations, test.change) {
+			t.Errorf("%s: want extra size %d, got %d", test.name, TxExtraSize(destinations, test.change), got)
+		}
+		weight := tx.Weight()
+		estimate := EstimateTxWeight(len(sources), testRingSize, len(tx.Outputs), len(tx.Extra()))
+		if estimate < weight || estimate > weight+weight/20 {
+			t.Errorf("%s: estimated weight %d, actual weight %d", test.name, estimate, weight)
+		}
+		if fee < weight*baseFee {
+			t.Errorf("%s: fee %d does not cover weight %d", test.name, fee, weight)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package wallet
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+
+	"golang.org/x/crypto/twofish"
+)
+
+// JamtisAddressIndex is the 128 bit index j of a jamtis address
+type JamtisAddressIndex [address.JamtisAddressIndexLength]byte
+
+// NewJamtisAddressIndex returns the address index with n encoded little endian in its first bytes
+func NewJamtisAddressIndex(n uint64) (j JamtisAddressIndex) {
+	binary.LittleEndian.PutUint64(j[:], n)
+	return
+}
+
+type jamtisAddressTag = [address.JamtisAddressTagLength]byte
+
+// newAddressTagCipher returns the block cipher keyed with the cipher-tag secret sct
+func newAddressTagCipher(sct *crypto.Scalar) (c cipher.Block) {
+	// a 32 byte key is always valid
+	c, _ = twofish.NewCipher(sct.Bytes())
+	return
+}
+
+// cipherAddressIndex returns the address tag of j, j ciphered with the cipher-tag key followed by a hint
+// addr_tag = cipher[sct](j) || H_2(sct || cipher[sct](j))
+func (w *JamtisWallet) cipherAddressIndex(j JamtisAddressIndex) (tag jamtisAddressTag) {
+	w.tagCipher.Encrypt(tag[:address.JamtisAddressIndexLength], j[:])
+	hint := w.addressTagHint(tag[:address.JamtisAddressIndexLength])
+	copy(tag[address.JamtisAddressIndexLength:], hint[:])
+	return
+}
+
+// decipherAddressTag recovers the address index from tag, tags which were not ciphered with the key of the
+// wallet are recognized by their hint
+func (w *JamtisWallet) decipherAddressTag(tag jamtisAddressTag) (j JamtisAddressIndex, err error) {
+	hint := w.addressTagHint(tag[:address.JamtisAddressIndexLength])
+	for n, b := range hint {
+		if tag[address.JamtisAddressIndexLength+n] != b {
+			err = err_msg.ErrAddressTag
+			return
+		}
+	}
+	w.tagCipher.Decrypt(j[:], tag[:address.JamtisAddressIndexLength])
+	return
+}
+
+func (w *JamtisWallet) addressTagHint(cipheredIndex []byte) (r [address.JamtisAddressTagHintLength]byte) {
+	h := crypto.Keccak256([]byte("address tag hint\x00"), w.sct.Bytes(), cipheredIndex)
+	copy(r[:], h[:])
+	return
+}
+
+// encryptAddressTag encrypts the address tag of an output with the sender-receiver secret q, it also decrypts
+// addr_tag_enc = addr_tag XOR H_18(q || Ko)
+func encryptAddressTag(tag jamtisAddressTag, q *crypto.Scalar, Ko *crypto.Point) (r jamtisAddressTag) {
+	mask := crypto.Keccak256([]byte("address tag encryption\x00"), q.Bytes(), Ko.Bytes())
+	for n := range tag {
+		r[n] = tag[n] ^ mask[n]
+	}
+	return
+}
@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"gomonero/seraphis"
+)
+
+// JamtisPayment is an amount paid to a jamtis address
+type JamtisPayment struct {
+	Address *address.JamtisAddress
+	Amount  uint64
+}
+
+// selfSendSecret returns the sender-receiver secret of a self-send output of type t with ephemeral key Ke
+// the secret is derived from kvb instead of the view tag derivation, so only the wallet can compute it
+// q = Hs("sender-receiver secret self-send" || t || kvb || Ke)
+func (w *JamtisWallet) selfSendSecret(t seraphis.EnoteType, Ke *crypto.Point) (q *crypto.Scalar) {
+	q = crypto.HashToScalar([]byte("sender-receiver secret self-send\x00"), []byte{byte(t)}, w.kvb.Bytes(), Ke.Bytes())
+	return
+}
+
+// CreateSelfSendOutput creates an output of type t paying amount to the address of the wallet at j
+func (w *JamtisWallet) CreateSelfSendOutput(t seraphis.EnoteType, j JamtisAddressIndex, amount amount64) (p *seraphis.OutputProposal, err error) {
+	if t == seraphis.EnoteTypePlain || t > seraphis.EnoteTypeSelfSpend {
+		err = err_msg.ErrOutOfBounds
+		return
+	}
+	if t == seraphis.EnoteTypeDummy && amount != (amount64{}) {
+		err = err_msg.ErrDummyAmount
+		return
+	}
+	a, err := w.Address(j)
+	if err != nil {
+		return
+	}
+	p, err = createOutput(a, amount, func(Kd, Ke *crypto.Point) *crypto.Scalar {
+		return w.selfSendSecret(t, Ke)
+	})
+	return
+}
+
+// CreateTransactionOutputs returns the outputs of a transaction spending inputs worth inputAmount to pay payments
+// and fee, the rest is returned to the address of the wallet at change
+//
+// payments to addresses of the wallet are self-spends, transactions have at least two outputs, a dummy is added
+// if there is no change, and the outputs are sorted by one time address
+func (w *JamtisWallet) CreateTransactionOutputs(payments []JamtisPayment, inputAmount, fee uint64, change JamtisAddressIndex) (outputs []*seraphis.OutputProposal, err error) {
+	if len(payments) == 0 {
+		err = err_msg.ErrNoDestinations
+		return
+	}
+	spent := fee
+	for _, p := range payments {
+		if spent+p.Amount < spent {
+			err = err_msg.ErrInsufficientFunds
+			return
+		}
+		spent += p.Amount
+	}
+	if spent > inputAmount {
+		err = err_msg.ErrInsufficientFunds
+		return
+	}
+
+	for _, p := range payments {
+		var o *seraphis.OutputProposal
+		if j, ok := w.ownAddressIndex(p.Address); ok {
+			o, err = w.CreateSelfSendOutput(seraphis.EnoteTypeSelfSpend, j, newAmount64(p.Amount))
+		} else {
+			o, err = w.CreateOutput(p.Address, newAmount64(p.Amount))
+		}
+		if err != nil {
+			outputs = nil
+			return
+		}
+		outputs = append(outputs, o)
+	}
+
+	var o *seraphis.OutputProposal
+	switch {
+	case inputAmount > spent:
+		o, err = w.CreateSelfSendOutput(seraphis.EnoteTypeChange, change, newAmount64(inputAmount-spent))
+	case len(outputs) < 2:
+		o, err = w.CreateSelfSendOutput(seraphis.EnoteTypeDummy, change, amount64{})
+	}
+	if err != nil {
+		outputs = nil
+		return
+	}
+	if o != nil {
+		outputs = append(outputs, o)
+	}
+
+	seraphis.SortOutputs(outputs)
+	return
+}
+
+// ownAddressIndex returns the index of a if it is an address of the wallet
+func (w *JamtisWallet) ownAddressIndex(a *address.JamtisAddress) (j JamtisAddressIndex, ok bool) {
+	j, err := w.decipherAddressTag(a.Tag)
+	if err != nil {
+		return
+	}
+	own, err := w.Address(j)
+	ok = err == nil && own.K1.Equal(a.K1) == 1 && own.K2.Equal(a.K2) == 1 && own.K3.Equal(a.K3) == 1
+	return
+}
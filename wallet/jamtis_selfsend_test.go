@@ -0,0 +1,83 @@
+package wallet
+
+import (
+	"gomonero/err_msg"
+	"gomonero/seraphis"
+	"testing"
+)
+
+func TestJamtisTransactionOutputs(t *testing.T) {
+	w := NewJamtisWallet()
+	other := NewJamtisWallet()
+
+	otherAddress, _ := other.Address(NewJamtisAddressIndex(7))
+	ownAddress, _ := w.Address(NewJamtisAddressIndex(3))
+	change := NewJamtisAddressIndex(0)
+
+	payments := []JamtisPayment{{otherAddress, 1000}, {ownAddress, 200}}
+	outputs, err := w.CreateTransactionOutputs(payments, 1500, 50, change)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(outputs) != 3 {
+		t.Fatalf("want 3 outputs, got %d", len(outputs))
+	}
+
+	received := make(map[seraphis.EnoteType]uint64)
+	var paid uint64
+	for _, o := range outputs {
+		if r, err := w.ReceiveOutput(&o.Enote, o.Ke); err == nil {
+			if r.Type == seraphis.EnoteTypePlain {
+				t.Errorf("output of the wallet is not a self-send")
+			}
+			received[r.Type] += r.Amount
+			continue
+		}
+		r, err := other.ReceiveOutput(&o.Enote, o.Ke)
+		if err != nil {
+			t.Errorf("output received by neither wallet: %v", err)
+			continue
+		}
+		if r.Type != seraphis.EnoteTypePlain {
+			t.Errorf("payment received as a self-send")
+		}
+		paid += r.Amount
+	}
+	if paid != 1000 || received[seraphis.EnoteTypeSelfSpend] != 200 || received[seraphis.EnoteTypeChange] != 250 {
+		t.Errorf("wrong amounts: paid %d, received %v", paid, received)
+	}
+}
+
+func TestJamtisDummyOutput(t *testing.T) {
+	w := NewJamtisWallet()
+	a, _ := NewJamtisWallet().Address(NewJamtisAddressIndex(1))
+
+	outputs, err := w.CreateTransactionOutputs([]JamtisPayment{{a, 100}}, 110, 10, NewJamtisAddressIndex(0))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("want 2 outputs, got %d", len(outputs))
+	}
+	dummies := 0
+	for _, o := range outputs {
+		r, err := w.ReceiveOutput(&o.Enote, o.Ke)
+		if err != nil {
+			continue
+		}
+		if r.Type != seraphis.EnoteTypeDummy || r.Amount != 0 {
+			t.Errorf("want a zero amount dummy output")
+		}
+		dummies++
+	}
+	if dummies != 1 {
+		t.Errorf("want 1 dummy output, got %d", dummies)
+	}
+
+	if _, err = w.CreateTransactionOutputs([]JamtisPayment{{a, 100}}, 100, 10, NewJamtisAddressIndex(0)); err != err_msg.ErrInsufficientFunds {
+		t.Errorf("want %v, got %v", err_msg.ErrInsufficientFunds, err)
+	}
+	if _, err = w.CreateSelfSendOutput(seraphis.EnoteTypeDummy, NewJamtisAddressIndex(0), newAmount64(1)); err != err_msg.ErrDummyAmount {
+		t.Errorf("want %v, got %v", err_msg.ErrDummyAmount, err)
+	}
+}
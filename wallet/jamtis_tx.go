@@ -0,0 +1,168 @@
+package wallet
+
+import (
+	"bytes"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"gomonero/seraphis"
+	"sort"
+)
+
+// jamtisInput is an enote of the wallet being spent
+type jamtisInput struct {
+	record      *seraphis.EnoteRecord
+	core        seraphis.EnoteCore
+	ledgerIndex uint64
+	image       *seraphis.EnoteImage
+	tk, tc      *crypto.Scalar //masks of the enote image
+}
+
+// BuildTx returns a transaction paying payments with the unspent enotes of the wallet in ledger, the change is sent
+// to the address of the wallet at change and the fee is feePerWeight per unit of transaction weight
+func (w *JamtisWallet) BuildTx(payments []JamtisPayment, feePerWeight uint64, change JamtisAddressIndex,
+	ledger seraphis.Ledger, params seraphis.TxParams) (tx *seraphis.Tx, err error) {
+	inputs, inputAmount, fee, err := w.selectInputs(payments, feePerWeight, ledger, params)
+	if err != nil {
+		return
+	}
+	outputs, err := w.CreateTransactionOutputs(payments, inputAmount, fee, change)
+	if err != nil {
+		return
+	}
+
+	for _, in := range inputs {
+		in.image, in.tk, in.tc = seraphis.NewEnoteImage(in.core.Ko, in.core.C, in.record.KeyImage)
+	}
+	sort.Slice(inputs, func(a, b int) bool {
+		return bytes.Compare(inputs[a].record.KeyImage.Bytes(), inputs[b].record.KeyImage.Bytes()) < 0
+	})
+
+	tx = &seraphis.Tx{Fee: fee}
+	for _, in := range inputs {
+		tx.Images = append(tx.Images, in.image)
+	}
+	for _, o := range outputs {
+		tx.Outputs = append(tx.Outputs, o.Enote)
+		tx.EphemeralKeys = append(tx.EphemeralKeys, o.Ke)
+	}
+	m := tx.Message()
+
+	for _, in := range inputs {
+		var proof *crypto.CompositionProof
+		if proof, err = w.imageProof(m, in); err != nil {
+			tx = nil
+			return
+		}
+		tx.ImageProofs = append(tx.ImageProofs, proof)
+
+		var membership *seraphis.MembershipProof
+		if membership, err = membershipProof(m, in, ledger, params); err != nil {
+			tx = nil
+			return
+		}
+		tx.MembershipProofs = append(tx.MembershipProofs, membership)
+	}
+
+	if tx.BalanceProof, err = balanceProof(inputs, outputs); err != nil {
+		tx = nil
+	}
+	return
+}
+
+// selectInputs returns unspent enotes of the wallet worth at least the payments and the fee of a transaction
+// spending them, the largest enotes are spent first
+func (w *JamtisWallet) selectInputs(payments []JamtisPayment, feePerWeight uint64, ledger seraphis.Ledger,
+	params seraphis.TxParams) (inputs []*jamtisInput, inputAmount, fee uint64, err error) {
+	var paid uint64
+	for _, p := range payments {
+		if paid+p.Amount < paid {
+			err = err_msg.ErrInsufficientFunds
+			return
+		}
+		paid += p.Amount
+	}
+	// change or a dummy
+	numOutputs := len(payments) + 1
+	if numOutputs < 2 {
+		numOutputs = 2
+	}
+
+	var candidates []*jamtisInput
+	for _, r := range w.EnoteRecords() {
+		if ledger.KeyImageSpent(r.KeyImage) {
+			continue
+		}
+		index, ok := ledger.EnoteIndex(r.Enote.OnetimeAddress())
+		if !ok {
+			continue
+		}
+		core := seraphis.EnoteCore{Ko: r.Enote.OnetimeAddress(), C: r.Enote.AmountCommitment()}
+		candidates = append(candidates, &jamtisInput{record: r, core: core, ledgerIndex: index})
+	}
+	sort.SliceStable(candidates, func(a, b int) bool { return candidates[a].record.Amount > candidates[b].record.Amount })
+
+	for _, in := range candidates {
+		if len(inputs)+1+numOutputs > crypto.BulletproofPlusMaxValues {
+			break
+		}
+		inputs = append(inputs, in)
+		inputAmount += in.record.Amount
+		fee = feePerWeight * seraphis.TxWeight(len(inputs), numOutputs, params)
+		if inputAmount >= paid+fee && paid+fee >= paid {
+			return
+		}
+	}
+	err = err_msg.ErrInsufficientFunds
+	inputs = nil
+	return
+}
+
+// imageProof proves the masked address of the image of in is
+// K" = tk G + Hn(Ko, C) ksp X + Hn(Ko, C) km U
+func (w *JamtisWallet) imageProof(m crypto.Hash, in *jamtisInput) (proof *crypto.CompositionProof, err error) {
+	squash := seraphis.SquashPrefix(in.core.Ko, in.core.C)
+	ksp := in.record.ViewExtension.Add(w.kvb)
+	proof, err = crypto.NewCompositionProof(m, in.image.MaskedAddress, in.tk, squash.Multiply(ksp), squash.Multiply(w.km))
+	return
+}
+
+// membershipProof proves the image of in is the image of a member of a reference set sampled from ledger
+func membershipProof(m crypto.Hash, in *jamtisInput, ledger seraphis.Ledger, params seraphis.TxParams) (proof *seraphis.MembershipProof, err error) {
+	referenceSet, l, err := seraphis.SampleReferenceSet(ledger, in.ledgerIndex, params.RefSetSize())
+	if err != nil {
+		return
+	}
+	members := make([]seraphis.EnoteCore, len(referenceSet))
+	for k, index := range referenceSet {
+		if members[k], err = ledger.EnoteAt(index); err != nil {
+			return
+		}
+	}
+	proof, err = seraphis.NewMembershipProof(members, referenceSet, l, in.image, in.tk, in.tc, params.RefSetN, params.RefSetM, m)
+	return
+}
+
+// balanceProof proves the masked input commitments and the output commitments are in range and reveals the
+// remainder of their blinding factors, sum (tc + b) - sum b
+func balanceProof(inputs []*jamtisInput, outputs []*seraphis.OutputProposal) (proof *seraphis.BalanceProof, err error) {
+	var values []uint64
+	var blinds []*crypto.Scalar
+	remainder := crypto.ScalarZero()
+	for _, in := range inputs {
+		blind := in.tc.Add(in.record.AmountBlind)
+		values = append(values, in.record.Amount)
+		blinds = append(blinds, blind)
+		remainder = remainder.Add(blind)
+	}
+	for _, o := range outputs {
+		values = append(values, o.Amount)
+		blinds = append(blinds, o.AmountBlind)
+		remainder = remainder.Subtract(o.AmountBlind)
+	}
+	rangeProof, _, err := crypto.NewBulletproofPlus(values, blinds)
+	if err != nil {
+		return
+	}
+	proof = &seraphis.BalanceProof{RangeProof: rangeProof, RemainderBlind: remainder}
+	return
+}
@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"gomonero/seraphis"
+	"testing"
+)
+
+var testTxParams = seraphis.TxParams{RefSetN: 2, RefSetM: 3}
+
+// newTestLedger returns a ledger with decoy enotes and coinbase enotes paying amounts to w
+func newTestLedger(t *testing.T, w *JamtisWallet, amounts ...uint64) (ledger *seraphis.MemoryLedger) {
+	ledger = seraphis.NewMemoryLedger()
+	miner := NewJamtisWallet()
+	a, _ := miner.Address(NewJamtisAddressIndex(0))
+	for i := 0; i < testTxParams.RefSetSize(); i++ {
+		e, _ := miner.CreateCoinbaseOutput(a, 1000)
+		ledger.AddEnote(e)
+	}
+	a, _ = w.Address(NewJamtisAddressIndex(0))
+	for _, amount := range amounts {
+		e, Ke := w.CreateCoinbaseOutput(a, amount)
+		ledger.AddEnote(e)
+		if _, err := w.ReceiveOutput(e, Ke); err != nil {
+			t.Fatalf(err.Error())
+		}
+	}
+	return
+}
+
+// receiveTx returns the sum of the outputs of tx received by w
+func receiveTx(w *JamtisWallet, tx *seraphis.Tx) (amount uint64) {
+	for i := range tx.Outputs {
+		if r, err := w.ReceiveOutput(&tx.Outputs[i], tx.EphemeralKeys[i]); err == nil {
+			amount += r.Amount
+		}
+	}
+	return
+}
+
+func TestJamtisTx(t *testing.T) {
+	alice, bob := NewJamtisWallet(), NewJamtisWallet()
+	ledger := newTestLedger(t, alice, 6000000, 3000000, 2000000)
+	bobAddress, _ := bob.Address(NewJamtisAddressIndex(1))
+
+	tx, err := alice.BuildTx([]JamtisPayment{{bobAddress, 7000000}}, 10, NewJamtisAddressIndex(2), ledger, testTxParams)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if len(tx.Images) != 2 || len(tx.Outputs) != 2 {
+		t.Errorf("want 2 inputs and 2 outputs, got %d and %d", len(tx.Images), len(tx.Outputs))
+	}
+	if err = ledger.AddTx(tx, testTxParams); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err = seraphis.ValidateTx(tx, ledger, testTxParams); err != err_msg.ErrKeyImageSpent {
+		t.Errorf("double spend: want %v, got %v", err_msg.ErrKeyImageSpent, err)
+	}
+
+	if received := receiveTx(bob, tx); received != 7000000 {
+		t.Errorf("bob received %d", received)
+	}
+	if change := receiveTx(alice, tx); change != 9000000-7000000-tx.Fee {
+		t.Errorf("alice received %d change", change)
+	}
+
+	// the received enotes can be spent in turn
+	aliceAddress, _ := alice.Address(NewJamtisAddressIndex(3))
+	tx, err = bob.BuildTx([]JamtisPayment{{aliceAddress, 1000000}}, 10, NewJamtisAddressIndex(0), ledger, testTxParams)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if err = ledger.AddTx(tx, testTxParams); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	if _, err = bob.BuildTx([]JamtisPayment{{aliceAddress, 7000000}}, 10, NewJamtisAddressIndex(0), ledger, testTxParams); err != err_msg.ErrInsufficientFunds {
+		t.Errorf("want %v, got %v", err_msg.ErrInsufficientFunds, err)
+	}
+}
+
+func TestValidateTx(t *testing.T) {
+	alice, bob := NewJamtisWallet(), NewJamtisWallet()
+	ledger := newTestLedger(t, alice, 5000000)
+	bobAddress, _ := bob.Address(NewJamtisAddressIndex(0))
+	build := func() (tx *seraphis.Tx) {
+		tx, err := alice.BuildTx([]JamtisPayment{{bobAddress, 1000000}}, 10, NewJamtisAddressIndex(0), ledger, testTxParams)
+		if err != nil {
+			t.Fatalf(err.Error())
+		}
+		return
+	}
+	if err := seraphis.ValidateTx(build(), ledger, testTxParams); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	tx := build()
+	tx.Fee++
+	if err := seraphis.ValidateTx(tx, ledger, testTxParams); err != err_msg.ErrTxBalance {
+		t.Errorf("want %v, got %v", err_msg.ErrTxBalance, err)
+	}
+	tx = build()
+	tx.Outputs[0].Tag++
+	if err := seraphis.ValidateTx(tx, ledger, testTxParams); err != err_msg.ErrTxImageProof {
+		t.Errorf("want %v, got %v", err_msg.ErrTxImageProof, err)
+	}
+	tx = build()
+	tx.MembershipProofs[0].ReferenceSet[0], tx.MembershipProofs[0].ReferenceSet[1] = tx.MembershipProofs[0].ReferenceSet[1], tx.MembershipProofs[0].ReferenceSet[0]
+	if err := seraphis.ValidateTx(tx, ledger, testTxParams); err != err_msg.ErrTxMembershipProof {
+		t.Errorf("want %v, got %v", err_msg.ErrTxMembershipProof, err)
+	}
+	tx = build()
+	tx.Outputs = tx.Outputs[:1]
+	if err := seraphis.ValidateTx(tx, ledger, testTxParams); err != err_msg.ErrTxSemantics {
+		t.Errorf("want %v, got %v", err_msg.ErrTxSemantics, err)
+	}
+	// a key image with a torsion component is a second key image of a spent enote
+	tx = build()
+	if err := ledger.AddTx(tx, testTxParams); err != nil {
+		t.Fatalf(err.Error())
+	}
+	T := crypto.NewPointFromHexString("ecffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff7f")
+	tx.Images[0].KeyImage = tx.Images[0].KeyImage.Add(T)
+	if err := seraphis.ValidateTx(tx, ledger, testTxParams); err != err_msg.ErrTxSemantics {
+		t.Errorf("torsion key image: want %v, got %v", err_msg.ErrTxSemantics, err)
+	}
+	if err := ledger.AddTx(tx, testTxParams); err != err_msg.ErrTxSemantics {
+		t.Errorf("torsion key image: want %v, got %v", err_msg.ErrTxSemantics, err)
+	}
+}
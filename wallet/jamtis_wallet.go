@@ -1,51 +1,44 @@
 package wallet
 
 import (
+	"bytes"
+	"crypto/cipher"
 	"gomonero/address"
 	"gomonero/crypto"
 	"gomonero/err_msg"
+	"gomonero/seraphis"
+	"sync"
 )
 
 type JamtisWallet struct {
 	//private keys
-	km    *crypto.Scalar
-	kvb   *crypto.Scalar
-	kac   *crypto.Scalar
-	kfr   *crypto.Scalar
-	ka    []*crypto.Scalar   //kai = ka[i]
-	kaddr [][]*crypto.Scalar //kaddr i,j =  kaddr[i][j]
-	kx    [][]*crypto.Scalar //kx i,j = kx[i][j]
+	km  *crypto.Scalar
+	kvb *crypto.Scalar
+	kac *crypto.Scalar
+	kfr *crypto.Scalar
+	sga *crypto.Scalar //generate-address secret
+	sct *crypto.Scalar //cipher-tag secret
 	//public keys
 	Ks      *crypto.Point
 	Kid     *crypto.Point
 	Kfr     *crypto.Point
 	address address.JamtisAddress //pub keys
-	outputs []*output
+	outputs []*seraphis.EnoteRecord
 
-	jamtisAddressLookup map[[32]byte]JamtisAddressIndex //map of K1 keys for IDing outputs
+	tagCipher cipher.Block //address index cipher keyed with sct
+	mu        sync.RWMutex //guards the outputs
+	height    uint64       //restore height
+	labels    map[JamtisAddressIndex]string
 }
 
-type JamtisAddressIndex struct {
-	i, j uint32
-}
-
-type JamtisOutput struct {
-	Ke *crypto.Point //ephemeral key
-	v  byte          //view tag
-	Ko *crypto.Point //one time address
-	ae amount64      //encrypted amount
-	C  *crypto.Point //commitment
-	// private values
-	amount amount64
-	blind  *crypto.Scalar
-	index  JamtisAddressIndex
-	ksp    *crypto.Scalar //partial private spend key
-	s      bool           //spend status
+func NewJamtisWallet() (w *JamtisWallet) {
+	w = new(JamtisWallet).FromMasterKey(crypto.NewRandomScalar())
+	return
 }
 
-func NewJamtisWallet() (w *JamtisWallet) {
-	w = new(JamtisWallet)
-	w.km = crypto.NewRandomScalar()
+// FromMasterKey derives all the wallet keys from the master key km
+func (w *JamtisWallet) FromMasterKey(km *crypto.Scalar) *JamtisWallet {
+	w.km = km
 	w.kvb = w.km.KeyDerive("view-balance key\x00")
 	//todo implement birthday
 	w.kac = w.kvb.KeyDerive("account-creation key\x00")
@@ -56,156 +49,285 @@ func NewJamtisWallet() (w *JamtisWallet) {
 	w.Kid = w.kac.MultG()
 	w.Kfr = w.kfr.MultG()
 
-	n := 10 // initial number of pre generated keys
+	w.sga = w.kvb.KeyDerive("generate-address secret\x00")
+	w.sct = w.sga.KeyDerive("cipher-tag secret\x00")
+	w.tagCipher = newAddressTagCipher(w.sct)
+	return w
+}
 
-	w.ka = make([]*crypto.Scalar, n) //todo decide how many to premake
-	for i := range w.ka {
-		//kai = KeyDerive(kac, "account key" || i)
-		kaSalt := "account key\x00" + string(byte(i))
-		w.ka[i] = w.kac.KeyDerive(kaSalt)
-	}
+// Height returns the restore height of the wallet
+func (w *JamtisWallet) Height() (h uint64) {
+	h = w.height
+	return
+}
 
-	w.kaddr = make([][]*crypto.Scalar, n)
-	w.kx = make([][]*crypto.Scalar, n)
-	for i := 0; i < n; i++ {
-		w.kaddr[i] = make([]*crypto.Scalar, n)
-		w.kx[i] = make([]*crypto.Scalar, n)
-		for j := 0; j < n; j++ {
-			kaddrSalt := "address key\x00" + string(byte(i)) + string(byte(j))
-			w.kaddr[i][j] = w.ka[i].KeyDerive(kaddrSalt)
-
-			kxSalt := "key extension\x00" + string(byte(i)) + string(byte(j))
-			w.kx[i][j] = w.kaddr[i][j].KeyDerive(kxSalt)
-		}
-	}
+// SetHeight sets the restore height of the wallet
+func (w *JamtisWallet) SetHeight(h uint64) {
+	w.height = h
+}
 
-	w.InitializeJamtisAddressLookup(uint32(n), uint32(n))
+// Label returns the label of the address at index, or "" if it has none
+func (w *JamtisWallet) Label(index JamtisAddressIndex) (label string) {
+	label = w.labels[index]
 	return
 }
 
-func (w *JamtisWallet) Address(index JamtisAddressIndex) (r *address.JamtisAddress, err error) {
-	//bounds check
-	if index.i >= uint32(len(w.kx)) || index.i >= uint32(len(w.kaddr)) {
-		err = err_msg.ErrOutOfBounds
-		return
-	}
-	if index.j >= uint32(len(w.kx[index.i])) || index.j >= uint32(len(w.kaddr[index.i])) {
-		err = err_msg.ErrOutOfBounds
-		return
+// SetLabel sets the label of the address at index
+func (w *JamtisWallet) SetLabel(index JamtisAddressIndex, label string) {
+	if w.labels == nil {
+		w.labels = make(map[JamtisAddressIndex]string)
 	}
-	//todo add code to generate more keys if index is out of bounds
+	w.labels[index] = label
+}
+
+func (w *JamtisWallet) Address(j JamtisAddressIndex) (r *address.JamtisAddress, err error) {
+	kaddr, kx := w.addressKeys(j)
 
 	r = new(address.JamtisAddress)
-	//K1(i,j) = Ks + kx(i,j) * X
-	r.K1 = w.kx[index.i][index.j].MultX().Add(w.Ks)
-	//K2(i,j) = kaddr(i,j) * Kfr
-	r.K2 = w.Kfr.ScalarMult(w.kaddr[index.i][index.j])
-	//K3(i,j) = kaddr(i,j) * G
-	r.K3 = w.kaddr[index.i][index.j].MultG()
+	r.Network = w.address.Network
+	//K1j = Ks + kxj * X
+	r.K1 = kx.MultX().Add(w.Ks)
+	//K2j = kaddrj * Kfr
+	r.K2 = w.Kfr.ScalarMult(kaddr)
+	//K3j = kaddrj * G
+	r.K3 = kaddr.MultG()
+	r.Tag = w.cipherAddressIndex(j)
+	return
+}
 
+// addressKeys derives the address key and key extension of the address at j
+func (w *JamtisWallet) addressKeys(j JamtisAddressIndex) (kaddr, kx *crypto.Scalar) {
+	//kaddrj = KeyDerive(sga, "address key" || j)
+	kaddr = w.sga.KeyDerive("address key\x00" + string(j[:]))
+	//kxj = KeyDerive(sga, "key extension" || j)
+	kx = w.sga.KeyDerive("key extension\x00" + string(j[:]))
 	return
 }
 
-func (w *JamtisWallet) InitializeJamtisAddressLookup(iMax, jMax uint32) {
-	var i, j uint32
-	w.jamtisAddressLookup = make(map[[32]byte]JamtisAddressIndex, i*j)
-	for i = 0; i < iMax; i++ {
-		for j = 0; j < jMax; j++ {
-			aij, err := w.Address(JamtisAddressIndex{i, j})
-			if err == nil {
-				w.jamtisAddressLookup[aij.K1.Byte32()] = JamtisAddressIndex{i, j}
-			}
-		}
-	}
+// CreateOutput returns the proposal of an enote paying amount to a
+func (w *JamtisWallet) CreateOutput(a *address.JamtisAddress, amount amount64) (p *seraphis.OutputProposal, err error) {
+	p, err = createOutput(a, amount, func(Kd, Ke *crypto.Point) *crypto.Scalar {
+		return plainSenderReceiverSecret(Kd)
+	})
+	return
 }
 
-func (w *JamtisWallet) CreateOutput(a *address.JamtisAddress, amount amount64) (output *JamtisOutput, err error) {
-	output = new(JamtisOutput)
+// createOutput creates the proposal of an enote paying amount to a, secret returns the sender-receiver secret
+func createOutput(a *address.JamtisAddress, amount amount64, secret func(Kd, Ke *crypto.Point) *crypto.Scalar) (p *seraphis.OutputProposal, err error) {
+	p = new(seraphis.OutputProposal)
 	r := crypto.NewRandomScalar()
-	output.Ke = a.K3.ScalarMult(r)
+	p.Ke = a.K3.ScalarMult(r)
 	Kd := a.K2.ScalarMult(r).MultByCofactor()
+	p.Enote.Tag = viewTag(Kd)
 
-	vHashData := append([]byte("view tag\x00"), Kd.Bytes()...)
-	output.v = crypto.Hash8(vHashData)
-
-	qHashData := append([]byte("sender-receiver secret\x00"), Kd.Bytes()...)
-	q := crypto.HashToScalar(qHashData)
+	q := secret(Kd, p.Ke)
 
-	output.Ko = a.K1.Add(q.MultX())
+	Ko := a.K1.Add(q.MultX())
+	p.Enote.AddressTag = encryptAddressTag(a.Tag, q, Ko)
 
 	rG := r.MultG()
-	bHashData := append([]byte("blind\x00"), q.Bytes()...)
-	bHashData = append(bHashData, rG.Bytes()...)
-	b := crypto.HashToScalar(bHashData)
-
-	aMaskHashData := append([]byte("amount\x00"), q.Bytes()...)
-	aMaskHashData = append(aMaskHashData, rG.Bytes()...)
-	aMask := crypto.Hash64(aMaskHashData)
+	p.AmountBlind = outputBlind(q, rG)
+	aMask := amountMask(q, rG)
 
-	output.ae, err = amount.XOR(aMask[:])
+	ae, err := amount.XOR(aMask[:])
 	if err != nil {
-		output = nil
+		p = nil
 		return
 	}
+	p.Enote.EncodedAmount = ae
+	p.Amount = amount.Uint64()
 
-	output.C = b.DoubleScalarBaseMult(amount.Scalar(), crypto.PointH())
-
-	//todo add change and self spend logic
+	C := p.AmountBlind.DoubleScalarBaseMult(amount.Scalar(), crypto.PointH())
+	p.Enote.Core = seraphis.EnoteCore{Ko: Ko, C: C}
 	return
 }
 
-func (w *JamtisWallet) ReceiveOutput(output *JamtisOutput) (err error) {
-	Kd := output.Ke.ScalarMult(w.kfr).MultByCofactor()
+// CreateCoinbaseOutput returns a coinbase enote paying amount to a and its ephemeral key
+func (w *JamtisWallet) CreateCoinbaseOutput(a *address.JamtisAddress, amount uint64) (e *seraphis.CoinbaseEnote, Ke *crypto.Point) {
+	e = new(seraphis.CoinbaseEnote)
+	r := crypto.NewRandomScalar()
+	Ke = a.K3.ScalarMult(r)
+	Kd := a.K2.ScalarMult(r).MultByCofactor()
+	e.Tag = viewTag(Kd)
 
-	vHashData := append([]byte("view tag\x00"), Kd.Bytes()...)
-	v := crypto.Hash8(vHashData)
+	q := plainSenderReceiverSecret(Kd)
+	e.Core.Ko = a.K1.Add(q.MultX())
+	e.Core.Amount = amount
+	e.AddressTag = encryptAddressTag(a.Tag, q, e.Core.Ko)
+	return
+}
 
-	if v != output.v {
-		err = err_msg.ErrViewTag
-		return
-	}
+func viewTag(Kd *crypto.Point) (v byte) {
+	vHashData := append([]byte("view tag\x00"), Kd.Bytes()...)
+	v = crypto.Hash8(vHashData)
+	return
+}
 
+// plainSenderReceiverSecret returns the sender-receiver secret of enotes sent by other wallets
+func plainSenderReceiverSecret(Kd *crypto.Point) (q *crypto.Scalar) {
 	qHashData := append([]byte("sender-receiver secret\x00"), Kd.Bytes()...)
-	q := crypto.HashToScalar(qHashData)
+	q = crypto.HashToScalar(qHashData)
+	return
+}
 
-	Ks := output.Ko.Subtract(q.MultX())
-	index, ok := w.jamtisAddressLookup[Ks.Byte32()]
+func outputBlind(q *crypto.Scalar, rG *crypto.Point) (b *crypto.Scalar) {
+	bHashData := append([]byte("blind\x00"), q.Bytes()...)
+	bHashData = append(bHashData, rG.Bytes()...)
+	b = crypto.HashToScalar(bHashData)
+	return
+}
+
+func amountMask(q *crypto.Scalar, rG *crypto.Point) (r [8]byte) {
+	aMaskHashData := append([]byte("amount\x00"), q.Bytes()...)
+	aMaskHashData = append(aMaskHashData, rG.Bytes()...)
+	r = crypto.Hash64(aMaskHashData)
+	return
+}
+
+// FindReceived returns the basic record of e if its view tag matches, it only needs the find-received key
+func (w *JamtisWallet) FindReceived(e seraphis.EnoteVariant, Ke *crypto.Point) (r *seraphis.BasicEnoteRecord, err error) {
+	r, _, err = w.findReceived(e, Ke)
+	return
+}
 
-	if !ok {
-		err = err_msg.ErrLookup
-		output = nil
+func (w *JamtisWallet) findReceived(e seraphis.EnoteVariant, Ke *crypto.Point) (r *seraphis.BasicEnoteRecord, Kd *crypto.Point, err error) {
+	Kd = Ke.ScalarMult(w.kfr).MultByCofactor()
+	if viewTag(Kd) != e.ViewTag() {
+		err = err_msg.ErrViewTag
 		return
 	}
-	output.index = index
+	q := plainSenderReceiverSecret(Kd)
+	r = &seraphis.BasicEnoteRecord{
+		Enote:             e,
+		Ke:                Ke,
+		NominalAddressTag: encryptAddressTag(e.EncryptedAddressTag(), q, e.OnetimeAddress()),
+	}
+	return
+}
 
-	rG := output.Ke.ScalarMult(w.kaddr[index.i][index.j].Invert())
+// IntermediateRecord recovers the amount and address index of an enote sent by another wallet from its basic record
+func (w *JamtisWallet) IntermediateRecord(b *seraphis.BasicEnoteRecord) (r *seraphis.IntermediateEnoteRecord, err error) {
+	q := plainSenderReceiverSecret(b.Ke.ScalarMult(w.kfr).MultByCofactor())
+	o, err := w.openEnote(b.Enote, b.Ke, q, b.NominalAddressTag)
+	if err != nil {
+		return
+	}
+	r = &seraphis.IntermediateEnoteRecord{
+		Enote:        b.Enote,
+		Ke:           b.Ke,
+		Amount:       o.amount,
+		AmountBlind:  o.blind,
+		AddressIndex: o.j,
+	}
+	return
+}
 
-	aMaskHashData := append([]byte("amount\x00"), q.Bytes()...)
-	aMaskHashData = append(aMaskHashData, rG.Bytes()...)
-	aMask := crypto.Hash64(aMaskHashData)
+// ReceiveOutput returns the record of e if it was sent to an address of the wallet, either by another wallet or as
+// one of the self-send enotes of the wallet, and keeps it
+// an enote with the key image of a record already kept is rejected, only one of them could ever be spent
+func (w *JamtisWallet) ReceiveOutput(e seraphis.EnoteVariant, Ke *crypto.Point) (r *seraphis.EnoteRecord, err error) {
+	b, Kd, err := w.findReceived(e, Ke)
+	if err != nil {
+		return
+	}
 
-	output.amount, err = output.ae.XOR(aMask[:])
+	// the sender-receiver secret is the plain one or the one of a self-send type, only the right one decrypts the
+	// address tag, coinbase enotes are never self-sends
+	q := plainSenderReceiverSecret(Kd)
+	typ := seraphis.EnoteTypePlain
+	o, err := w.openEnote(e, Ke, q, b.NominalAddressTag)
+	_, selfSend := e.(*seraphis.Enote)
+	for t := seraphis.EnoteTypeDummy; err != nil && selfSend && t <= seraphis.EnoteTypeSelfSpend; t++ {
+		q = w.selfSendSecret(t, Ke)
+		tag := encryptAddressTag(e.EncryptedAddressTag(), q, e.OnetimeAddress())
+		if o, err = w.openEnote(e, Ke, q, tag); err == nil {
+			typ = t
+		}
+	}
 	if err != nil {
-		output = nil
 		return
 	}
 
-	bHashData := append([]byte("blind\x00"), q.Bytes()...)
-	bHashData = append(bHashData, rG.Bytes()...)
-	output.blind = crypto.HashToScalar(bHashData)
+	ksp := w.kvb.Add(o.kx).Add(q)
+	//KI = (km / ksp) U
+	KI := w.Ks.Subtract(w.kvb.MultX()).ScalarMult(ksp.Invert())
+
+	r = &seraphis.EnoteRecord{
+		Enote:         e,
+		Ke:            Ke,
+		ViewExtension: o.kx.Add(q),
+		Amount:        o.amount,
+		AmountBlind:   o.blind,
+		AddressIndex:  o.j,
+		KeyImage:      KI,
+		Type:          typ,
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, held := range w.outputs {
+		if bytes.Equal(held.KeyImage.Bytes(), KI.Bytes()) {
+			r, err = nil, err_msg.ErrDuplicateOutput
+			return
+		}
+	}
+	w.outputs = append(w.outputs, r)
+	return
+}
 
-	C := output.blind.DoubleScalarBaseMult(output.amount.Scalar(), crypto.PointH())
+// openedEnote are the secrets of an enote recovered by the receiver
+type openedEnote struct {
+	j      JamtisAddressIndex
+	kx     *crypto.Scalar //key extension of the receiving address
+	amount uint64
+	blind  *crypto.Scalar
+}
 
-	if C.Equal(output.C) == 0 {
-		err = err_msg.ErrJanus
-		output = nil
+// openEnote recovers the receiving address, amount and blinding factor of e, tag is its address tag decrypted with
+// the sender-receiver secret q
+func (w *JamtisWallet) openEnote(e seraphis.EnoteVariant, Ke *crypto.Point, q *crypto.Scalar, tag jamtisAddressTag) (o openedEnote, err error) {
+	// the index of the receiving address is recovered from the address tag
+	if o.j, err = w.decipherAddressTag(tag); err != nil {
+		return
+	}
+	kaddr, kx := w.addressKeys(o.j)
+	if kx.MultX().Add(w.Ks).Equal(e.OnetimeAddress().Subtract(q.MultX())) == 0 {
+		err = err_msg.ErrAddressTag
 		return
 	}
+	o.kx = kx
+
+	switch e := e.(type) {
+	case *seraphis.CoinbaseEnote:
+		o.amount = e.Core.Amount
+		o.blind = crypto.ScalarIdentity()
+	case *seraphis.Enote:
+		rG := Ke.ScalarMult(kaddr.Invert())
+
+		aMask := amountMask(q, rG)
+		var amount amount64
+		if amount, err = amount64(e.EncodedAmount).XOR(aMask[:]); err != nil {
+			return
+		}
+		o.amount = amount.Uint64()
+		o.blind = outputBlind(q, rG)
 
-	output.ksp = w.kvb.Add(w.kx[index.i][index.j]).Add(q)
-	Kt := w.Ks.Subtract(w.kvb.MultX()).ScalarMult(output.ksp.Invert())
-	_ = Kt
+		C := o.blind.DoubleScalarBaseMult(amount.Scalar(), crypto.PointH())
+		if C.Equal(e.Core.C) == 0 {
+			err = err_msg.ErrJanus
+			return
+		}
+	default:
+		err = err_msg.ErrEnoteVariant
+	}
+	return
+}
 
+// EnoteRecords returns the records of the enotes received by the wallet
+func (w *JamtisWallet) EnoteRecords() (r []*seraphis.EnoteRecord) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	r = append(r, w.outputs...)
 	return
 }
 
@@ -1,13 +1,16 @@
 package wallet
 
 import (
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"gomonero/seraphis"
 	"testing"
 )
 
 func TestJamtis(t *testing.T) {
 	w := NewJamtisWallet()
 
-	index := JamtisAddressIndex{2, 3}
+	index := NewJamtisAddressIndex(23)
 	a, addressErr := w.Address(index)
 	if addressErr != nil {
 		t.Errorf(addressErr.Error())
@@ -22,19 +25,143 @@ func TestJamtis(t *testing.T) {
 		return
 	}
 
-	receiveErr := w.ReceiveOutput(o)
+	r, receiveErr := w.ReceiveOutput(&o.Enote, o.Ke)
 	if receiveErr != nil {
 		t.Errorf(receiveErr.Error())
 		return
 	}
 
-	if amount != o.amount {
+	if amount.Uint64() != r.Amount || r.AmountBlind.Equal(o.AmountBlind) == 0 {
 		t.Errorf("wrong amount decoded")
 		return
 	}
 
-	Ko := o.ksp.MultX().Add(w.km.MultU())
-	if Ko.Equal(o.Ko) == 0 {
+	// ko = (ViewExtension + kvb) X + km U
+	ksp := r.ViewExtension.Add(w.kvb)
+	Ko := ksp.MultX().Add(w.km.MultU())
+	if Ko.Equal(o.Enote.Core.Ko) == 0 {
 		t.Errorf("ko does not match Ko")
 	}
+	// KI = (km / ksp) U
+	if crypto.KeyImage(ksp, w.km).Equal(r.KeyImage) == 0 {
+		t.Errorf("wrong key image")
+	}
+
+	// the enote can be spent with a composition proof on its masked address
+	// K" = tk G + Hn(Ko, C) ksp X + Hn(Ko, C) km U
+	image, tk, tc := seraphis.NewEnoteImage(o.Enote.Core.Ko, o.Enote.Core.C, r.KeyImage)
+	squash := seraphis.SquashPrefix(o.Enote.Core.Ko, o.Enote.Core.C)
+	m := crypto.Keccak256([]byte("tx proposal"))
+	proof, err := crypto.NewCompositionProof(m, image.MaskedAddress, tk, squash.Multiply(ksp), squash.Multiply(w.km))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !proof.Verify(m, image.MaskedAddress, image.KeyImage) {
+		t.Errorf("composition proof does not verify")
+	}
+
+	// and a membership proof over a reference set containing it
+	members := make([]seraphis.EnoteCore, 8)
+	for k := range members {
+		members[k] = seraphis.EnoteCore{Ko: crypto.NewRandomScalar().MultG(), C: crypto.NewRandomScalar().MultG()}
+	}
+	members[3] = o.Enote.Core
+	membership, err := seraphis.NewMembershipProof(members, make([]uint64, 8), 3, image, tk, tc, 2, 3, m)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !membership.Verify(members, image, 2, 3, m) {
+		t.Errorf("membership proof does not verify")
+	}
+}
+
+func TestJamtisEnoteRecords(t *testing.T) {
+	w := NewJamtisWallet()
+	j := NewJamtisAddressIndex(5)
+	a, _ := w.Address(j)
+
+	o, err := w.CreateOutput(a, newAmount64(1000))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	b, err := w.FindReceived(&o.Enote, o.Ke)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	ir, err := w.IntermediateRecord(b)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if ir.Amount != 1000 || ir.AddressIndex != j {
+		t.Errorf("wrong intermediate record")
+	}
+
+	// coinbase enotes have a public amount and a blinding factor of 1
+	e, Ke := w.CreateCoinbaseOutput(a, 5000)
+	r, err := w.ReceiveOutput(e, Ke)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if r.Amount != 5000 || r.AmountBlind.Equal(crypto.ScalarIdentity()) == 0 {
+		t.Errorf("wrong coinbase record")
+	}
+	if r.AmountBlind.DoubleScalarBaseMult(newAmount64(r.Amount).Scalar(), crypto.PointH()).Equal(e.AmountCommitment()) == 0 {
+		t.Errorf("coinbase commitment does not open")
+	}
+	if len(w.EnoteRecords()) != 1 {
+		t.Errorf("want 1 enote record, got %d", len(w.EnoteRecords()))
+	}
+
+	// the same enote received twice has the key image of the record already kept
+	if _, err = w.ReceiveOutput(e, Ke); err != err_msg.ErrDuplicateOutput {
+		t.Errorf("want %v, got %v", err_msg.ErrDuplicateOutput, err)
+	}
+	if len(w.EnoteRecords()) != 1 {
+		t.Errorf("want 1 enote record after a duplicate, got %d", len(w.EnoteRecords()))
+	}
+}
+
+func TestJamtisAddressTag(t *testing.T) {
+	w := NewJamtisWallet()
+
+	// the index is recovered from the output without a lookup table, whatever its value
+	var j JamtisAddressIndex
+	for n := range j {
+		j[n] = byte(0xff - n)
+	}
+	a, err := w.Address(j)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	o, err := w.CreateOutput(a, newRandomAmount())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	r, err := w.ReceiveOutput(&o.Enote, o.Ke)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if r.AddressIndex != j {
+		t.Errorf("wrong address index recovered")
+	}
+
+	// an output with a modified address tag is rejected
+	o, err = w.CreateOutput(a, newRandomAmount())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	o.Enote.AddressTag[0] ^= 1
+	if _, err = w.ReceiveOutput(&o.Enote, o.Ke); err != err_msg.ErrAddressTag {
+		t.Errorf("want %v, got %v", err_msg.ErrAddressTag, err)
+	}
+
+	// outputs to the addresses of another wallet are not received
+	other, _ := NewJamtisWallet().Address(j)
+	o, err = w.CreateOutput(other, newRandomAmount())
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err = w.ReceiveOutput(&o.Enote, o.Ke); err == nil {
+		t.Errorf("received an output of another wallet")
+	}
 }
@@ -0,0 +1,406 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// keys file layout
+//
+// the file is a json envelope holding the kdf parameters and the sealed payload
+// key = argon2id(password, salt, time, memory, threads)
+// ciphertext = xchacha20poly1305(key, nonce, payload, additional data = version || kind)
+// the payload is a json document holding the private keys and wallet metadata
+
+const (
+	keysFileVersion = 1
+
+	keysFileKindStandard = "standard"
+	keysFileKindJamtis   = "jamtis"
+
+	kdfArgon2id = "argon2id"
+
+	// bounds of the kdf parameters read from keys files, a file must not make the key derivation run out of memory
+	// or time
+	kdfMinSalt    = 8
+	kdfMaxTime    = 16
+	kdfMaxMemory  = 1024 * 1024 //KiB
+	kdfMaxThreads = 64
+)
+
+// kdfParams are the argon2id parameters used to derive the file key from the password
+type kdfParams struct {
+	Name    string
+	Salt    []byte
+	Time    uint32
+	Memory  uint32 //KiB
+	Threads uint8
+}
+
+// defaultKdfParams returns fresh kdf parameters with a random salt
+func defaultKdfParams() (p kdfParams, err error) {
+	p = kdfParams{
+		Name:    kdfArgon2id,
+		Salt:    make([]byte, 16),
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}
+	_, err = rand.Read(p.Salt)
+	return
+}
+
+func (p kdfParams) key(password []byte) (key []byte, err error) {
+	if p.Name != kdfArgon2id || len(p.Salt) < kdfMinSalt {
+		err = err_msg.ErrKdf
+		return
+	}
+	if p.Time == 0 || p.Time > kdfMaxTime || p.Memory == 0 || p.Memory > kdfMaxMemory || p.Threads == 0 || p.Threads > kdfMaxThreads {
+		err = err_msg.ErrKdf
+		return
+	}
+	key = argon2.IDKey(password, p.Salt, p.Time, p.Memory, p.Threads, chacha20poly1305.KeySize)
+	return
+}
+
+type keysFile struct {
+	Version    int
+	Kind       string
+	Kdf        kdfParams
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+func (f *keysFile) additionalData() (r []byte) {
+	r = append([]byte{byte(f.Version)}, []byte(f.Kind)...)
+	return
+}
+
+// sealKeys encrypts payload with a key derived from password and returns the serialized keys file
+func sealKeys(kind string, payload, password []byte) (r []byte, err error) {
+	f := &keysFile{
+		Version: keysFileVersion,
+		Kind:    kind,
+		Nonce:   make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if f.Kdf, err = defaultKdfParams(); err != nil {
+		return
+	}
+	if _, err = rand.Read(f.Nonce); err != nil {
+		return
+	}
+
+	key, err := f.Kdf.key(password)
+	if err != nil {
+		return
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, payload, f.additionalData())
+	r, err = json.Marshal(f)
+	return
+}
+
+// openKeys decrypts a serialized keys file of the given kind and returns its payload
+func openKeys(kind string, data, password []byte) (payload []byte, err error) {
+	f := new(keysFile)
+	if err = json.Unmarshal(data, f); err != nil {
+		return
+	}
+	if f.Version != keysFileVersion {
+		err = err_msg.ErrKeysFileVersion
+		return
+	}
+	if f.Kind != kind {
+		err = err_msg.ErrKeysFileKind
+		return
+	}
+	key, err := f.Kdf.key(password)
+	if err != nil {
+		return
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		err = err_msg.ErrPassword
+		return
+	}
+	payload, err = aead.Open(nil, f.Nonce, f.Ciphertext, f.additionalData())
+	if err != nil {
+		err = err_msg.ErrPassword
+	}
+	return
+}
+
+// writeFileAtomic atomically replaces the file at path with data, readable only by the owner
+func writeFileAtomic(path string, data []byte) (err error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if err = tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return
+	}
+	if err = tmp.Close(); err != nil {
+		return
+	}
+	err = os.Rename(tmp.Name(), path)
+	return
+}
+
+func scalarFromKeysFile(b []byte) (r *crypto.Scalar, err error) {
+	r = crypto.NewScalarFromBytes(b)
+	err = r.Err
+	return
+}
+
+type subAddressLabel struct {
+	Major, Minor uint32
+	Label        string
+}
+
+type walletKeys struct {
+	Network  int
+	Height   uint64
+	Kv       []byte
+	Ks       []byte //empty for view only wallets
+	SpendKey []byte `json:",omitempty"` //public spend key of view only wallets
+	MajorMax uint32 `json:",omitempty"` //size of the subaddress lookup table of older keys files
+	MinorMax uint32 `json:",omitempty"`
+	//lookahead, the lookup table is rebuilt from it and the created subaddresses
+	LookaheadMajor uint32   `json:",omitempty"`
+	LookaheadMinor uint32   `json:",omitempty"`
+	Accounts       []uint32 `json:",omitempty"` //number of subaddresses created in each account
+	Labels         []subAddressLabel
+}
+
+// MarshalKeys returns the wallet keys and metadata encrypted with password
+func (w *Wallet) MarshalKeys(password []byte) (r []byte, err error) {
+	k := walletKeys{
+		Network: w.address.Network,
+		Height:  w.height,
+		Kv:      w.kv.Bytes(),
+	}
+	if w.IsViewOnly() {
+		k.SpendKey = w.address.Ks.Bytes()
+	} else {
+		k.Ks = w.ks.Bytes()
+	}
+	k.LookaheadMajor, k.LookaheadMinor = w.SubAddressLookahead()
+	w.lookupMu.Lock()
+	k.Accounts = append([]uint32{}, w.numSubAddresses()...)
+	w.lookupMu.Unlock()
+	for i, label := range w.labels {
+		k.Labels = append(k.Labels, subAddressLabel{i.Major, i.Minor, label})
+	}
+	payload, err := json.Marshal(k)
+	if err != nil {
+		return
+	}
+	r, err = sealKeys(keysFileKindStandard, payload, password)
+	return
+}
+
+// UnmarshalWalletKeys returns the wallet encrypted in data by MarshalKeys
+func UnmarshalWalletKeys(data, password []byte) (w *Wallet, err error) {
+	payload, err := openKeys(keysFileKindStandard, data, password)
+	if err != nil {
+		return
+	}
+	k := new(walletKeys)
+	if err = json.Unmarshal(payload, k); err != nil {
+		return
+	}
+	kv, err := scalarFromKeysFile(k.Kv)
+	if err != nil {
+		return
+	}
+	if len(k.Ks) == 0 {
+		Ks := crypto.NewPointFromBytes(k.SpendKey)
+		if Ks.Err != nil {
+			err = Ks.Err
+			return
+		}
+		w = NewViewOnlyWallet(kv, Ks, k.Network)
+	} else {
+		var ks *crypto.Scalar
+		if ks, err = scalarFromKeysFile(k.Ks); err != nil {
+			return
+		}
+		w = new(Wallet).FromKeys(kv, ks)
+	}
+	w.address.Network = k.Network
+	w.height = k.Height
+	for _, l := range k.Labels {
+		w.SetLabel(SubAddressIndex{l.Major, l.Minor}, l.Label)
+	}
+	if k.MajorMax > 0 && k.MinorMax > 0 {
+		w.InitializeSubAddressLookup(k.MajorMax, k.MinorMax)
+	}
+	if k.LookaheadMajor > 0 && k.LookaheadMinor > 0 {
+		w.SetSubAddressLookahead(k.LookaheadMajor, k.LookaheadMinor)
+	}
+	for major, n := range k.Accounts {
+		if n > 0 {
+			w.markSubAddressCreated(SubAddressIndex{uint32(major), n - 1})
+			w.expandSubAddressLookup(SubAddressIndex{uint32(major), n - 1})
+		}
+	}
+	return
+}
+
+// SaveKeys writes the wallet keys to path encrypted with password
+func (w *Wallet) SaveKeys(path string, password []byte) (err error) {
+	data, err := w.MarshalKeys(password)
+	if err != nil {
+		return
+	}
+	err = writeFileAtomic(path, data)
+	return
+}
+
+// LoadWallet reads a wallet from the keys file at path
+func LoadWallet(path string, password []byte) (w *Wallet, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	w, err = UnmarshalWalletKeys(data, password)
+	return
+}
+
+// ChangePassword replaces the keys file of the wallet at path, which must open with oldPassword, with the wallet
+// keys encrypted with newPassword
+func (w *Wallet) ChangePassword(path string, oldPassword, newPassword []byte) (err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	old, err := UnmarshalWalletKeys(data, oldPassword)
+	if err != nil {
+		return
+	}
+	if old.address.Ks.Equal(w.address.Ks) == 0 || old.kv.Equal(w.kv) == 0 {
+		err = err_msg.ErrKeysFileWallet
+		return
+	}
+	err = w.SaveKeys(path, newPassword)
+	return
+}
+
+type jamtisAddressLabel struct {
+	Index []byte
+	Label string
+}
+
+type jamtisWalletKeys struct {
+	Network int
+	Height  uint64
+	Km      []byte
+	Labels  []jamtisAddressLabel
+}
+
+// MarshalKeys returns the wallet master key and metadata encrypted with password
+func (w *JamtisWallet) MarshalKeys(password []byte) (r []byte, err error) {
+	k := jamtisWalletKeys{
+		Network: w.address.Network,
+		Height:  w.height,
+		Km:      w.km.Bytes(),
+	}
+	for index, label := range w.labels {
+		index := index
+		k.Labels = append(k.Labels, jamtisAddressLabel{index[:], label})
+	}
+	payload, err := json.Marshal(k)
+	if err != nil {
+		return
+	}
+	r, err = sealKeys(keysFileKindJamtis, payload, password)
+	return
+}
+
+// UnmarshalJamtisWalletKeys returns the wallet encrypted in data by MarshalKeys
+func UnmarshalJamtisWalletKeys(data, password []byte) (w *JamtisWallet, err error) {
+	payload, err := openKeys(keysFileKindJamtis, data, password)
+	if err != nil {
+		return
+	}
+	k := new(jamtisWalletKeys)
+	if err = json.Unmarshal(payload, k); err != nil {
+		return
+	}
+	km, err := scalarFromKeysFile(k.Km)
+	if err != nil {
+		return
+	}
+	w = new(JamtisWallet).FromMasterKey(km)
+	w.address.Network = k.Network
+	w.height = k.Height
+	for _, l := range k.Labels {
+		var j JamtisAddressIndex
+		copy(j[:], l.Index)
+		w.SetLabel(j, l.Label)
+	}
+	return
+}
+
+// SaveKeys writes the wallet keys to path encrypted with password
+func (w *JamtisWallet) SaveKeys(path string, password []byte) (err error) {
+	data, err := w.MarshalKeys(password)
+	if err != nil {
+		return
+	}
+	err = writeFileAtomic(path, data)
+	return
+}
+
+// LoadJamtisWallet reads a jamtis wallet from the keys file at path
+func LoadJamtisWallet(path string, password []byte) (w *JamtisWallet, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	w, err = UnmarshalJamtisWalletKeys(data, password)
+	return
+}
+
+// ChangePassword replaces the keys file of the jamtis wallet at path, which must open with oldPassword, with the
+// wallet keys encrypted with newPassword
+func (w *JamtisWallet) ChangePassword(path string, oldPassword, newPassword []byte) (err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	old, err := UnmarshalJamtisWalletKeys(data, oldPassword)
+	if err != nil {
+		return
+	}
+	if old.km.Equal(w.km) == 0 {
+		err = err_msg.ErrKeysFileWallet
+		return
+	}
+	err = w.SaveKeys(path, newPassword)
+	return
+}
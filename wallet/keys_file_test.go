@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"errors"
+	"gomonero/err_msg"
+	"path/filepath"
+	"testing"
+)
+
+func TestWalletKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallet.keys")
+	password := []byte("correct horse battery staple")
+
+	w := NewWallet()
+	w.SetHeight(2500000)
+	w.SetLabel(SubAddressIndex{1, 2}, "savings")
+	w.InitializeSubAddressLookup(3, 4)
+
+	if err := w.SaveKeys(path, password); err != nil {
+		t.Fatalf(err.Error())
+	}
+
+	_, err := LoadWallet(path, []byte("wrong password"))
+	if !errors.Is(err, err_msg.ErrPassword) {
+		t.Errorf("want: %s, got: %v", err_msg.ErrPassword, err)
+	}
+	_, err = LoadJamtisWallet(path, password)
+	if !errors.Is(err, err_msg.ErrKeysFileKind) {
+		t.Errorf("want: %s, got: %v", err_msg.ErrKeysFileKind, err)
+	}
+
+	got, err := LoadWallet(path, password)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got.kv.Equal(w.kv) == 0 || got.ks.Equal(w.ks) == 0 {
+		t.Errorf("private keys do not match")
+	}
+	if got.address.Base58() != w.address.Base58() {
+		t.Errorf("wrong address: want: %v got: %v", w.address.Base58(), got.address.Base58())
+	}
+	if got.Height() != w.Height() {
+		t.Errorf("wrong height: want: %v got: %v", w.Height(), got.Height())
+	}
+	if got.Label(SubAddressIndex{1, 2}) != "savings" {
+		t.Errorf("wrong label: want: savings got: %v", got.Label(SubAddressIndex{1, 2}))
+	}
+	if _, ok := got.SubAddressLookup(w.SubAddressPublicSpendKey(SubAddressIndex{2, 3})); !ok {
+		t.Errorf("subaddress lookup was not restored")
+	}
+
+	newPassword := []byte("new password")
+	if err = got.ChangePassword(path, password, newPassword); err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err = LoadWallet(path, password); !errors.Is(err, err_msg.ErrPassword) {
+		t.Errorf("old password still opens the keys file")
+	}
+	if _, err = LoadWallet(path, newPassword); err != nil {
+		t.Errorf(err.Error())
+	}
+	if err = NewWallet().ChangePassword(path, newPassword, password); err != err_msg.ErrKeysFileWallet {
+		t.Errorf("keys file of another wallet: want %v, got %v", err_msg.ErrKeysFileWallet, err)
+	}
+}
+
+func TestKdfParamsBounds(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		modify func(p *kdfParams)
+	}{
+		{"short salt", func(p *kdfParams) { p.Salt = p.Salt[:4] }},
+		{"time", func(p *kdfParams) { p.Time = kdfMaxTime + 1 }},
+		{"memory", func(p *kdfParams) { p.Memory = kdfMaxMemory + 1 }},
+		{"threads", func(p *kdfParams) { p.Threads = kdfMaxThreads + 1 }},
+		{"zero time", func(p *kdfParams) { p.Time = 0 }},
+	} {
+		p, err := defaultKdfParams()
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.modify(&p)
+		if _, err = p.key([]byte("password")); err != err_msg.ErrKdf {
+			t.Errorf("%s: want %v, got %v", test.name, err_msg.ErrKdf, err)
+		}
+	}
+}
+
+func TestJamtisWalletKeysFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jamtis.keys")
+	password := []byte("password")
+
+	w := NewJamtisWallet()
+	w.SetHeight(100)
+	w.SetLabel(NewJamtisAddressIndex(1<<40), "donations")
+
+	if err := w.SaveKeys(path, password); err != nil {
+		t.Fatalf(err.Error())
+	}
+	got, err := LoadJamtisWallet(path, password)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got.Ks.Equal(w.Ks) == 0 {
+		t.Errorf("wrong spend key")
+	}
+	if got.Height() != 100 || got.Label(NewJamtisAddressIndex(1<<40)) != "donations" {
+		t.Errorf("wallet metadata was not restored")
+	}
+
+	// an output created for the original wallet is received by the loaded wallet
+	a, _ := w.Address(NewJamtisAddressIndex(1 << 40))
+	o, _ := w.CreateOutput(a, newRandomAmount())
+	if _, err = got.ReceiveOutput(&o.Enote, o.Ke); err != nil {
+		t.Errorf(err.Error())
+	}
+}
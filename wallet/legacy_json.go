@@ -0,0 +1,252 @@
+package wallet
+
+import (
+	"bytes"
+	"fmt"
+	"gomonero/err_msg"
+	"strconv"
+)
+
+// the reference wallet stores binary strings (e.g. key_data) directly in its json documents
+// encoding/json would replace the invalid utf-8 in those strings, so the flat objects of a legacy
+// keys file are read and written byte for byte here
+
+// legacyJSONValue is a string or a literal (number, true, false, null) member of a json object
+type legacyJSONValue struct {
+	str      []byte
+	literal  string
+	isString bool
+}
+
+func (v legacyJSONValue) uint64() (r uint64, ok bool) {
+	if v.isString {
+		return
+	}
+	r, err := strconv.ParseUint(v.literal, 10, 64)
+	ok = err == nil
+	return
+}
+
+type legacyJSONField struct {
+	name  string
+	value legacyJSONValue
+}
+
+func jsonString(s []byte) legacyJSONValue {
+	return legacyJSONValue{str: s, isString: true}
+}
+
+func jsonNumber(n uint64) legacyJSONValue {
+	return legacyJSONValue{literal: strconv.FormatUint(n, 10)}
+}
+
+// writeLegacyJSON writes fields as a json object the way rapidjson does, escaping only control characters
+func writeLegacyJSON(fields []legacyJSONField) (r []byte) {
+	buf := new(bytes.Buffer)
+	writeString := func(s []byte) {
+		buf.WriteByte('"')
+		for _, c := range s {
+			switch {
+			case c == '"' || c == '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case c == '\b':
+				buf.WriteString(`\b`)
+			case c == '\f':
+				buf.WriteString(`\f`)
+			case c == '\n':
+				buf.WriteString(`\n`)
+			case c == '\r':
+				buf.WriteString(`\r`)
+			case c == '\t':
+				buf.WriteString(`\t`)
+			case c < 0x20:
+				fmt.Fprintf(buf, `\u%04X`, c)
+			default:
+				buf.WriteByte(c)
+			}
+		}
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeString([]byte(f.name))
+		buf.WriteByte(':')
+		if f.value.isString {
+			writeString(f.value.str)
+		} else {
+			buf.WriteString(f.value.literal)
+		}
+	}
+	buf.WriteByte('}')
+	r = buf.Bytes()
+	return
+}
+
+type legacyJSONReader struct {
+	b []byte
+}
+
+func (p *legacyJSONReader) skipSpace() {
+	for len(p.b) > 0 && (p.b[0] == ' ' || p.b[0] == '\t' || p.b[0] == '\n' || p.b[0] == '\r') {
+		p.b = p.b[1:]
+	}
+}
+
+func (p *legacyJSONReader) expect(c byte) (err error) {
+	p.skipSpace()
+	if len(p.b) == 0 || p.b[0] != c {
+		err = err_msg.ErrLegacyJSON
+		return
+	}
+	p.b = p.b[1:]
+	return
+}
+
+func (p *legacyJSONReader) string() (r []byte, err error) {
+	if err = p.expect('"'); err != nil {
+		return
+	}
+	r = []byte{}
+	for {
+		if len(p.b) == 0 {
+			err = err_msg.ErrLegacyJSON
+			return
+		}
+		c := p.b[0]
+		p.b = p.b[1:]
+		if c == '"' {
+			return
+		}
+		if c != '\\' {
+			r = append(r, c)
+			continue
+		}
+		if len(p.b) == 0 {
+			err = err_msg.ErrLegacyJSON
+			return
+		}
+		c = p.b[0]
+		p.b = p.b[1:]
+		switch c {
+		case '"', '\\', '/':
+			r = append(r, c)
+		case 'b':
+			r = append(r, '\b')
+		case 'f':
+			r = append(r, '\f')
+		case 'n':
+			r = append(r, '\n')
+		case 'r':
+			r = append(r, '\r')
+		case 't':
+			r = append(r, '\t')
+		case 'u':
+			if len(p.b) < 4 {
+				err = err_msg.ErrLegacyJSON
+				return
+			}
+			var u uint64
+			if u, err = strconv.ParseUint(string(p.b[:4]), 16, 16); err != nil {
+				err = err_msg.ErrLegacyJSON
+				return
+			}
+			p.b = p.b[4:]
+			r = append(r, []byte(string(rune(u)))...)
+		default:
+			err = err_msg.ErrLegacyJSON
+			return
+		}
+	}
+}
+
+// skipValue skips a nested object or array
+func (p *legacyJSONReader) skipValue() (err error) {
+	depth := 0
+	for {
+		p.skipSpace()
+		if len(p.b) == 0 {
+			err = err_msg.ErrLegacyJSON
+			return
+		}
+		switch p.b[0] {
+		case '"':
+			if _, err = p.string(); err != nil {
+				return
+			}
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		}
+		p.b = p.b[1:]
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// readLegacyJSON parses a json object, nested objects and arrays are skipped
+func readLegacyJSON(b []byte) (r map[string]legacyJSONValue, err error) {
+	p := &legacyJSONReader{b: b}
+	if err = p.expect('{'); err != nil {
+		return
+	}
+	r = make(map[string]legacyJSONValue)
+	p.skipSpace()
+	if len(p.b) > 0 && p.b[0] == '}' {
+		return
+	}
+	for {
+		var name []byte
+		if name, err = p.string(); err != nil {
+			return
+		}
+		if err = p.expect(':'); err != nil {
+			return
+		}
+		p.skipSpace()
+		if len(p.b) == 0 {
+			err = err_msg.ErrLegacyJSON
+			return
+		}
+		switch p.b[0] {
+		case '"':
+			var s []byte
+			if s, err = p.string(); err != nil {
+				return
+			}
+			r[string(name)] = jsonString(s)
+		case '{', '[':
+			if err = p.skipValue(); err != nil {
+				return
+			}
+		default:
+			end := bytes.IndexAny(p.b, ",} \t\r\n")
+			if end <= 0 {
+				err = err_msg.ErrLegacyJSON
+				return
+			}
+			r[string(name)] = legacyJSONValue{literal: string(p.b[:end])}
+			p.b = p.b[end:]
+		}
+		p.skipSpace()
+		if len(p.b) == 0 {
+			err = err_msg.ErrLegacyJSON
+			return
+		}
+		c := p.b[0]
+		p.b = p.b[1:]
+		if c == '}' {
+			return
+		}
+		if c != ',' {
+			err = err_msg.ErrLegacyJSON
+			return
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/epee"
+	"gomonero/err_msg"
+	"io/ioutil"
+	"time"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// monero-wallet-cli .keys file
+//monero/src/wallet/wallet2.cpp get_keys_file_data, load_keys_buf
+//
+// file = binary archive of {iv [8]byte, account_data string}
+// account_data = chacha20(key = cn_slow_hash(password), iv, json document)
+// json key_data = epee portable storage serialization of account_base
+// the secret keys inside key_data are xored with a chacha20 key stream when encrypted_secret_keys is set
+
+const (
+	legacyKdfRounds     = 1
+	legacyIVLength      = 8
+	legacyHashKeyMemory = 'k' //config::HASH_KEY_MEMORY
+
+	//cryptonote::network_type
+	legacyMainnet  = 0
+	legacyTestnet  = 1
+	legacyStagenet = 2
+)
+
+// legacyChachaKey derives the chacha key from data with cn_slow_hash, like crypto::generate_chacha_key
+func legacyChachaKey(data []byte, rounds int) (key crypto.Hash) {
+	key = crypto.CnSlowHash(data)
+	for n := 1; n < rounds; n++ {
+		key = crypto.CnSlowHash(key[:])
+	}
+	return
+}
+
+// legacyChacha20 xors data with the original (64 bit nonce) chacha20 key stream
+func legacyChacha20(data []byte, key crypto.Hash, iv []byte) (r []byte, err error) {
+	// with a zero counter the ietf variant matches the original variant when the nonce is prefixed by 4 zero bytes
+	nonce := make([]byte, chacha20.NonceSize)
+	copy(nonce[chacha20.NonceSize-legacyIVLength:], iv)
+	c, err := chacha20.NewUnauthenticatedCipher(key[:], nonce)
+	if err != nil {
+		return
+	}
+	r = make([]byte, len(data))
+	c.XORKeyStream(r, data)
+	return
+}
+
+// legacySecretKeyStream returns the key stream that the secret keys are xored with
+// monero/src/cryptonote_basic/account.cpp get_key_stream
+func legacySecretKeyStream(key crypto.Hash, iv []byte, length int) (r []byte, err error) {
+	data := append(key[:], legacyHashKeyMemory)
+	derived := legacyChachaKey(data, 1)
+	r, err = legacyChacha20(make([]byte, length), derived, iv)
+	return
+}
+
+func xorBytes(a, b []byte) {
+	for i := range a {
+		a[i] ^= b[i]
+	}
+}
+
+func networkFromLegacy(nettype uint64) (network int, err error) {
+	switch nettype {
+	case legacyMainnet:
+		network = address.MainNetwork
+	case legacyTestnet:
+		network = address.TestNetwork
+	case legacyStagenet:
+		network = address.StageNetwork
+	default:
+		err = err_msg.AddressTypeError
+	}
+	return
+}
+
+func networkToLegacy(network int) (nettype uint64, err error) {
+	switch network {
+	case address.MainNetwork:
+		nettype = legacyMainnet
+	case address.TestNetwork:
+		nettype = legacyTestnet
+	case address.StageNetwork:
+		nettype = legacyStagenet
+	default:
+		err = err_msg.AddressTypeError
+	}
+	return
+}
+
+// ImportLegacyKeys returns the wallet stored in the monero-wallet-cli .keys file data
+func ImportLegacyKeys(data, password []byte) (w *Wallet, err error) {
+	if len(data) < legacyIVLength {
+		err = err_msg.ErrLegacyKeysFile
+		return
+	}
+	iv := data[:legacyIVLength]
+	length, n := binary.Uvarint(data[legacyIVLength:])
+	if n <= 0 || uint64(len(data)-legacyIVLength-n) != length {
+		err = err_msg.ErrLegacyKeysFile
+		return
+	}
+	key := legacyChachaKey(password, legacyKdfRounds)
+	accountData, err := legacyChacha20(data[legacyIVLength+n:], key, iv)
+	if err != nil {
+		return
+	}
+
+	// old keys files hold the portable storage account directly instead of a json document
+	keyData := accountData
+	encryptedSecretKeys := false
+	nettype := uint64(legacyMainnet)
+	var refreshHeight, lookaheadMajor, lookaheadMinor uint64
+	if json, jsonErr := readLegacyJSON(accountData); jsonErr == nil {
+		v, ok := json["key_data"]
+		if !ok || !v.isString {
+			err = err_msg.ErrLegacyKeysFile
+			return
+		}
+		keyData = v.str
+		if v, ok := json["watch_only"]; ok && v.literal != "0" && v.literal != "false" {
+			err = err_msg.ErrWatchOnly
+			return
+		}
+		if v, ok := json["multisig"]; ok && v.literal != "0" && v.literal != "false" {
+			err = err_msg.ErrLegacyUnsupported
+			return
+		}
+		if v, ok := json["key_on_device"]; ok && v.literal != "0" {
+			err = err_msg.ErrLegacyUnsupported
+			return
+		}
+		if v, ok := json["encrypted_secret_keys"]; ok {
+			encryptedSecretKeys = v.literal != "0" && v.literal != "false"
+		}
+		if v, ok := json["nettype"].uint64(); ok {
+			nettype = v
+		}
+		refreshHeight, _ = json["refresh_height"].uint64()
+		lookaheadMajor, _ = json["subaddress_lookahead_major"].uint64()
+		lookaheadMinor, _ = json["subaddress_lookahead_minor"].uint64()
+	}
+
+	account, err := epee.Unmarshal(keyData)
+	if err != nil {
+		// a wrong password decrypts to garbage
+		err = err_msg.ErrPassword
+		return
+	}
+	keys, ok := account.Section("m_keys")
+	if !ok {
+		err = err_msg.ErrLegacyKeysFile
+		return
+	}
+	addr, _ := keys.Section("m_account_address")
+	Ks, _ := addr.Bytes("m_spend_public_key")
+	Kv, _ := addr.Bytes("m_view_public_key")
+	ks, _ := keys.Bytes("m_spend_secret_key")
+	kv, _ := keys.Bytes("m_view_secret_key")
+	multisigKeys, _ := keys.Bytes("m_multisig_keys")
+	if len(Ks) != crypto.KeyLength || len(Kv) != crypto.KeyLength || len(ks) != crypto.KeyLength || len(kv) != crypto.KeyLength {
+		err = err_msg.ErrLegacyKeysFile
+		return
+	}
+	if len(multisigKeys) > 0 {
+		err = err_msg.ErrLegacyUnsupported
+		return
+	}
+	ks = append([]byte{}, ks...)
+	kv = append([]byte{}, kv...)
+	if encryptedSecretKeys {
+		encryptionIV, ok := keys.Bytes("m_encryption_iv")
+		if !ok {
+			encryptionIV = make([]byte, legacyIVLength)
+		}
+		var stream []byte
+		if stream, err = legacySecretKeyStream(key, encryptionIV, 2*crypto.KeyLength); err != nil {
+			return
+		}
+		xorBytes(ks, stream[:crypto.KeyLength])
+		xorBytes(kv, stream[crypto.KeyLength:])
+	}
+	if isZero(ks) {
+		err = err_msg.ErrWatchOnly
+		return
+	}
+
+	ksScalar := crypto.NewScalarFromBytes(ks)
+	kvScalar := crypto.NewScalarFromBytes(kv)
+	if ksScalar.Err != nil || kvScalar.Err != nil {
+		err = err_msg.ErrPassword
+		return
+	}
+	w = new(Wallet).FromKeys(kvScalar, ksScalar)
+	if w.address.Ks.Equal(crypto.NewPointFromBytes(Ks)) == 0 || w.address.Kv.Equal(crypto.NewPointFromBytes(Kv)) == 0 {
+		w = nil
+		err = err_msg.ErrPassword
+		return
+	}
+	if w.address.Network, err = networkFromLegacy(nettype); err != nil {
+		w = nil
+		return
+	}
+	w.height = refreshHeight
+	if lookaheadMajor > 0 && lookaheadMinor > 0 {
+		w.SetSubAddressLookahead(uint32(lookaheadMajor), uint32(lookaheadMinor))
+	}
+	return
+}
+
+// ExportLegacyKeys returns the wallet as a monero-wallet-cli .keys file encrypted with password
+func (w *Wallet) ExportLegacyKeys(password []byte) (r []byte, err error) {
+	if w.IsViewOnly() {
+		err = err_msg.ErrWatchOnly
+		return
+	}
+	nettype, err := networkToLegacy(w.address.Network)
+	if err != nil {
+		return
+	}
+	key := legacyChachaKey(password, legacyKdfRounds)
+
+	encryptionIV := make([]byte, legacyIVLength)
+	if _, err = rand.Read(encryptionIV); err != nil {
+		return
+	}
+	stream, err := legacySecretKeyStream(key, encryptionIV, 2*crypto.KeyLength)
+	if err != nil {
+		return
+	}
+	ks := w.ks.Bytes()
+	kv := w.kv.Bytes()
+	xorBytes(ks, stream[:crypto.KeyLength])
+	xorBytes(kv, stream[crypto.KeyLength:])
+
+	account := epee.Section{
+		"m_keys": epee.Section{
+			"m_account_address": epee.Section{
+				"m_spend_public_key": w.address.Ks.Bytes(),
+				"m_view_public_key":  w.address.Kv.Bytes(),
+			},
+			"m_spend_secret_key": ks,
+			"m_view_secret_key":  kv,
+			"m_multisig_keys":    []byte{},
+			"m_encryption_iv":    encryptionIV,
+		},
+		"m_creation_timestamp": uint64(time.Now().Unix()),
+	}
+	keyData, err := epee.Marshal(account)
+	if err != nil {
+		return
+	}
+
+	lookaheadMajor, lookaheadMinor := w.SubAddressLookahead()
+	json := writeLegacyJSON([]legacyJSONField{
+		{"key_data", jsonString(keyData)},
+		{"seed_language", jsonString([]byte("English"))},
+		{"key_on_device", jsonNumber(0)},
+		{"watch_only", jsonNumber(0)},
+		{"multisig", jsonNumber(0)},
+		{"multisig_threshold", jsonNumber(0)},
+		{"refresh_height", jsonNumber(w.height)},
+		{"nettype", jsonNumber(nettype)},
+		{"subaddress_lookahead_major", jsonNumber(uint64(lookaheadMajor))},
+		{"subaddress_lookahead_minor", jsonNumber(uint64(lookaheadMinor))},
+		{"original_keys_available", jsonNumber(0)},
+		{"encrypted_secret_keys", jsonNumber(1)},
+	})
+
+	iv := make([]byte, legacyIVLength)
+	if _, err = rand.Read(iv); err != nil {
+		return
+	}
+	accountData, err := legacyChacha20(json, key, iv)
+	if err != nil {
+		return
+	}
+	length := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(length, uint64(len(accountData)))
+	r = append(iv, length[:n]...)
+	r = append(r, accountData...)
+	return
+}
+
+// LoadLegacyWallet reads a wallet from the monero-wallet-cli .keys file at path
+func LoadLegacyWallet(path string, password []byte) (w *Wallet, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	w, err = ImportLegacyKeys(data, password)
+	return
+}
+
+// SaveLegacyKeys writes the wallet to path as a monero-wallet-cli .keys file
+func (w *Wallet) SaveLegacyKeys(path string, password []byte) (err error) {
+	data, err := w.ExportLegacyKeys(password)
+	if err != nil {
+		return
+	}
+	err = writeFileAtomic(path, data)
+	return
+}
+
+func isZero(b []byte) (r bool) {
+	r = true
+	for _, c := range b {
+		if c != 0 {
+			r = false
+		}
+	}
+	return
+}
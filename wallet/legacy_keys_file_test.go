@@ -0,0 +1,81 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"testing"
+)
+
+func TestLegacyChacha20(t *testing.T) {
+	// original chacha20 with a 64 bit nonce, all zero key and nonce 0000000000000001
+	iv, _ := hex.DecodeString("0000000000000001")
+	want := "de9cba7bf3d69ef5e786dc63973f653a0b49e015adbff7134fcb7df137821031e85a050278a7084527214f73efc7fa5b5277062eb7a0433e445f41e31afab757"
+	got, err := legacyChacha20(make([]byte, 64), crypto.Hash{}, iv)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if hex.EncodeToString(got) != want {
+		t.Errorf("want: %s, got: %x", want, got)
+	}
+}
+
+func TestLegacyJSON(t *testing.T) {
+	binary := []byte{0x00, 0x01, '"', '\\', '\n', 0x7f, 0x80, 0xff}
+	fields := []legacyJSONField{
+		{"key_data", jsonString(binary)},
+		{"nettype", jsonNumber(2)},
+	}
+	got, err := readLegacyJSON(writeLegacyJSON(fields))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if hex.EncodeToString(got["key_data"].str) != hex.EncodeToString(binary) {
+		t.Errorf("want: %x, got: %x", binary, got["key_data"].str)
+	}
+	if n, ok := got["nettype"].uint64(); !ok || n != 2 {
+		t.Errorf("want: 2, got: %v", n)
+	}
+
+	// nested members written by the reference wallet are skipped
+	got, err = readLegacyJSON([]byte(`{"a": {"b": [1, "}"]}, "c" : "d" , "e":true}`))
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if string(got["c"].str) != "d" || got["e"].literal != "true" {
+		t.Errorf("wrong members: %v", got)
+	}
+}
+
+func TestLegacyKeysFile(t *testing.T) {
+	password := []byte("password")
+	w := new(Wallet).FromKeys(
+		crypto.NewScalarFromHexString("75327f96ed4f4c9daacde2ac3441d487b34c0ca6daf33d0f5ad9820b4a46b403"),
+		crypto.NewScalarFromHexString("5cb87ea14173499040473c1df47d62ade23537d14ad17bce93002c4c8d227204"),
+	)
+	w.address.Network = address.StageNetwork
+	w.SetHeight(1234)
+
+	data, err := w.ExportLegacyKeys(password)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if _, err = ImportLegacyKeys(data, []byte("wrong")); !errors.Is(err, err_msg.ErrPassword) {
+		t.Errorf("want: %s, got: %v", err_msg.ErrPassword, err)
+	}
+	got, err := ImportLegacyKeys(data, password)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if got.ks.Equal(w.ks) == 0 || got.kv.Equal(w.kv) == 0 {
+		t.Errorf("private keys do not match")
+	}
+	if got.address.Base58() != w.address.Base58() {
+		t.Errorf("wrong address: want: %v got: %v", w.address.Base58(), got.address.Base58())
+	}
+	if got.Height() != 1234 {
+		t.Errorf("wrong refresh height: want: 1234 got: %v", got.Height())
+	}
+}
@@ -0,0 +1,119 @@
+package wallet
+
+import (
+	"encoding/binary"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"strings"
+)
+
+// message signing, a signature of a message by the spend or the view key of an address proves its ownership
+// SigV2 signs Keccak("MoneroMessageSignature\x00" || Ks || Kv || key || varint(len(message)) || message), the older
+// SigV1 signs Keccak(message) and is only verified
+//
+// monero/src/wallet/wallet2.cpp get_message_hash, sign_message, verify_message
+
+// MessageSigningKey is the key of an address signing a message
+type MessageSigningKey uint8
+
+// monero/src/wallet/wallet2.h message_signature_type_t
+const (
+	SignWithSpendKey MessageSigningKey = iota
+	SignWithViewKey
+)
+
+const (
+	messageSignatureV1 = "SigV1"
+	messageSignatureV2 = "SigV2"
+	messageSigningKey  = "MoneroMessageSignature\x00" //config::HASH_KEY_MESSAGE_SIGNING
+)
+
+// messageHash returns the hash signed by key of the address with the public keys Ks and Kv
+func messageHash(data []byte, Ks, Kv *crypto.PublicKey, key MessageSigningKey) (h crypto.Hash) {
+	length := make([]byte, binary.MaxVarintLen64)
+	length = length[:binary.PutUvarint(length, uint64(len(data)))]
+	h = crypto.Keccak256([]byte(messageSigningKey), Ks.Bytes(), Kv.Bytes(), []byte{byte(key)}, length, data)
+	return
+}
+
+// SignMessage returns the SigV2 signature of data by key of subaddress i of the wallet, {0, 0} is the standard
+// address, a view only wallet can only sign with the view key of the standard address
+//
+// the private view key of subaddress i is kv (ks + m) with its public view key kv Ksi
+func (w *Wallet) SignMessage(data []byte, key MessageSigningKey, i SubAddressIndex) (signature string, err error) {
+	if key != SignWithSpendKey && key != SignWithViewKey {
+		err = err_msg.ErrMessageSigningKey
+		return
+	}
+	if w.IsViewOnly() && (key == SignWithSpendKey || i != (SubAddressIndex{})) {
+		err = err_msg.ErrWatchOnly
+		return
+	}
+	var Ks, Kv *crypto.PublicKey
+	var k *crypto.PrivateKey
+	if i == (SubAddressIndex{}) {
+		Ks, Kv = w.address.Ks, w.address.Kv
+		k = w.kv
+		if key == SignWithSpendKey {
+			k = w.ks
+		}
+	} else {
+		a := w.SubAddress(i)
+		Ks, Kv = a.Ksi, a.Kvi
+		if k, err = w.SubAddressPrivateSpendKey(i); err != nil {
+			return
+		}
+		if key == SignWithViewKey {
+			k = w.kv.Multiply(k)
+		}
+	}
+	K := Ks
+	if key == SignWithViewKey {
+		K = Kv
+	}
+	sig := crypto.NewSignature(messageHash(data, Ks, Kv, key), K, k)
+	signature = messageSignatureV2 + address.EncodeMoneroBase58(sig.C.Bytes(), sig.R.Bytes())
+	return
+}
+
+// VerifyMessage returns the key of the address a which signed data with signature, SigV1 and SigV2 signatures are
+// accepted, it does not depend on the keys of the wallet
+func (w *Wallet) VerifyMessage(data []byte, a address.Destination, signature string) (key MessageSigningKey, err error) {
+	v1 := strings.HasPrefix(signature, messageSignatureV1)
+	if !v1 && !strings.HasPrefix(signature, messageSignatureV2) {
+		err = err_msg.ErrMessageSignatureFormat
+		return
+	}
+	encoded := signature[len(messageSignatureV2):]
+	raw := address.DecodeMoneroBase58(encoded)
+	if len(raw) != 2*crypto.KeyLength || address.EncodeMoneroBase58(raw) != encoded {
+		err = err_msg.ErrMessageSignatureFormat
+		return
+	}
+	sig := &crypto.Signature{
+		C: crypto.NewScalarFromBytes(raw[:crypto.KeyLength]),
+		R: crypto.NewScalarFromBytes(raw[crypto.KeyLength:]),
+	}
+	if sig.C.Err != nil || sig.R.Err != nil {
+		err = err_msg.ErrMessageSignatureFormat
+		return
+	}
+	Ks, Kv := a.SpendKey(), a.ViewKey()
+	for _, key = range []MessageSigningKey{SignWithSpendKey, SignWithViewKey} {
+		h := crypto.Keccak256(data)
+		if !v1 {
+			h = messageHash(data, Ks, Kv, key)
+		}
+		K := Ks
+		if key == SignWithViewKey {
+			K = Kv
+		}
+		if sig.Verify(h, K) {
+			return
+		}
+	}
+	key = 0
+	err = err_msg.ErrMessageSignature
+	return
+}
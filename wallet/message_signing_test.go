@@ -0,0 +1,63 @@
+package wallet
+
+import (
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"testing"
+)
+
+func TestMessageSigning(t *testing.T) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(2, 5)
+	data := []byte("message")
+	for _, test := range []struct {
+		key   MessageSigningKey
+		index SubAddressIndex
+	}{
+		{SignWithSpendKey, SubAddressIndex{}},
+		{SignWithViewKey, SubAddressIndex{}},
+		{SignWithSpendKey, SubAddressIndex{1, 2}},
+		{SignWithViewKey, SubAddressIndex{0, 3}},
+	} {
+		var a address.Destination = &w.address
+		if test.index != (SubAddressIndex{}) {
+			a = w.SubAddress(test.index)
+		}
+		signature, err := w.SignMessage(data, test.key, test.index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key, err := w.VerifyMessage(data, a, signature); err != nil || key != test.key {
+			t.Errorf("%v %v: want key %d, got %d: %v", test.key, test.index, test.key, key, err)
+		}
+		if _, err := w.VerifyMessage([]byte("other message"), a, signature); err != err_msg.ErrMessageSignature {
+			t.Errorf("%v %v: other message, want %v, got %v", test.key, test.index, err_msg.ErrMessageSignature, err)
+		}
+		if _, err := w.VerifyMessage(data, w.SubAddress(SubAddressIndex{1, 1}), signature); err != err_msg.ErrMessageSignature {
+			t.Errorf("%v %v: other address, want %v, got %v", test.key, test.index, err_msg.ErrMessageSignature, err)
+		}
+	}
+
+	// SigV1 signs the hash of the message
+	sig := crypto.NewSignature(crypto.Keccak256(data), w.address.Kv, w.kv)
+	signature := "SigV1" + address.EncodeMoneroBase58(sig.C.Bytes(), sig.R.Bytes())
+	if key, err := w.VerifyMessage(data, &w.address, signature); err != nil || key != SignWithViewKey {
+		t.Errorf("SigV1: want key %d, got %d: %v", SignWithViewKey, key, err)
+	}
+	for _, signature := range []string{"", "SigV3", "SigV2abc", signature[:len(signature)-1]} {
+		if _, err := w.VerifyMessage(data, &w.address, signature); err != err_msg.ErrMessageSignatureFormat {
+			t.Errorf("%q: want %v, got %v", signature, err_msg.ErrMessageSignatureFormat, err)
+		}
+	}
+
+	viewOnly := NewViewOnlyWallet(w.kv, w.address.Ks, w.address.Network)
+	if _, err := viewOnly.SignMessage(data, SignWithSpendKey, SubAddressIndex{}); err != err_msg.ErrWatchOnly {
+		t.Errorf("want %v, got %v", err_msg.ErrWatchOnly, err)
+	}
+	if signature, err := viewOnly.SignMessage(data, SignWithViewKey, SubAddressIndex{}); err != nil {
+		t.Error(err)
+	} else if key, err := w.VerifyMessage(data, &w.address, signature); err != nil || key != SignWithViewKey {
+		t.Errorf("view only: want key %d, got %d: %v", SignWithViewKey, key, err)
+	}
+}
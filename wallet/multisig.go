@@ -0,0 +1,748 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"math/bits"
+	"sort"
+)
+
+// multisig wallets, the private spend key of an M of N wallet is split between the groups of N - M + 1 signers, so
+// that any M signers hold the keys of all the groups
+//
+// the keys of the groups are derived in N - M + 1 rounds of key exchange, in round r each signer publishes the public
+// keys of its groups of r signers, then the key of a group T of r + 1 signers whose last signer is j is
+// k_T = Hs("Multisig_derived" || k_(T - j) K_j), which only the members of T can compute
+// the spend key is the sum of a_T K_T for the groups of N - M + 1 signers, the aggregation coefficients a_T prevent key
+// cancellation, the private view key is the sum of the contributions of the first round
+//
+// monero/src/multisig/multisig_account.cpp, monero/src/multisig/multisig_kex_msg.cpp
+// monero/src/wallet/wallet2.cpp export_multisig, import_multisig, sign_multisig_tx
+
+const multisigMaxSigners = 16
+
+// multisigGroup is a set of signers, bit n is the signer with the n-th smallest base public key
+type multisigGroup uint64
+
+func (g multisigGroup) size() (n int) {
+	n = bits.OnesCount64(uint64(g))
+	return
+}
+
+func (g multisigGroup) has(n int) (r bool) {
+	r = g&(1<<uint(n)) != 0
+	return
+}
+
+// last returns the signer of g with the largest index
+func (g multisigGroup) last() (n int) {
+	n = 63 - bits.LeadingZeros64(uint64(g))
+	return
+}
+
+// multisigGroups returns the groups of size of n signers, in increasing order
+func multisigGroups(n, size int) (groups []multisigGroup) {
+	for g := multisigGroup(1); g < 1<<uint(n); g++ {
+		if g.size() == size {
+			groups = append(groups, g)
+		}
+	}
+	return
+}
+
+// multisigDomain returns a domain separator padded to a key as config::HASH_KEY_MULTISIG
+func multisigDomain(domain string) (r []byte) {
+	r = make([]byte, crypto.KeyLength)
+	copy(r, domain)
+	return
+}
+
+// MultisigKexMessage is a message of a round of the key exchange, it can be exchanged encoded with encoding/json
+// the first round message carries a private view key contribution and must only be sent to the other signers
+type MultisigKexMessage struct {
+	Round     int
+	Signer    *crypto.PublicKey  //base public key of the sender
+	ViewKey   *crypto.PrivateKey `json:",omitempty"` //first round only
+	Groups    []uint64           //groups of Round signers the sender belongs to, from the second round
+	Keys      []*crypto.PublicKey
+	Signature *crypto.Signature //by the base private key of the sender
+}
+
+func (msg *MultisigKexMessage) hash() (h crypto.Hash) {
+	round := make([]byte, 8)
+	binary.LittleEndian.PutUint64(round, uint64(msg.Round))
+	data := [][]byte{multisigDomain("Multisig_kex_msg"), round, msg.Signer.Bytes()}
+	if msg.ViewKey != nil {
+		data = append(data, msg.ViewKey.Bytes())
+	}
+	for n, g := range msg.Groups {
+		group := make([]byte, 8)
+		binary.LittleEndian.PutUint64(group, g)
+		data = append(data, group, msg.Keys[n].Bytes())
+	}
+	h = crypto.Keccak256(data...)
+	return
+}
+
+// MultisigAccount is the share of a signer of a multisig wallet
+type MultisigAccount struct {
+	threshold    int
+	signers      int
+	network      int
+	k            *crypto.PrivateKey //base private key
+	K            *crypto.PublicKey  //base public key, identifies the signer
+	kv           *crypto.PrivateKey //view key contribution, then shared private view key
+	round        int                //next key exchange round, 0 once the exchange is complete
+	participants []*crypto.PublicKey
+	me           int                                 //index of the signer in participants
+	private      map[multisigGroup]*crypto.Scalar    //keys of the groups of the signer
+	public       map[multisigGroup]*crypto.PublicKey //keys of all the groups
+	coefficients map[multisigGroup]*crypto.Scalar    //aggregation coefficients of the groups of the spend key
+	wallet       *Wallet
+	nonces       map[crypto.Hash][][2]*crypto.Scalar //secret nonces of the proposals being signed, by proposal hash
+}
+
+// NewMultisigAccount starts the key exchange of a threshold of signers wallet from the keys of w, msg is sent to the
+// other signers and their messages are processed with ProcessKexMessages
+func NewMultisigAccount(w *Wallet, threshold, signers int) (a *MultisigAccount, msg *MultisigKexMessage, err error) {
+	if w.IsViewOnly() {
+		err = err_msg.ErrWatchOnly
+		return
+	}
+	if signers < 2 || signers > multisigMaxSigners || threshold < 1 || threshold > signers {
+		err = err_msg.ErrMultisigParameters
+		return
+	}
+	// get_multisig_blinded_secret_key
+	a = &MultisigAccount{
+		threshold: threshold,
+		signers:   signers,
+		network:   w.address.Network,
+		k:         crypto.HashToScalar(w.ks.Bytes(), multisigDomain("Multisig")),
+		kv:        crypto.HashToScalar(w.kv.Bytes(), multisigDomain("Multisig")),
+		round:     1,
+		private:   make(map[multisigGroup]*crypto.Scalar),
+		public:    make(map[multisigGroup]*crypto.PublicKey),
+		nonces:    make(map[crypto.Hash][][2]*crypto.Scalar),
+	}
+	a.K = a.k.PublicKey()
+	msg = a.signMessage(&MultisigKexMessage{Round: 1, Signer: a.K, ViewKey: a.kv})
+	return
+}
+
+func (a *MultisigAccount) signMessage(msg *MultisigKexMessage) *MultisigKexMessage {
+	msg.Signature = crypto.NewSignature(msg.hash(), a.K, a.k)
+	return msg
+}
+
+// KexRounds returns the number of key exchange rounds, N - M + 1
+func (a *MultisigAccount) KexRounds() (n int) {
+	n = a.signers - a.threshold + 1
+	return
+}
+
+// IsReady returns true once the key exchange is complete
+func (a *MultisigAccount) IsReady() (r bool) {
+	r = a.round == 0
+	return
+}
+
+// Wallet returns the view only wallet of the multisig address, it finds the received outputs and gets their key
+// images with ImportMultisigInfo
+func (a *MultisigAccount) Wallet() (w *Wallet) {
+	w = a.wallet
+	return
+}
+
+// ProcessKexMessages processes the messages of the other signers for the current round and returns the message of
+// the next round, nil once the key exchange is complete
+func (a *MultisigAccount) ProcessKexMessages(msgs []*MultisigKexMessage) (next *MultisigKexMessage, err error) {
+	if a.IsReady() {
+		err = err_msg.ErrMultisigRound
+		return
+	}
+	others := make(map[[32]byte]*MultisigKexMessage)
+	for _, msg := range msgs {
+		if msg == nil || msg.Signer == nil || msg.Signature == nil || len(msg.Groups) != len(msg.Keys) {
+			err = err_msg.ErrMultisigMessage
+			return
+		}
+		if msg.Round != a.round {
+			err = err_msg.ErrMultisigRound
+			return
+		}
+		if msg.Signer.Equal(a.K) == 1 {
+			continue
+		}
+		if _, ok := others[msg.Signer.Byte32()]; ok || (a.round == 1) != (msg.ViewKey != nil) || !msg.Signature.Verify(msg.hash(), msg.Signer) {
+			err = err_msg.ErrMultisigMessage
+			return
+		}
+		others[msg.Signer.Byte32()] = msg
+	}
+	if len(others) != a.signers-1 {
+		err = err_msg.ErrMultisigSigners
+		return
+	}
+
+	if a.round == 1 {
+		a.participants = []*crypto.PublicKey{a.K}
+		for _, msg := range others {
+			a.participants = append(a.participants, msg.Signer)
+			a.kv = a.kv.Add(msg.ViewKey)
+		}
+		sort.Slice(a.participants, func(i, j int) bool {
+			return bytes.Compare(a.participants[i].Bytes(), a.participants[j].Bytes()) < 0
+		})
+		for n, K := range a.participants {
+			a.public[1<<uint(n)] = K
+			if K.Equal(a.K) == 1 {
+				a.me = n
+			}
+		}
+		a.private[1<<uint(a.me)] = a.k
+	} else if err = a.addGroupKeys(others); err != nil {
+		return
+	}
+
+	if a.round == a.KexRounds() {
+		a.finishKex()
+		return
+	}
+	a.round++
+	next = &MultisigKexMessage{Round: a.round, Signer: a.K}
+	for _, g := range multisigGroups(a.signers, a.round) {
+		if !g.has(a.me) {
+			continue
+		}
+		// k_T = Hs(k_(T - j) K_j) = Hs(k_j K_(T - j))
+		j := g.last()
+		rest := g &^ (1 << uint(j))
+		var DH *crypto.Point
+		if j == a.me {
+			DH = a.public[rest].ScalarMult(a.k)
+		} else {
+			DH = a.public[1<<uint(j)].ScalarMult(a.private[rest])
+		}
+		a.private[g] = crypto.HashToScalar(multisigDomain("Multisig_derived"), DH.Bytes())
+		a.public[g] = a.private[g].MultG()
+		next.Groups = append(next.Groups, uint64(g))
+		next.Keys = append(next.Keys, a.public[g])
+	}
+	next = a.signMessage(next)
+	return
+}
+
+// addGroupKeys records the group keys of the current round published by the other signers, each group is
+// published by all its members which must agree
+func (a *MultisigAccount) addGroupKeys(others map[[32]byte]*MultisigKexMessage) (err error) {
+	for _, msg := range others {
+		sender := -1
+		for n, K := range a.participants {
+			if K.Equal(msg.Signer) == 1 {
+				sender = n
+			}
+		}
+		if sender < 0 {
+			err = err_msg.ErrMultisigSigners
+			return
+		}
+		for n, group := range msg.Groups {
+			g := multisigGroup(group)
+			if g.size() != a.round || !g.has(sender) || g.last() >= a.signers || msg.Keys[n] == nil {
+				err = err_msg.ErrMultisigMessage
+				return
+			}
+			if K, ok := a.public[g]; ok && K.Equal(msg.Keys[n]) == 0 {
+				err = err_msg.ErrMultisigMessage
+				return
+			}
+			a.public[g] = msg.Keys[n]
+		}
+	}
+	for _, g := range multisigGroups(a.signers, a.round) {
+		if _, ok := a.public[g]; !ok {
+			err = err_msg.ErrMultisigMessage
+			return
+		}
+	}
+	return
+}
+
+// finishKex computes the spend key from the keys of the groups of N - M + 1 signers
+// a_T = Hs("Multisig_aggregation" || K_T || K_T0 || K_T1 || ...), Ks = sum a_T K_T
+func (a *MultisigAccount) finishKex() {
+	groups := multisigGroups(a.signers, a.KexRounds())
+	var transcript []byte
+	for _, g := range groups {
+		transcript = append(transcript, a.public[g].Bytes()...)
+	}
+	a.coefficients = make(map[multisigGroup]*crypto.Scalar)
+	Ks := crypto.PointI()
+	for _, g := range groups {
+		a.coefficients[g] = crypto.HashToScalar(multisigDomain("Multisig_aggregation"), a.public[g].Bytes(), transcript)
+		Ks = Ks.Add(a.public[g].ScalarMult(a.coefficients[g]))
+	}
+	a.wallet = NewViewOnlyWallet(a.kv, Ks, a.network)
+	a.round = 0
+}
+
+// share returns the part of the private spend key contributed by the signer when signing with signers, each group is
+// contributed by its first member among signers
+func (a *MultisigAccount) share(signers []int) (p *crypto.Scalar) {
+	p = crypto.ScalarZero()
+	for g, c := range a.coefficients {
+		if !g.has(a.me) {
+			continue
+		}
+		for _, n := range signers {
+			if g.has(n) {
+				if n == a.me {
+					p = p.Add(c.Multiply(a.private[g]))
+				}
+				break
+			}
+		}
+	}
+	return
+}
+
+// MultisigInfo holds the partial key images of the outputs of a multisig wallet computed by a signer, it can be
+// exchanged encoded with encoding/json
+type MultisigInfo struct {
+	Signer    *crypto.PublicKey
+	KeyImages []MultisigPartialKeyImage
+}
+
+// MultisigPartialKeyImage is a_T k_T Hp(Ko) for a group T of the signer, proven against a_T K_T
+type MultisigPartialKeyImage struct {
+	Ko       *crypto.PublicKey
+	Group    uint64
+	KeyImage *crypto.PublicKey
+	Proof    *crypto.DLEQProof
+}
+
+// ExportMultisigInfo returns the partial key images of the outputs of the multisig wallet waiting for their key
+// images, to be imported by the other signers
+func (a *MultisigAccount) ExportMultisigInfo() (info *MultisigInfo, err error) {
+	if !a.IsReady() {
+		err = err_msg.ErrMultisigNotReady
+		return
+	}
+	info = &MultisigInfo{Signer: a.K}
+	for _, o := range a.wallet.outputs.Pending() {
+		Hp := o.Ko.HashToEC()
+		for g, c := range a.coefficients {
+			if !g.has(a.me) {
+				continue
+			}
+			k := c.Multiply(a.private[g])
+			info.KeyImages = append(info.KeyImages, MultisigPartialKeyImage{
+				Ko:       o.Ko,
+				Group:    uint64(g),
+				KeyImage: Hp.ScalarMult(k),
+				Proof:    crypto.NewDLEQProof(crypto.Keccak256(o.Ko.Bytes()), Hp, k),
+			})
+		}
+	}
+	return
+}
+
+// ImportMultisigInfo combines the partial key images of infos with those of the signer, the outputs whose groups are
+// all covered get their key image KI = x Hp(Ko) + sum a_T k_T Hp(Ko), it returns the number of such outputs
+func (a *MultisigAccount) ImportMultisigInfo(infos []*MultisigInfo) (n int, err error) {
+	own, err := a.ExportMultisigInfo()
+	if err != nil {
+		return
+	}
+	partials := make(map[[32]byte]map[multisigGroup]*crypto.PublicKey)
+	for _, info := range append([]*MultisigInfo{own}, infos...) {
+		if info == nil {
+			err = err_msg.ErrMultisigKeyImage
+			return
+		}
+		for _, pki := range info.KeyImages {
+			g := multisigGroup(pki.Group)
+			c, ok := a.coefficients[g]
+			if !ok || pki.Ko == nil || pki.KeyImage == nil || pki.Proof == nil ||
+				!pki.Proof.Verify(crypto.Keccak256(pki.Ko.Bytes()), pki.Ko.HashToEC(), a.public[g].ScalarMult(c), pki.KeyImage) {
+				err = err_msg.ErrMultisigKeyImage
+				return
+			}
+			if partials[pki.Ko.Byte32()] == nil {
+				partials[pki.Ko.Byte32()] = make(map[multisigGroup]*crypto.PublicKey)
+			}
+			partials[pki.Ko.Byte32()][g] = pki.KeyImage
+		}
+	}
+	w := a.wallet
+	for _, o := range w.outputs.Pending() {
+		p := partials[o.Ko.Byte32()]
+		if len(p) != len(a.coefficients) {
+			continue
+		}
+		KI := o.Ko.HashToEC().ScalarMult(w.outputViewSecret(o))
+		for _, pki := range p {
+			KI = KI.Add(pki)
+		}
+		if err = w.outputs.SetKeyImage(o.Ko, KI); err != nil {
+			return
+		}
+		n++
+	}
+	return
+}
+
+// MultisigTxProposal is a transaction waiting for the signatures of threshold signers, it can be exchanged encoded
+// with encoding/json
+//
+// the signers rebuild the outputs of Tx from the payments and the transaction private keys before signing
+type MultisigTxProposal struct {
+	Tx               *RingCTTx //without CLSAGs
+	Inputs           []MultisigTxInput
+	Signers          []*crypto.PublicKey   //base public keys of the signers, sorted
+	Payments         []exportedDestination //destinations of the outputs of Tx, in order
+	Change           int                   //index of the change in Payments, -1 without change
+	ChangeAddress    SubAddressIndex       //subaddress of the wallet receiving the change
+	TxKey            *crypto.PrivateKey
+	AdditionalTxKeys []*crypto.PrivateKey
+}
+
+// MultisigTxInput is an input of a proposal, in transaction order
+type MultisigTxInput struct {
+	Ring   []RingMember
+	Output *Output
+	CLSAG  *crypto.MultisigCLSAG
+	Z      *crypto.Scalar //mask of the output minus mask of the pseudo output
+}
+
+// MultisigTxNonces are the public nonces of a signer for the inputs of a proposal
+type MultisigTxNonces struct {
+	Signer *crypto.PublicKey
+	Nonces []*crypto.CLSAGNonce
+}
+
+// MultisigTxPartial are the partial responses of a signer for the inputs of a proposal
+type MultisigTxPartial struct {
+	Signer    *crypto.PublicKey
+	Responses []*crypto.Scalar
+}
+
+// ProposeTx returns the transaction of BuildTx to be signed by signers, the base public keys of threshold
+// participants, the sources must have their key images imported with ImportMultisigInfo
+//
+// the signers exchange their NewTxNonces, then their PartialSignTx, and CombineTx returns the signed transaction
+func (a *MultisigAccount) ProposeTx(destinations []TxDestination, sources []TxSource, fee uint64, change SubAddressIndex, signers []*crypto.PublicKey) (p *MultisigTxProposal, err error) {
+	if !a.IsReady() {
+		err = err_msg.ErrMultisigNotReady
+		return
+	}
+	p = &MultisigTxProposal{Signers: append([]*crypto.PublicKey{}, signers...)}
+	sort.Slice(p.Signers, func(i, j int) bool {
+		return bytes.Compare(p.Signers[i].Bytes(), p.Signers[j].Bytes()) < 0
+	})
+	if _, err = a.signerIndices(p); err != nil {
+		p = nil
+		return
+	}
+	tx, inputs, keys, payments, changeIndex, err := a.wallet.buildUnsignedTx(destinations, sources, fee, change)
+	if err != nil {
+		p = nil
+		return
+	}
+	p.Tx, p.Payments, p.Change, p.ChangeAddress = tx, exportDestinations(payments), changeIndex, change
+	p.TxKey, p.AdditionalTxKeys = keys.PrivateKeys()
+	for _, in := range inputs {
+		z := in.mask.Subtract(in.pseudoMask)
+		P, _ := ringKeys(in.source.Ring)
+		var ms *crypto.MultisigCLSAG
+		if ms, err = crypto.NewMultisigCLSAG(P, in.l, in.keyImage, z); err != nil {
+			p = nil
+			return
+		}
+		p.Inputs = append(p.Inputs, MultisigTxInput{Ring: in.source.Ring, Output: in.source.Output, CLSAG: ms, Z: z})
+	}
+	return
+}
+
+// signerIndices returns the indices of the signers of p among the participants
+func (a *MultisigAccount) signerIndices(p *MultisigTxProposal) (signers []int, err error) {
+	for n, K := range p.Signers {
+		if n > 0 && K.Equal(p.Signers[n-1]) == 1 {
+			err = err_msg.ErrMultisigSigners
+			return
+		}
+		for i, Ki := range a.participants {
+			if Ki.Equal(K) == 1 {
+				signers = append(signers, i)
+			}
+		}
+	}
+	if len(signers) != a.threshold || len(signers) != len(p.Signers) {
+		signers = nil
+		err = err_msg.ErrMultisigSigners
+	}
+	return
+}
+
+// check returns an error if p is not a proposal spending outputs of the multisig wallet, or if the outputs of p.Tx
+// do not pay its payments, and returns the summary of the transaction
+func (a *MultisigAccount) check(p *MultisigTxProposal) (signers []int, summary *TxSummary, err error) {
+	if !a.IsReady() {
+		err = err_msg.ErrMultisigNotReady
+		return
+	}
+	if signers, err = a.signerIndices(p); err != nil {
+		return
+	}
+	if p.Tx == nil || len(p.Inputs) != len(p.Tx.Inputs) || len(p.Tx.PseudoOuts) != len(p.Inputs) {
+		err = err_msg.ErrTxSemantics
+		return
+	}
+	for n, in := range p.Inputs {
+		if in.Output == nil || in.CLSAG == nil || in.Z == nil || in.CLSAG.I == nil ||
+			in.CLSAG.I.Equal(p.Tx.Inputs[n].KeyImage) == 0 || a.wallet.outputPublicKey(in.Output).Equal(in.Output.Ko) == 0 {
+			err = err_msg.ErrTxSemantics
+			return
+		}
+	}
+	summary, err = a.wallet.checkProposalOutputs(p)
+	return
+}
+
+// checkProposalOutputs rebuilds the outputs of p.Tx from the payments and the transaction private keys of p, and
+// returns the summary of the transaction if the one time addresses, the encrypted amounts and the commitments match
+//
+// monero/src/wallet/wallet2.cpp sign_multisig_tx
+func (w *Wallet) checkProposalOutputs(p *MultisigTxProposal) (summary *TxSummary, err error) {
+	payments, err := importDestinations(p.Payments)
+	if err != nil {
+		return
+	}
+	if p.Change < -1 || p.Change >= len(payments) {
+		err = err_msg.ErrTxSemantics
+		return
+	}
+	var destinations []TxDestination
+	addresses := make([]address.Destination, len(payments))
+	changeAmount := uint64(0)
+	for n, d := range payments {
+		addresses[n] = d.Address
+		if n == p.Change {
+			changeAmount = d.Amount
+		} else {
+			destinations = append(destinations, d)
+		}
+	}
+	if p.Change >= 0 {
+		var changeAddress address.Destination = &w.address
+		if p.ChangeAddress != (SubAddressIndex{}) {
+			changeAddress = w.SubAddress(p.ChangeAddress)
+		}
+		d := addresses[p.Change]
+		if d.IsSubaddress() != changeAddress.IsSubaddress() || d.SpendKey().Equal(changeAddress.SpendKey()) == 0 ||
+			d.ViewKey().Equal(changeAddress.ViewKey()) == 0 {
+			err = err_msg.ErrTxSemantics
+			return
+		}
+	}
+	var sources []TxSource
+	for _, in := range p.Inputs {
+		sources = append(sources, TxSource{Output: in.Output, Ring: in.Ring})
+	}
+	if summary, err = newTxSummary(destinations, sources, p.Tx.Fee, p.ChangeAddress); err != nil {
+		return
+	}
+	if summary.ChangeAmount != changeAmount {
+		summary, err = nil, err_msg.ErrTxBalance
+		return
+	}
+
+	keys, err := address.TxKeysFromPrivateKeys(addresses, p.Change, w.kv, p.TxKey, p.AdditionalTxKeys)
+	if err != nil {
+		summary = nil
+		return
+	}
+	rebuilt := &RingCTTx{R: keys.R, AdditionalKeys: keys.AdditionalKeys}
+	masks, err := setTxOutputs(rebuilt, payments, p.Change, keys)
+	if err != nil {
+		summary = nil
+		return
+	}
+	tx := p.Tx
+	ok := tx.R != nil && tx.R.Equal(rebuilt.R) == 1 && len(tx.AdditionalKeys) == len(rebuilt.AdditionalKeys) &&
+		len(tx.Outputs) == len(rebuilt.Outputs) && len(tx.EcdhInfo) == len(rebuilt.Outputs) && len(tx.OutPk) == len(rebuilt.Outputs) &&
+		(tx.EncryptedPaymentID == nil) == (rebuilt.EncryptedPaymentID == nil) &&
+		(tx.EncryptedPaymentID == nil || *tx.EncryptedPaymentID == *rebuilt.EncryptedPaymentID)
+	for n := 0; ok && n < len(rebuilt.AdditionalKeys); n++ {
+		ok = tx.AdditionalKeys[n] != nil && tx.AdditionalKeys[n].Equal(rebuilt.AdditionalKeys[n]) == 1
+	}
+	for n := 0; ok && n < len(rebuilt.Outputs); n++ {
+		C := masks[n].DoubleScalarBaseMult(newAmount64(payments[n].Amount).Scalar(), crypto.PointH())
+		ok = tx.Outputs[n].Ko != nil && tx.Outputs[n].Ko.Equal(rebuilt.Outputs[n].Ko) == 1 &&
+			tx.Outputs[n].ViewTag == rebuilt.Outputs[n].ViewTag &&
+			tx.EcdhInfo[n] != nil && *tx.EcdhInfo[n] == *rebuilt.EcdhInfo[n] &&
+			tx.OutPk[n] != nil && tx.OutPk[n].Equal(C) == 1
+	}
+	if !ok {
+		summary, err = nil, err_msg.ErrTxSemantics
+	}
+	return
+}
+
+// NewTxNonces returns the public nonces of the signer for p, the secret nonces are kept for a single PartialSignTx
+// accept must approve the summary of the transaction, a nil accept signs without confirmation
+func (a *MultisigAccount) NewTxNonces(p *MultisigTxProposal, accept TxAcceptFunc) (nonces *MultisigTxNonces, err error) {
+	signers, summary, err := a.check(p)
+	if err != nil {
+		return
+	}
+	if accept != nil && !accept(summary) {
+		err = err_msg.ErrTxRejected
+		return
+	}
+	if n := sort.SearchInts(signers, a.me); n == len(signers) || signers[n] != a.me {
+		err = err_msg.ErrMultisigSigners
+		return
+	}
+	h, err := p.hash()
+	if err != nil {
+		return
+	}
+	nonces = &MultisigTxNonces{Signer: a.K}
+	var secrets [][2]*crypto.Scalar
+	for _, in := range p.Inputs {
+		secret, nonce := crypto.NewCLSAGNonce(in.Output.Ko)
+		secrets = append(secrets, secret)
+		nonces.Nonces = append(nonces.Nonces, nonce)
+	}
+	a.nonces[h] = secrets
+	return
+}
+
+// hash returns the hash of the whole proposal, the secret nonces of a signer are only used for the proposal they were
+// created for, a proposal with other decoys or other inputs is another proposal
+func (p *MultisigTxProposal) hash() (h crypto.Hash, err error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+	h = crypto.Keccak256(data)
+	return
+}
+
+// inputNonces returns the nonces of the signers of p for each input, in the order of p.Signers
+func inputNonces(p *MultisigTxProposal, nonces []*MultisigTxNonces) (r [][]*crypto.CLSAGNonce, err error) {
+	r = make([][]*crypto.CLSAGNonce, len(p.Inputs))
+	for _, K := range p.Signers {
+		found := false
+		for _, n := range nonces {
+			if n == nil || n.Signer == nil || n.Signer.Equal(K) == 0 {
+				continue
+			}
+			if found || len(n.Nonces) != len(p.Inputs) {
+				err = err_msg.ErrCLSAGNonce
+				return
+			}
+			found = true
+			for i, nonce := range n.Nonces {
+				r[i] = append(r[i], nonce)
+			}
+		}
+		if !found {
+			err = err_msg.ErrMultisigSigners
+			return
+		}
+	}
+	return
+}
+
+// PartialSignTx returns the partial responses of the signer for p from the nonces of all the signers
+func (a *MultisigAccount) PartialSignTx(p *MultisigTxProposal, nonces []*MultisigTxNonces) (partial *MultisigTxPartial, err error) {
+	signers, _, err := a.check(p)
+	if err != nil {
+		return
+	}
+	h, err := p.hash()
+	if err != nil {
+		return
+	}
+	secrets, ok := a.nonces[h]
+	if !ok {
+		err = err_msg.ErrCLSAGNonce
+		return
+	}
+	// secret nonces must never sign twice
+	delete(a.nonces, h)
+	m := p.Tx.Message()
+	all, err := inputNonces(p, nonces)
+	if err != nil {
+		return
+	}
+	share := a.share(signers)
+	partial = &MultisigTxPartial{Signer: a.K}
+	for n, in := range p.Inputs {
+		P, C := ringKeys(in.Ring)
+		var s *crypto.Scalar
+		if s, err = in.CLSAG.PartialSign(m, P, C, p.Tx.PseudoOuts[n], all[n], secrets[n], share); err != nil {
+			partial = nil
+			return
+		}
+		partial.Responses = append(partial.Responses, s)
+	}
+	return
+}
+
+// CombineTx returns the transaction of p signed with the nonces and partial responses of all the signers, and records
+// its private keys
+func (a *MultisigAccount) CombineTx(p *MultisigTxProposal, nonces []*MultisigTxNonces, partials []*MultisigTxPartial) (tx *RingCTTx, err error) {
+	if _, _, err = a.check(p); err != nil {
+		return
+	}
+	all, err := inputNonces(p, nonces)
+	if err != nil {
+		return
+	}
+	responses := make([][]*crypto.Scalar, len(p.Inputs))
+	for _, K := range p.Signers {
+		found := false
+		for _, partial := range partials {
+			if partial == nil || partial.Signer == nil || partial.Signer.Equal(K) == 0 {
+				continue
+			}
+			if found || len(partial.Responses) != len(p.Inputs) {
+				err = err_msg.ErrMultisigCLSAG
+				return
+			}
+			found = true
+			for i, s := range partial.Responses {
+				responses[i] = append(responses[i], s)
+			}
+		}
+		if !found {
+			err = err_msg.ErrMultisigSigners
+			return
+		}
+	}
+	signed := *p.Tx
+	signed.CLSAGs = nil
+	m := p.Tx.Message()
+	for n, in := range p.Inputs {
+		P, C := ringKeys(in.Ring)
+		var sig *crypto.CLSAG
+		x := a.wallet.outputViewSecret(in.Output)
+		if sig, err = in.CLSAG.Combine(m, P, C, p.Tx.PseudoOuts[n], all[n], responses[n], x, in.Z); err != nil {
+			return
+		}
+		signed.CLSAGs = append(signed.CLSAGs, sig)
+	}
+	if err = a.wallet.txKeys.Add(signed.Hash(), p.TxKey, p.AdditionalTxKeys); err != nil {
+		return
+	}
+	tx = &signed
+	return
+}
@@ -0,0 +1,223 @@
+package wallet
+
+import (
+	"encoding/json"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"testing"
+)
+
+// jsonCopy returns v encoded and decoded with encoding/json, as exchanged between signers
+func jsonCopy(t *testing.T, v, r interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(data, r); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestMultisig returns the accounts of a threshold of signers multisig wallet after the key exchange
+func newTestMultisig(t *testing.T, threshold, signers int) (accounts []*MultisigAccount) {
+	var msgs []*MultisigKexMessage
+	for n := 0; n < signers; n++ {
+		a, msg, err := NewMultisigAccount(NewWallet(), threshold, signers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		accounts, msgs = append(accounts, a), append(msgs, msg)
+	}
+	for round := 1; round <= signers-threshold+1; round++ {
+		var next []*MultisigKexMessage
+		for _, a := range accounts {
+			var received []*MultisigKexMessage
+			jsonCopy(t, msgs, &received)
+			msg, err := a.ProcessKexMessages(received)
+			if err != nil {
+				t.Fatalf("round %d: %v", round, err)
+			}
+			if (msg == nil) != a.IsReady() {
+				t.Fatalf("round %d: want a message until the key exchange is complete", round)
+			}
+			next = append(next, msg)
+		}
+		msgs = next
+	}
+	return
+}
+
+// multisigSpendKey returns the private spend key shared by accounts
+func multisigSpendKey(accounts []*MultisigAccount) (ks *crypto.Scalar) {
+	ks = crypto.ScalarZero()
+	for g, c := range accounts[0].coefficients {
+		for _, a := range accounts {
+			if k, ok := a.private[g]; ok {
+				ks = ks.Add(c.Multiply(k))
+				break
+			}
+		}
+	}
+	return
+}
+
+func TestMultisig(t *testing.T) {
+	for _, test := range []struct {
+		threshold, signers int
+	}{
+		{2, 3},
+		{3, 3},
+		{2, 4},
+	} {
+		accounts := newTestMultisig(t, test.threshold, test.signers)
+		w := accounts[0].Wallet()
+		for _, a := range accounts {
+			if !a.IsReady() || a.Wallet().address.Ks.Equal(w.address.Ks) == 0 || a.Wallet().kv.Equal(w.kv) == 0 {
+				t.Fatalf("%d of %d: signers do not agree on the keys", test.threshold, test.signers)
+			}
+		}
+		ks := multisigSpendKey(accounts)
+		if ks.MultG().Equal(w.address.Ks) == 0 {
+			t.Fatalf("%d of %d: spend key is not the sum of the group keys", test.threshold, test.signers)
+		}
+
+		sources := newTestSources(t, w, 3000, 5000)
+		var infos []*MultisigInfo
+		for _, a := range accounts {
+			for _, s := range sources {
+				if err := a.Wallet().AddOutput(s.Output); err != nil {
+					t.Fatal(err)
+				}
+			}
+			info, err := a.ExportMultisigInfo()
+			if err != nil {
+				t.Fatal(err)
+			}
+			infos = append(infos, info)
+		}
+		for _, a := range accounts {
+			var received []*MultisigInfo
+			jsonCopy(t, infos, &received)
+			if n, err := a.ImportMultisigInfo(received); err != nil || n != len(sources) {
+				t.Fatalf("%d of %d: want %d key images, got %d: %v", test.threshold, test.signers, len(sources), n, err)
+			}
+		}
+		for n, o := range w.OutputStore().Outputs() {
+			sources[n].Output.KeyImage = ks.Add(w.outputViewSecret(sources[n].Output)).KeyImage()
+			if o.KeyImage.Equal(sources[n].Output.KeyImage) == 0 {
+				t.Errorf("%d of %d: wrong key image", test.threshold, test.signers)
+			}
+		}
+
+		// the last signers sign a proposal of the first one
+		signing := accounts[len(accounts)-test.threshold:]
+		var signers []*crypto.PublicKey
+		for _, a := range signing {
+			signers = append(signers, a.K)
+		}
+		other := NewWallet()
+		destinations := []TxDestination{{Address: &other.address, Amount: 1000}}
+		proposal, err := accounts[0].ProposeTx(destinations, sources, 100, SubAddressIndex{}, signers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the signers rebuild the outputs and refuse a proposal paying someone else
+		attacker := NewWallet()
+		tampered := new(MultisigTxProposal)
+		jsonCopy(t, proposal, tampered)
+		for n := range tampered.Payments {
+			if n != tampered.Change {
+				tampered.Payments[n].SpendKey, tampered.Payments[n].ViewKey = attacker.address.Ks, attacker.address.Kv
+			}
+		}
+		if _, err = signing[0].NewTxNonces(tampered, nil); err != err_msg.ErrTxSemantics {
+			t.Errorf("%d of %d: tampered payments, want %v, got %v", test.threshold, test.signers, err_msg.ErrTxSemantics, err)
+		}
+		jsonCopy(t, proposal, tampered)
+		tampered.Tx.Outputs[0].Ko = attacker.address.Ks
+		if _, err = signing[0].NewTxNonces(tampered, nil); err != err_msg.ErrTxSemantics {
+			t.Errorf("%d of %d: tampered output, want %v, got %v", test.threshold, test.signers, err_msg.ErrTxSemantics, err)
+		}
+		reject := func(s *TxSummary) bool { return false }
+		if _, err = signing[0].NewTxNonces(proposal, reject); err != err_msg.ErrTxRejected {
+			t.Errorf("%d of %d: want %v, got %v", test.threshold, test.signers, err_msg.ErrTxRejected, err)
+		}
+
+		var nonces []*MultisigTxNonces
+		for _, a := range signing {
+			p := new(MultisigTxProposal)
+			jsonCopy(t, proposal, p)
+			n, err := a.NewTxNonces(p, func(s *TxSummary) bool {
+				return len(s.Destinations) == 1 && s.Destinations[0].Amount == 1000 &&
+					s.Destinations[0].Address.SpendKey().Equal(other.address.Ks) == 1 && s.Fee == 100
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			nonces = append(nonces, n)
+		}
+		// the nonces only sign the proposal they were created for, not one with other decoy responses
+		modified := new(MultisigTxProposal)
+		jsonCopy(t, proposal, modified)
+		ms := modified.Inputs[0].CLSAG
+		ms.S[(ms.L+1)%len(ms.S)] = crypto.NewRandomScalar()
+		if _, err = signing[0].PartialSignTx(modified, nonces); err != err_msg.ErrCLSAGNonce {
+			t.Errorf("%d of %d: modified decoys, want %v, got %v", test.threshold, test.signers, err_msg.ErrCLSAGNonce, err)
+		}
+
+		var partials []*MultisigTxPartial
+		for _, a := range signing {
+			partial, err := a.PartialSignTx(proposal, nonces)
+			if err != nil {
+				t.Fatal(err)
+			}
+			partials = append(partials, partial)
+			if _, err = a.PartialSignTx(proposal, nonces); err != err_msg.ErrCLSAGNonce {
+				t.Errorf("%d of %d: nonces reused, want %v, got %v", test.threshold, test.signers, err_msg.ErrCLSAGNonce, err)
+			}
+		}
+		tx, err := accounts[0].CombineTx(proposal, nonces, partials)
+		if err != nil {
+			t.Fatalf("%d of %d: %v", test.threshold, test.signers, err)
+		}
+		if err = tx.Verify(sourceRings(tx, sources)); err != nil {
+			t.Errorf("%d of %d: %v", test.threshold, test.signers, err)
+		}
+		if got := receivedAmount(t, other, tx); got != 1000 {
+			t.Errorf("%d of %d: want 1000 received, got %d", test.threshold, test.signers, got)
+		}
+		if r, _, ok := accounts[0].Wallet().TxKey(tx.Hash()); !ok || r.Equal(proposal.TxKey) == 0 {
+			t.Errorf("%d of %d: transaction keys not recorded", test.threshold, test.signers)
+		}
+		if _, err = accounts[0].CombineTx(proposal, nonces, partials[1:]); err != err_msg.ErrMultisigSigners {
+			t.Errorf("%d of %d: want %v, got %v", test.threshold, test.signers, err_msg.ErrMultisigSigners, err)
+		}
+	}
+}
+
+func TestMultisigKexErrors(t *testing.T) {
+	if _, _, err := NewMultisigAccount(NewWallet(), 3, 2); err != err_msg.ErrMultisigParameters {
+		t.Errorf("want %v, got %v", err_msg.ErrMultisigParameters, err)
+	}
+	a, msgA, _ := NewMultisigAccount(NewWallet(), 2, 3)
+	_, msgB, _ := NewMultisigAccount(NewWallet(), 2, 3)
+	_, msgC, _ := NewMultisigAccount(NewWallet(), 2, 3)
+	if _, err := a.ProcessKexMessages([]*MultisigKexMessage{msgA, msgB}); err != err_msg.ErrMultisigSigners {
+		t.Errorf("missing signer: want %v, got %v", err_msg.ErrMultisigSigners, err)
+	}
+	forged := *msgC
+	forged.ViewKey = crypto.NewRandomScalar()
+	if _, err := a.ProcessKexMessages([]*MultisigKexMessage{msgB, &forged}); err != err_msg.ErrMultisigMessage {
+		t.Errorf("forged message: want %v, got %v", err_msg.ErrMultisigMessage, err)
+	}
+	next, err := a.ProcessKexMessages([]*MultisigKexMessage{msgA, msgB, msgC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.ProcessKexMessages([]*MultisigKexMessage{msgB, msgC}); err != err_msg.ErrMultisigRound {
+		t.Errorf("replayed round: want %v, got %v", err_msg.ErrMultisigRound, err)
+	}
+	if _, err = a.ExportMultisigInfo(); err != err_msg.ErrMultisigNotReady || next == nil {
+		t.Errorf("want %v, got %v", err_msg.ErrMultisigNotReady, err)
+	}
+}
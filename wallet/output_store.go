@@ -0,0 +1,360 @@
+package wallet
+
+import (
+	"encoding/json"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// monero/src/cryptonote_config.h
+const (
+	SpendableAge         = 10        //CRYPTONOTE_DEFAULT_TX_SPENDABLE_AGE
+	maxBlockNumber       = 500000000 //CRYPTONOTE_MAX_BLOCK_NUMBER, larger unlock times are unix timestamps
+	lockedTxDeltaBlocks  = 1         //CRYPTONOTE_LOCKED_TX_ALLOWED_DELTA_BLOCKS
+	lockedTxDeltaSeconds = 120       //CRYPTONOTE_LOCKED_TX_ALLOWED_DELTA_SECONDS_V2
+)
+
+// Output is an output received by the wallet
+type Output struct {
+	TxHash      crypto.Hash
+	Index       uint64 //index of the output in the transaction
+	GlobalIndex uint64 //index of the output in the blockchain
+	Height      uint64 //height of the block containing the transaction
+	UnlockTime  uint64
+	SubAddress  SubAddressIndex
+	Ko          *crypto.PublicKey //one time address
+	TxPublicKey *crypto.PublicKey
+	KeyImage    *crypto.PublicKey
+	Amount      uint64
+	Mask        *crypto.Scalar //commitment mask
+	Spent       bool
+	SpentHeight uint64
+	SpentTxHash crypto.Hash
+}
+
+// IsUnlocked returns true if the output can be spent in a block at height, when the blockchain has height blocks
+//
+// monero/src/wallet/wallet2.cpp is_transfer_unlocked, is_tx_spendtime_unlocked
+func (o *Output) IsUnlocked(height uint64, now time.Time) (r bool) {
+	if o.Height+SpendableAge > height {
+		return
+	}
+	if o.UnlockTime < maxBlockNumber {
+		r = height-1+lockedTxDeltaBlocks >= o.UnlockTime
+		return
+	}
+	r = uint64(now.Unix())+lockedTxDeltaSeconds >= o.UnlockTime
+	return
+}
+
+// OutputStore records the outputs received by a wallet and their spend status
+type OutputStore interface {
+	// Add records a received output, outputs are identified by their key image
+	Add(o *Output) error
+	// MarkSpent records that the output with key image KI was spent in txHash at height
+	MarkSpent(KI *crypto.PublicKey, height uint64, txHash crypto.Hash) error
+	// Outputs returns copies of all recorded outputs in the order they were added
+	Outputs() []*Output
+	// Rollback forgets everything that happened in blocks at height or above, used to handle reorgs
+	Rollback(height uint64) error
+	// Balance returns the sum of the unspent outputs
+	Balance() uint64
+	// UnlockedBalance returns the sum of the unspent outputs which are unlocked at height
+	UnlockedBalance(height uint64) uint64
+	// AddPending records an output of a view only wallet waiting for its key image, pending outputs are identified by
+	// their one time address
+	AddPending(o *Output) error
+	// Pending returns copies of the outputs waiting for their key images
+	Pending() []*Output
+	// SetKeyImage records the key image KI of the pending output with the one time address Ko, which is then an
+	// output like those of Add
+	SetKeyImage(Ko, KI *crypto.PublicKey) error
+}
+
+// MemoryOutputStore is an OutputStore which is held in memory, it is safe for concurrent use
+type MemoryOutputStore struct {
+	mu        sync.RWMutex
+	outputs   []*Output
+	keyImages map[[32]byte]*Output
+	pending   []*Output //outputs without key image
+}
+
+func NewMemoryOutputStore() (s *MemoryOutputStore) {
+	s = new(MemoryOutputStore)
+	s.keyImages = make(map[[32]byte]*Output)
+	return
+}
+
+func (s *MemoryOutputStore) Add(o *Output) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = s.add(o)
+	return
+}
+
+func (s *MemoryOutputStore) add(o *Output) (err error) {
+	if o.KeyImage == nil {
+		err = err_msg.ErrKeyImage
+		return
+	}
+	KI := o.KeyImage.Byte32()
+	if _, ok := s.keyImages[KI]; ok {
+		err = err_msg.ErrDuplicateOutput
+		return
+	}
+	c := *o
+	s.outputs = append(s.outputs, &c)
+	s.keyImages[KI] = &c
+	return
+}
+
+func (s *MemoryOutputStore) AddPending(o *Output) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = s.addPending(o)
+	return
+}
+
+func (s *MemoryOutputStore) addPending(o *Output) (err error) {
+	if o.Ko == nil || o.KeyImage != nil {
+		err = err_msg.ErrPendingOutput
+		return
+	}
+	for _, outputs := range [][]*Output{s.pending, s.outputs} {
+		for _, p := range outputs {
+			if p.Ko != nil && p.Ko.Equal(o.Ko) == 1 {
+				err = err_msg.ErrDuplicateOutput
+				return
+			}
+		}
+	}
+	c := *o
+	s.pending = append(s.pending, &c)
+	return
+}
+
+func (s *MemoryOutputStore) Pending() (r []*Output) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r = make([]*Output, len(s.pending))
+	for i, o := range s.pending {
+		c := *o
+		r[i] = &c
+	}
+	return
+}
+
+func (s *MemoryOutputStore) SetKeyImage(Ko, KI *crypto.PublicKey) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = s.setKeyImage(Ko, KI)
+	return
+}
+
+// setKeyImage moves the pending output with the one time address Ko to the outputs, the store is unchanged on error
+func (s *MemoryOutputStore) setKeyImage(Ko, KI *crypto.PublicKey) (err error) {
+	for i, o := range s.pending {
+		if o.Ko.Equal(Ko) == 0 {
+			continue
+		}
+		c := *o
+		c.KeyImage = KI
+		if err = s.add(&c); err != nil {
+			return
+		}
+		s.pending = append(s.pending[:i:i], s.pending[i+1:]...)
+		return
+	}
+	err = err_msg.ErrPendingOutput
+	return
+}
+
+func (s *MemoryOutputStore) MarkSpent(KI *crypto.PublicKey, height uint64, txHash crypto.Hash) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err = s.markSpent(KI, height, txHash)
+	return
+}
+
+func (s *MemoryOutputStore) markSpent(KI *crypto.PublicKey, height uint64, txHash crypto.Hash) (err error) {
+	o, ok := s.keyImages[KI.Byte32()]
+	if !ok {
+		err = err_msg.ErrKeyImage
+		return
+	}
+	o.Spent = true
+	o.SpentHeight = height
+	o.SpentTxHash = txHash
+	return
+}
+
+func (s *MemoryOutputStore) Outputs() (r []*Output) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r = make([]*Output, len(s.outputs))
+	for i, o := range s.outputs {
+		c := *o
+		r[i] = &c
+	}
+	return
+}
+
+func (s *MemoryOutputStore) Rollback(height uint64) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollback(height)
+	return
+}
+
+func (s *MemoryOutputStore) rollback(height uint64) {
+	kept := s.outputs[:0]
+	for _, o := range s.outputs {
+		if o.Height >= height {
+			delete(s.keyImages, o.KeyImage.Byte32())
+			continue
+		}
+		if o.Spent && o.SpentHeight >= height {
+			o.Spent = false
+			o.SpentHeight = 0
+			o.SpentTxHash = crypto.Hash{}
+		}
+		kept = append(kept, o)
+	}
+	for i := len(kept); i < len(s.outputs); i++ {
+		s.outputs[i] = nil
+	}
+	s.outputs = kept
+
+	var pending []*Output
+	for _, o := range s.pending {
+		if o.Height < height {
+			pending = append(pending, o)
+		}
+	}
+	s.pending = pending
+}
+
+func (s *MemoryOutputStore) Balance() (r uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, o := range s.outputs {
+		if !o.Spent {
+			r += o.Amount
+		}
+	}
+	return
+}
+
+func (s *MemoryOutputStore) UnlockedBalance(height uint64) (r uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	for _, o := range s.outputs {
+		if !o.Spent && o.IsUnlocked(height, now) {
+			r += o.Amount
+		}
+	}
+	return
+}
+
+// FileOutputStore is a MemoryOutputStore which is written to a json file after every change, the pending outputs are
+// written with the others without key image
+type FileOutputStore struct {
+	MemoryOutputStore
+	path string
+}
+
+// NewFileOutputStore opens the output store at path, the file is created on the first change if it does not exist
+func NewFileOutputStore(path string) (s *FileOutputStore, err error) {
+	s = &FileOutputStore{path: path}
+	s.keyImages = make(map[[32]byte]*Output)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		s = nil
+		return
+	}
+	var outputs []*Output
+	if err = json.Unmarshal(data, &outputs); err != nil {
+		s = nil
+		return
+	}
+	for _, o := range outputs {
+		if o.KeyImage == nil {
+			err = s.addPending(o)
+		} else {
+			err = s.add(o)
+		}
+		if err != nil {
+			s = nil
+			return
+		}
+	}
+	return
+}
+
+func (s *FileOutputStore) Add(o *Output) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = s.add(o); err != nil {
+		return
+	}
+	err = s.save()
+	return
+}
+
+func (s *FileOutputStore) AddPending(o *Output) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = s.addPending(o); err != nil {
+		return
+	}
+	err = s.save()
+	return
+}
+
+func (s *FileOutputStore) SetKeyImage(Ko, KI *crypto.PublicKey) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = s.setKeyImage(Ko, KI); err != nil {
+		return
+	}
+	err = s.save()
+	return
+}
+
+func (s *FileOutputStore) MarkSpent(KI *crypto.PublicKey, height uint64, txHash crypto.Hash) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = s.markSpent(KI, height, txHash); err != nil {
+		return
+	}
+	err = s.save()
+	return
+}
+
+func (s *FileOutputStore) Rollback(height uint64) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rollback(height)
+	err = s.save()
+	return
+}
+
+// save writes the outputs to the file, the caller must hold the lock
+func (s *FileOutputStore) save() (err error) {
+	outputs := append(append([]*Output{}, s.outputs...), s.pending...)
+	data, err := json.Marshal(outputs)
+	if err != nil {
+		return
+	}
+	err = writeFileAtomic(s.path, data)
+	return
+}
@@ -0,0 +1,246 @@
+package wallet
+
+import (
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestOutput(height, amount uint64) (o *Output) {
+	o = &Output{
+		Height:   height,
+		Amount:   amount,
+		KeyImage: crypto.NewRandomScalar().MultG(),
+	}
+	return
+}
+
+func testOutputStore(t *testing.T, s OutputStore) {
+	a := newTestOutput(100, 1)
+	b := newTestOutput(105, 2)
+	c := newTestOutput(110, 4)
+	for _, o := range []*Output{a, b, c} {
+		if err := s.Add(o); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if err := s.Add(a); err == nil {
+		t.Errorf("Add accepted a duplicate key image")
+	}
+	if got := s.Balance(); got != 7 {
+		t.Errorf("Balance: want 7, got %v", got)
+	}
+	if got := s.UnlockedBalance(115); got != 3 {
+		t.Errorf("UnlockedBalance: want 3, got %v", got)
+	}
+
+	txHash := crypto.Keccak256([]byte("spend"))
+	if err := s.MarkSpent(b.KeyImage, 112, txHash); err != nil {
+		t.Fatalf("MarkSpent failed: %v", err)
+	}
+	if err := s.MarkSpent(crypto.NewRandomScalar().MultG(), 112, txHash); err == nil {
+		t.Errorf("MarkSpent accepted an unknown key image")
+	}
+	if got := s.Balance(); got != 5 {
+		t.Errorf("Balance after spend: want 5, got %v", got)
+	}
+	outputs := s.Outputs()
+	if len(outputs) != 3 || !outputs[1].Spent || outputs[1].SpentHeight != 112 || outputs[1].SpentTxHash != txHash {
+		t.Errorf("Outputs: spend not recorded")
+	}
+
+	// reorg of blocks 110 and above
+	if err := s.Rollback(110); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	outputs = s.Outputs()
+	if len(outputs) != 2 || outputs[1].Spent {
+		t.Errorf("Rollback: want 2 unspent outputs, got %v", outputs)
+	}
+	if got := s.Balance(); got != 3 {
+		t.Errorf("Balance after rollback: want 3, got %v", got)
+	}
+	if err := s.Add(c); err != nil {
+		t.Errorf("Add after rollback failed: %v", err)
+	}
+}
+
+func TestMemoryOutputStore(t *testing.T) {
+	testOutputStore(t, NewMemoryOutputStore())
+}
+
+func TestFileOutputStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outputs")
+	s, err := NewFileOutputStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testOutputStore(t, s)
+
+	reopened, err := NewFileOutputStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, got := s.Outputs(), reopened.Outputs()
+	if len(want) != len(got) {
+		t.Fatalf("reopened store: want %v outputs, got %v", len(want), len(got))
+	}
+	for i := range want {
+		if want[i].KeyImage.Equal(got[i].KeyImage) == 0 || want[i].Amount != got[i].Amount || want[i].Height != got[i].Height {
+			t.Errorf("reopened store: output %v differs", i)
+		}
+	}
+}
+
+func TestPendingOutputs(t *testing.T) {
+	file, err := NewFileOutputStore(filepath.Join(t.TempDir(), "outputs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []OutputStore{NewMemoryOutputStore(), file} {
+		a, b := newTestOutput(100, 1), newTestOutput(110, 2)
+		KI := a.KeyImage
+		for _, o := range []*Output{a, b} {
+			o.Ko, o.KeyImage = crypto.NewRandomScalar().MultG(), nil
+			if err = s.AddPending(o); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = s.AddPending(a); err != err_msg.ErrDuplicateOutput {
+			t.Errorf("want %v, got %v", err_msg.ErrDuplicateOutput, err)
+		}
+		if s.Balance() != 0 || len(s.Pending()) != 2 {
+			t.Errorf("pending outputs counted in the balance")
+		}
+		if err = s.SetKeyImage(crypto.NewRandomScalar().MultG(), KI); err != err_msg.ErrPendingOutput {
+			t.Errorf("want %v, got %v", err_msg.ErrPendingOutput, err)
+		}
+		if err = s.SetKeyImage(a.Ko, KI); err != nil {
+			t.Fatal(err)
+		}
+		if s.Balance() != 1 || len(s.Pending()) != 1 || s.Pending()[0].Ko.Equal(b.Ko) == 0 {
+			t.Errorf("output not moved out of the pending outputs")
+		}
+		if err = s.Rollback(110); err != nil {
+			t.Fatal(err)
+		}
+		if len(s.Pending()) != 0 {
+			t.Errorf("rollback kept a pending output")
+		}
+	}
+}
+
+func TestFileOutputStoreConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outputs")
+	s, err := NewFileOutputStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputs []*Output
+	for i := uint64(0); i < 16; i++ {
+		outputs = append(outputs, newTestOutput(i, 1))
+		if err = s.Add(outputs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// every save holds the spends of the previous ones
+	done := make(chan error)
+	for _, o := range outputs {
+		go func(o *Output) {
+			done <- s.MarkSpent(o.KeyImage, 20, crypto.Hash{})
+		}(o)
+	}
+	for range outputs {
+		if err = <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	reopened, err := NewFileOutputStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Balance(); got != 0 {
+		t.Errorf("want all outputs spent in the file, got a balance of %v", got)
+	}
+}
+
+func TestOutputIsUnlocked(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	tests := []struct {
+		name       string
+		height     uint64
+		unlockTime uint64
+		chain      uint64
+		want       bool
+	}{
+		{"too young", 100, 0, 109, false},
+		{"spendable age", 100, 0, 110, true},
+		{"height lock", 100, 200, 150, false},
+		{"height lock expired", 100, 200, 200, true},
+		{"time lock", 100, 1600001000, 150, false},
+		{"time lock leeway", 100, 1600000100, 150, true},
+	}
+	for _, tt := range tests {
+		o := &Output{Height: tt.height, UnlockTime: tt.unlockTime}
+		if got := o.IsUnlocked(tt.chain, now); got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestWalletAddOutput(t *testing.T) {
+	w := NewWallet()
+	index := SubAddressIndex{Major: 1, Minor: 2}
+	Ko, Ke, _, _ := w.SubAddress(index).OneTimeAddress(1)
+	o := &Output{Ko: Ko, TxPublicKey: Ke, Index: 1, SubAddress: index, Amount: 5}
+	if err := w.AddOutput(o); err != nil {
+		t.Fatalf("AddOutput failed: %v", err)
+	}
+	ko, _ := w.SubaddressOutputPrivateKey(Ke, 1, index)
+	KI := ko.KeyImage()
+	if err := w.MarkSpent(KI, 10, crypto.Hash{}); err != nil {
+		t.Errorf("MarkSpent with the expected key image failed: %v", err)
+	}
+	o.SubAddress = SubAddressIndex{Major: 1, Minor: 3}
+	if err := w.AddOutput(o); err == nil {
+		t.Errorf("AddOutput accepted an output for the wrong subaddress")
+	}
+}
+
+func TestFileTxKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tx_keys")
+	s, err := NewFileTxKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := crypto.Hash{1}, crypto.Hash{2}
+	r := crypto.NewRandomScalar()
+	additional := []*crypto.PrivateKey{crypto.NewRandomScalar(), crypto.NewRandomScalar()}
+	if err = s.Add(a, crypto.NewRandomScalar(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Add(b, crypto.NewRandomScalar(), nil); err != nil {
+		t.Fatal(err)
+	}
+	// the keys of a transaction are replaced
+	if err = s.Add(a, r, additional); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileTxKeyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotR, gotAdditional, ok := reopened.Get(a)
+	if !ok || gotR.Equal(r) == 0 || len(gotAdditional) != 2 || gotAdditional[1].Equal(additional[1]) == 0 {
+		t.Error("reopened store: wrong keys")
+	}
+	if _, gotAdditional, ok = reopened.Get(b); !ok || gotAdditional != nil {
+		t.Error("reopened store: wrong keys without additional keys")
+	}
+	if _, _, ok = reopened.Get(crypto.Hash{3}); ok {
+		t.Error("unknown transaction found")
+	}
+}
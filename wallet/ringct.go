@@ -0,0 +1,96 @@
+package wallet
+
+import (
+	"encoding/binary"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// RingCT amount encryption
+//monero/src/ringct/rctOps.cpp ecdhEncode, ecdhDecode, genCommitmentMask
+//
+// the amount key is the scalar Hs(Kss || varint(outputIndex)) which is also used to derive the one time address of the output
+
+// EcdhInfo is the encrypted amount of a RingCT output (rct::ecdhTuple)
+type EcdhInfo struct {
+	Mask    [32]byte //encrypted commitment mask, unused in the compact format
+	Amount  [32]byte //encrypted amount, the compact format only uses the first 8 bytes
+	Compact bool     //v2 format used by RCTTypeBulletproof2 and later, the mask is derived from the amount key
+}
+
+// CommitmentMask returns the commitment mask of the compact format, Hs("commitment_mask" || amountKey)
+func CommitmentMask(amountKey *crypto.Scalar) (mask *crypto.Scalar) {
+	mask = crypto.HashToScalar([]byte("commitment_mask"), amountKey.Bytes())
+	return
+}
+
+// amountEncodingFactor returns the 8 bytes the amount is xored with in the compact format
+func amountEncodingFactor(amountKey *crypto.Scalar) (r [8]byte) {
+	r = crypto.Hash64([]byte("amount"), amountKey.Bytes())
+	return
+}
+
+// EncodeEcdhInfo encrypts amount and mask for the holder of amountKey, mask is ignored in the compact format
+func EncodeEcdhInfo(amountKey *crypto.Scalar, amount uint64, mask *crypto.Scalar, compact bool) (e *EcdhInfo, err error) {
+	e = &EcdhInfo{Compact: compact}
+	var a amount64
+	binary.LittleEndian.PutUint64(a[:], amount)
+	if compact {
+		factor := amountEncodingFactor(amountKey)
+		if a, err = a.XOR(factor[:]); err != nil {
+			e = nil
+			return
+		}
+		copy(e.Amount[:], a[:])
+		return
+	}
+	// mask + Hs(amountKey), amount + Hs(Hs(amountKey))
+	sharedSec1 := crypto.HashToScalar(amountKey.Bytes())
+	sharedSec2 := crypto.HashToScalar(sharedSec1.Bytes())
+	copy(e.Mask[:], mask.Add(sharedSec1).Bytes())
+	copy(e.Amount[:], a.Scalar().Add(sharedSec2).Bytes())
+	return
+}
+
+// DecodeEcdhInfo decrypts the amount and commitment mask of an output with commitment C
+// err_msg.ErrJanus is returned if they do not open C
+func DecodeEcdhInfo(amountKey *crypto.Scalar, e *EcdhInfo, C *crypto.PublicKey) (amount uint64, mask *crypto.Scalar, err error) {
+	var a amount64
+	if e.Compact {
+		factor := amountEncodingFactor(amountKey)
+		copy(a[:], e.Amount[:8])
+		if a, err = a.XOR(factor[:]); err != nil {
+			return
+		}
+		mask = CommitmentMask(amountKey)
+	} else {
+		maskedMask := crypto.NewScalarFromBytes(e.Mask[:])
+		maskedAmount := crypto.NewScalarFromBytes(e.Amount[:])
+		if maskedMask.Err != nil || maskedAmount.Err != nil {
+			err = err_msg.ErrEcdhInfo
+			return
+		}
+		sharedSec1 := crypto.HashToScalar(amountKey.Bytes())
+		sharedSec2 := crypto.HashToScalar(sharedSec1.Bytes())
+		mask = maskedMask.Subtract(sharedSec1)
+		// amounts larger than 64 bits can not open the commitment below
+		copy(a[:], maskedAmount.Subtract(sharedSec2).Bytes())
+	}
+
+	if mask.DoubleScalarBaseMult(a.Scalar(), crypto.PointH()).Equal(C) == 0 {
+		err = err_msg.ErrJanus
+		mask = nil
+		return
+	}
+	amount = binary.LittleEndian.Uint64(a[:])
+	return
+}
+
+// DecodeAmount decrypts the amount of output outputIndex of a transaction with public key Ke recognized by
+// ScanOutputForStandardAddress or ScanOutputForSubAddress
+func (w *Wallet) DecodeAmount(Ke *crypto.PublicKey, outputIndex uint64, e *EcdhInfo, C *crypto.PublicKey) (amount uint64, mask *crypto.Scalar, err error) {
+	//kv * Ke * 8 = Kss = shared secret
+	Kss := crypto.GenerateKeyDerivation(Ke, w.kv)
+	amount, mask, err = DecodeEcdhInfo(Kss.DerivationToScalar(outputIndex), e, C)
+	return
+}
@@ -0,0 +1,335 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"math/big"
+	"sort"
+)
+
+// TxSource is an output of the wallet spent with a ring of decoys
+type TxSource struct {
+	Output *Output
+	Ring   []RingMember //sorted by global index, contains the output
+}
+
+// TxDestination is an amount paid to an address
+type TxDestination struct {
+	Address address.Destination //*address.StandardAddress, *address.Subaddress or *address.IntegratedAddress
+	Amount  uint64
+}
+
+// TxSummary is what a transaction pays, it is shown to the holder of the spend key before signing
+type TxSummary struct {
+	Destinations []TxDestination
+	InputAmount  uint64
+	Fee          uint64
+	Change       SubAddressIndex //subaddress of the wallet receiving the change
+	ChangeAmount uint64
+}
+
+// TxAcceptFunc returns true if the transaction of s may be signed
+type TxAcceptFunc func(s *TxSummary) bool
+
+// newTxSummary returns the summary of a transaction spending sources to pay destinations and fee
+func newTxSummary(destinations []TxDestination, sources []TxSource, fee uint64, change SubAddressIndex) (s *TxSummary, err error) {
+	s = &TxSummary{Destinations: append([]TxDestination{}, destinations...), Fee: fee, Change: change}
+	for _, source := range sources {
+		if source.Output == nil || s.InputAmount+source.Output.Amount < s.InputAmount {
+			s, err = nil, err_msg.ErrInsufficientFunds
+			return
+		}
+		s.InputAmount += source.Output.Amount
+	}
+	outputAmount := fee
+	for _, d := range destinations {
+		if outputAmount+d.Amount < outputAmount {
+			s, err = nil, err_msg.ErrInsufficientFunds
+			return
+		}
+		outputAmount += d.Amount
+	}
+	if s.InputAmount < outputAmount {
+		s, err = nil, err_msg.ErrInsufficientFunds
+		return
+	}
+	s.ChangeAmount = s.InputAmount - outputAmount
+	return
+}
+
+// checkTxFee returns ErrTxFee if fee is above the fee of EstimateFee at maxBaseFee for a transaction spending sources
+// to pay destinations
+func checkTxFee(destinations []TxDestination, sources []TxSource, fee uint64, change SubAddressIndex, maxBaseFee uint64) (err error) {
+	ringSize := 0
+	for _, source := range sources {
+		if len(source.Ring) > ringSize {
+			ringSize = len(source.Ring)
+		}
+	}
+	if fee > EstimateFee(destinations, change, len(sources), ringSize, maxBaseFee) {
+		err = err_msg.ErrTxFee
+	}
+	return
+}
+
+// ringCTInput is a source being signed
+type ringCTInput struct {
+	source     TxSource
+	l          int            //index of the output in the ring
+	ko         *crypto.Scalar //private key of the one time address, nil for view only wallets
+	mask       *crypto.Scalar //commitment mask of the output
+	keyImage   *crypto.PublicKey
+	pseudoMask *crypto.Scalar
+}
+
+// BuildTx returns a signed transaction spending sources and paying destinations, the inputs minus the destinations
+// and the fee are paid to the subaddress change of the wallet
+//
+// a transaction with a single destination also pays change, even if it is zero, and carries a dummy encrypted
+// payment id if the destination is not an integrated address, so it can not be told apart from a payment with change
+//
+// monero/src/cryptonote_core/cryptonote_tx_utils.cpp construct_tx_with_tx_key
+// monero/src/wallet/wallet2.cpp transfer_selected_rct
+func (w *Wallet) BuildTx(destinations []TxDestination, sources []TxSource, fee uint64, change SubAddressIndex) (tx *RingCTTx, err error) {
+	if w.IsViewOnly() {
+		err = err_msg.ErrWatchOnly
+		return
+	}
+	tx, inputs, keys, _, _, err := w.buildUnsignedTx(destinations, sources, fee, change)
+	if err != nil {
+		return
+	}
+	m := tx.Message()
+	for n, in := range inputs {
+		P, C := ringKeys(in.source.Ring)
+		var sig *crypto.CLSAG
+		if sig, _, err = crypto.NewCLSAG(m, P, C, tx.PseudoOuts[n], in.l, in.ko, in.mask.Subtract(in.pseudoMask)); err != nil {
+			tx = nil
+			return
+		}
+		tx.CLSAGs = append(tx.CLSAGs, sig)
+	}
+	r, additional := keys.PrivateKeys()
+	if err = w.txKeys.Add(tx.Hash(), r, additional); err != nil {
+		tx = nil
+	}
+	return
+}
+
+// buildUnsignedTx returns the transaction of BuildTx without its CLSAGs, its inputs in transaction order, its keys
+// and the destinations of its outputs, payments[changeIndex] is the change and changeIndex is -1 without change, a
+// view only wallet spends outputs with imported key images
+func (w *Wallet) buildUnsignedTx(destinations []TxDestination, sources []TxSource, fee uint64, change SubAddressIndex) (tx *RingCTTx, inputs []*ringCTInput, keys *address.TxKeys, payments []TxDestination, changeIndex int, err error) {
+	if len(destinations) == 0 {
+		err = err_msg.ErrNoDestinations
+		return
+	}
+	inputs, inputAmount, err := w.ringCTInputs(sources)
+	if err != nil {
+		return
+	}
+	outputAmount := fee
+	var integrated *address.IntegratedAddress
+	for _, d := range destinations {
+		outputAmount += d.Amount
+		if outputAmount < d.Amount {
+			err = err_msg.ErrInsufficientFunds
+			return
+		}
+		if a, ok := d.Address.(*address.IntegratedAddress); ok {
+			if integrated != nil {
+				err = err_msg.ErrPaymentIDs
+				return
+			}
+			integrated = a
+		}
+	}
+	if inputAmount < outputAmount {
+		err = err_msg.ErrInsufficientFunds
+		return
+	}
+
+	payments = append([]TxDestination{}, destinations...)
+	changeIndex = -1
+	if changeAmount := inputAmount - outputAmount; changeAmount > 0 || len(destinations) == 1 {
+		var changeAddress address.Destination = &w.address
+		if change != (SubAddressIndex{}) {
+			changeAddress = w.SubAddress(change)
+		}
+		changeIndex = len(payments)
+		payments = append(payments, TxDestination{Address: changeAddress, Amount: changeAmount})
+	}
+	if len(payments) > crypto.BulletproofPlusMaxValues {
+		err = err_msg.ErrTooManyOutputs
+		return
+	}
+	shuffle(len(payments), func(a, b int) {
+		payments[a], payments[b] = payments[b], payments[a]
+		switch changeIndex {
+		case a:
+			changeIndex = b
+		case b:
+			changeIndex = a
+		}
+	})
+
+	addresses := make([]address.Destination, len(payments))
+	for n, p := range payments {
+		addresses[n] = p.Address
+	}
+	if keys, err = address.NewTxKeys(addresses, changeIndex, w.kv); err != nil {
+		return
+	}
+
+	tx = &RingCTTx{R: keys.R, AdditionalKeys: keys.AdditionalKeys, Fee: fee}
+	masks, err := setTxOutputs(tx, payments, changeIndex, keys)
+	if err != nil {
+		tx = nil
+		return
+	}
+	values := make([]uint64, len(payments))
+	for n, p := range payments {
+		values[n] = p.Amount
+	}
+	if tx.BulletproofPlus, tx.OutPk, err = crypto.NewBulletproofPlus(values, masks); err != nil {
+		tx = nil
+		return
+	}
+
+	// the pseudo output masks sum to the output masks
+	remainder := crypto.ScalarZero()
+	for _, mask := range masks {
+		remainder = remainder.Add(mask)
+	}
+	for n, in := range inputs {
+		if n == len(inputs)-1 {
+			in.pseudoMask = remainder
+		} else {
+			in.pseudoMask = crypto.NewRandomScalar()
+			remainder = remainder.Subtract(in.pseudoMask)
+		}
+		tx.Inputs = append(tx.Inputs, RingCTInput{
+			KeyOffsets: keyOffsets(in.source.Ring),
+			KeyImage:   in.keyImage,
+		})
+		tx.PseudoOuts = append(tx.PseudoOuts, in.pseudoMask.DoubleScalarBaseMult(newAmount64(in.source.Output.Amount).Scalar(), crypto.PointH()))
+	}
+	return
+}
+
+// setTxOutputs sets the outputs, the encrypted amounts and the encrypted payment id of tx paying payments with keys
+// and returns the commitment masks of the outputs, payments[change] is the change of the wallet
+//
+// a transaction with a single destination besides the change carries a dummy encrypted payment id if the destination
+// is not an integrated address
+func setTxOutputs(tx *RingCTTx, payments []TxDestination, change int, keys *address.TxKeys) (masks []*crypto.Scalar, err error) {
+	if len(keys.Outputs) != len(payments) {
+		err = err_msg.ErrMismatchedLengths
+		return
+	}
+	var integrated *address.IntegratedAddress
+	var single address.Destination
+	destinations := 0
+	for n, p := range payments {
+		if n == change {
+			continue
+		}
+		if a, ok := p.Address.(*address.IntegratedAddress); ok && integrated == nil {
+			integrated = a
+		}
+		single = p.Address
+		destinations++
+	}
+	if integrated != nil {
+		encrypted := keys.EncryptPaymentID(integrated.Kv, integrated.PaymentID)
+		tx.EncryptedPaymentID = &encrypted
+	} else if destinations == 1 {
+		encrypted := keys.EncryptPaymentID(single.ViewKey(), [address.PaymentIDLength]byte{})
+		tx.EncryptedPaymentID = &encrypted
+	}
+
+	for n, p := range payments {
+		out := keys.Outputs[n]
+		tx.Outputs = append(tx.Outputs, RingCTOutput{Ko: out.Ko, ViewTag: out.ViewTag})
+		masks = append(masks, CommitmentMask(out.AmountKey))
+		var e *EcdhInfo
+		if e, err = EncodeEcdhInfo(out.AmountKey, p.Amount, masks[n], true); err != nil {
+			masks = nil
+			return
+		}
+		tx.EcdhInfo = append(tx.EcdhInfo, e)
+	}
+	return
+}
+
+// ringCTInputs returns the inputs spending sources sorted by key image, in decreasing order as in the reference
+// implementation, and the amount they spend, the private keys of the inputs are nil for view only wallets
+func (w *Wallet) ringCTInputs(sources []TxSource) (inputs []*ringCTInput, amount uint64, err error) {
+	if len(sources) == 0 {
+		err = err_msg.ErrInsufficientFunds
+		return
+	}
+	for _, s := range sources {
+		in := &ringCTInput{source: s, l: -1, mask: s.Output.Mask}
+		Ko := w.outputPublicKey(s.Output)
+		if w.IsViewOnly() {
+			if s.Output.KeyImage == nil {
+				err = err_msg.ErrWatchOnly
+				return
+			}
+			in.keyImage = s.Output.KeyImage
+		} else {
+			in.ko = w.outputPrivateKey(s.Output)
+			in.keyImage = in.ko.KeyImage()
+		}
+		if in.mask == nil {
+			// outputs without a commitment are spent with the commitment G + amount H
+			in.mask = crypto.ScalarIdentity()
+		}
+		C := in.mask.DoubleScalarBaseMult(newAmount64(s.Output.Amount).Scalar(), crypto.PointH())
+		for n, member := range s.Ring {
+			if n > 0 && member.GlobalIndex <= s.Ring[n-1].GlobalIndex {
+				err = err_msg.ErrTxSemantics
+				return
+			}
+			if member.GlobalIndex == s.Output.GlobalIndex && member.Ko.Equal(s.Output.Ko) == 1 && member.C.Equal(C) == 1 {
+				in.l = n
+			}
+		}
+		if in.l < 0 || Ko.Equal(s.Output.Ko) == 0 {
+			err = err_msg.ErrRingMember
+			return
+		}
+		amount += s.Output.Amount
+		if amount < s.Output.Amount {
+			err = err_msg.ErrInsufficientFunds
+			return
+		}
+		inputs = append(inputs, in)
+	}
+	sort.Slice(inputs, func(a, b int) bool {
+		return bytes.Compare(inputs[a].keyImage.Bytes(), inputs[b].keyImage.Bytes()) > 0
+	})
+	return
+}
+
+// PaymentID returns the payment id of tx paying an integrated address of the wallet
+func (w *Wallet) PaymentID(tx *RingCTTx) (paymentID [address.PaymentIDLength]byte, ok bool) {
+	if tx.EncryptedPaymentID == nil {
+		return
+	}
+	paymentID = address.EncryptPaymentID(crypto.GenerateKeyDerivation(tx.R, w.kv), *tx.EncryptedPaymentID)
+	ok = true
+	return
+}
+
+// shuffle randomly permutes n elements with swap
+func shuffle(n int, swap func(a, b int)) {
+	for i := n - 1; i > 0; i-- {
+		j, _ := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		swap(i, int(j.Int64()))
+	}
+}
@@ -0,0 +1,152 @@
+package wallet
+
+import (
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"testing"
+)
+
+const testRingSize = 16
+
+// newTestSources returns outputs of w paid by test transactions, each with a ring of random decoys
+func newTestSources(t *testing.T, w *Wallet, amounts ...uint64) (sources []TxSource) {
+	s := NewScanner(w, 1)
+	for n, amount := range amounts {
+		tx := newTestTransaction(t, uint64(n), amount, &w.address)
+		tx.Outputs[0].GlobalIndex = uint64(1000*n + 10*testRingSize/2)
+		r := s.ScanTransaction(tx)
+		if r.Err != nil || len(r.Outputs) != 1 {
+			t.Fatalf("test output not received: %v", r.Err)
+		}
+		o := r.Outputs[0]
+		source := TxSource{Output: o}
+		for i := 0; i < testRingSize; i++ {
+			member := RingMember{GlobalIndex: uint64(1000*n + 10*i), Ko: crypto.NewRandomScalar().MultG(), C: crypto.NewRandomScalar().MultG()}
+			if i == testRingSize/2 {
+				member = RingMember{GlobalIndex: o.GlobalIndex, Ko: o.Ko, C: tx.Outputs[0].C}
+			}
+			source.Ring = append(source.Ring, member)
+		}
+		sources = append(sources, source)
+	}
+	return
+}
+
+func sourceRings(tx *RingCTTx, sources []TxSource) (rings [][]RingMember) {
+	for _, in := range tx.Inputs {
+		for _, s := range sources {
+			if s.Output.KeyImage.Equal(in.KeyImage) == 1 {
+				rings = append(rings, s.Ring)
+			}
+		}
+	}
+	return
+}
+
+// receivedAmount returns the amount paid by tx to w
+func receivedAmount(t *testing.T, w *Wallet, tx *RingCTTx) (amount uint64) {
+	r := NewScanner(w, 1).ScanTransaction(tx.Transaction(100, 0))
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	for _, o := range r.Outputs {
+		amount += o.Amount
+	}
+	return
+}
+
+func TestBuildTx(t *testing.T) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(2, 5)
+	other := NewWallet()
+	other.InitializeSubAddressLookup(2, 5)
+	integrated := &address.IntegratedAddress{
+		Network:   address.MainNetworkIntegratedAddress,
+		Kv:        other.address.Kv,
+		Ks:        other.address.Ks,
+		PaymentID: [address.PaymentIDLength]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+
+	for _, test := range []struct {
+		name         string
+		destinations []address.Destination
+		change       SubAddressIndex
+		additional   bool
+	}{
+		{"standard address", []address.Destination{&other.address}, SubAddressIndex{}, false},
+		{"subaddress", []address.Destination{other.SubAddress(SubAddressIndex{1, 2})}, SubAddressIndex{}, false},
+		{"subaddress change", []address.Destination{other.SubAddress(SubAddressIndex{1, 2})}, SubAddressIndex{1, 1}, false},
+		{"integrated address", []address.Destination{integrated}, SubAddressIndex{}, false},
+		{"several addresses", []address.Destination{&other.address, other.SubAddress(SubAddressIndex{0, 3})}, SubAddressIndex{}, true},
+	} {
+		sources := newTestSources(t, w, 3000, 5000)
+		var destinations []TxDestination
+		for _, d := range test.destinations {
+			destinations = append(destinations, TxDestination{Address: d, Amount: 1000})
+		}
+		tx, err := w.BuildTx(destinations, sources, 100, test.change)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if err = tx.Verify(sourceRings(tx, sources)); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		}
+		if (len(tx.AdditionalKeys) > 0) != test.additional {
+			t.Errorf("%s: want additional keys %v, got %d", test.name, test.additional, len(tx.AdditionalKeys))
+		}
+		// a single subaddress destination and the change use R = r * Ksi
+		if d := test.destinations[0]; len(test.destinations) == 1 && d.IsSubaddress() {
+			r, _, _ := w.TxKey(tx.Hash())
+			if tx.R.Equal(d.SpendKey().ScalarMult(r)) == 0 {
+				t.Errorf("%s: transaction public key is not r * Ksi", test.name)
+			}
+		}
+		if len(tx.Outputs) != len(destinations)+1 {
+			t.Errorf("%s: want %d outputs, got %d", test.name, len(destinations)+1, len(tx.Outputs))
+		}
+		paid := uint64(1000 * len(destinations))
+		if got := receivedAmount(t, other, tx); got != paid {
+			t.Errorf("%s: want %d received, got %d", test.name, paid, got)
+		}
+		if got := receivedAmount(t, w, tx); got != 8000-paid-100 {
+			t.Errorf("%s: want %d change, got %d", test.name, 8000-paid-100, got)
+		}
+		if test.destinations[0] == integrated {
+			if paymentID, ok := other.PaymentID(tx); !ok || paymentID != integrated.PaymentID {
+				t.Errorf("%s: want payment id %x, got %x", test.name, integrated.PaymentID, paymentID)
+			}
+		}
+	}
+}
+
+func TestBuildTxErrors(t *testing.T) {
+	w := NewWallet()
+	other := NewWallet()
+	sources := newTestSources(t, w, 1000)
+	destinations := []TxDestination{{Address: &other.address, Amount: 950}}
+
+	if _, err := w.BuildTx(destinations, sources, 100, SubAddressIndex{}); err != err_msg.ErrInsufficientFunds {
+		t.Errorf("want %v, got %v", err_msg.ErrInsufficientFunds, err)
+	}
+	sources[0].Ring[testRingSize/2].C = sources[0].Ring[testRingSize/2].C.Add(crypto.PointH())
+	if _, err := w.BuildTx(destinations, sources, 10, SubAddressIndex{}); err != err_msg.ErrRingMember {
+		t.Errorf("want %v, got %v", err_msg.ErrRingMember, err)
+	}
+
+	sources = newTestSources(t, w, 1000)
+	tx, err := w.BuildTx(destinations, sources, 50, SubAddressIndex{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rings := sourceRings(tx, sources)
+	tx.Fee++
+	if err = tx.Verify(rings); err != err_msg.ErrTxBalance {
+		t.Errorf("want %v, got %v", err_msg.ErrTxBalance, err)
+	}
+	tx.Fee--
+	tx.UnlockTime = 10
+	if err = tx.Verify(rings); err != err_msg.ErrTxSignature {
+		t.Errorf("want %v, got %v", err_msg.ErrTxSignature, err)
+	}
+}
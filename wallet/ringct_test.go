@@ -0,0 +1,66 @@
+package wallet
+
+import (
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"testing"
+)
+
+func TestEcdhInfo(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		amountKey := crypto.NewRandomScalar()
+		amount := uint64(123456789012)
+		mask := crypto.NewRandomScalar()
+		if compact {
+			mask = CommitmentMask(amountKey)
+		}
+		var a amount64
+		copy(a[:], []byte{0x14, 0x1a, 0x99, 0xbe, 0x1c})
+		C := mask.DoubleScalarBaseMult(a.Scalar(), crypto.PointH())
+
+		e, err := EncodeEcdhInfo(amountKey, amount, mask, compact)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotAmount, gotMask, err := DecodeEcdhInfo(amountKey, e, C)
+		if err != nil {
+			t.Fatalf("compact %v: DecodeEcdhInfo failed: %v", compact, err)
+		}
+		if gotAmount != amount || gotMask.Equal(mask) == 0 {
+			t.Errorf("compact %v: want %v, got %v", compact, amount, gotAmount)
+		}
+
+		if _, _, err = DecodeEcdhInfo(crypto.NewRandomScalar(), e, C); err != err_msg.ErrJanus {
+			t.Errorf("compact %v: wrong amount key: want ErrJanus, got %v", compact, err)
+		}
+	}
+}
+
+func TestDecodeAmount(t *testing.T) {
+	w := NewWallet()
+	amount := uint64(5000000)
+	var a amount64
+	copy(a[:], []byte{0x40, 0x4b, 0x4c})
+
+	// standard address
+	r := crypto.NewRandomScalar()
+	Ke := r.MultG()
+	amountKey := crypto.GenerateKeyDerivation(w.address.Kv, r).DerivationToScalar(2)
+	e, _ := EncodeEcdhInfo(amountKey, amount, nil, true)
+	C := CommitmentMask(amountKey).DoubleScalarBaseMult(a.Scalar(), crypto.PointH())
+	if got, _, err := w.DecodeAmount(Ke, 2, e, C); err != nil || got != amount {
+		t.Errorf("DecodeAmount standard address: want %v, got %v %v", amount, got, err)
+	}
+
+	// subaddress
+	r = crypto.NewRandomScalar()
+	sub := w.SubAddress(SubAddressIndex{Major: 0, Minor: 3})
+	Ke = sub.Ksi.ScalarMult(r)
+	amountKey = crypto.GenerateKeyDerivation(sub.Kvi, r).DerivationToScalar(0)
+	mask := crypto.NewRandomScalar()
+	e, _ = EncodeEcdhInfo(amountKey, amount, mask, false)
+	C = mask.DoubleScalarBaseMult(a.Scalar(), crypto.PointH())
+	if got, _, err := w.DecodeAmount(Ke, 0, e, C); err != nil || got != amount {
+		t.Errorf("DecodeAmount subaddress: want %v, got %v %v", amount, got, err)
+	}
+}
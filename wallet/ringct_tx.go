@@ -0,0 +1,334 @@
+package wallet
+
+import (
+	"encoding/binary"
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+)
+
+// RingCT transactions of type RCTTypeBulletproofPlus, signed with CLSAG and paying outputs with view tags
+//
+// monero/src/cryptonote_basic/cryptonote_basic.h transaction_prefix, monero/src/ringct/rctTypes.h rctSig
+
+const (
+	ringCTTxVersion          = 2
+	rctTypeBulletproofPlus   = 6
+	txInToKeyTag             = 0x02
+	txOutToTaggedKeyTag      = 0x03
+	txExtraPubKeyTag         = 0x01
+	txExtraNonceTag          = 0x02
+	txExtraAdditionalKeysTag = 0x04
+	txExtraNonceEncryptedPID = 0x01
+)
+
+// RingMember is an output of the blockchain in the ring of an input
+type RingMember struct {
+	GlobalIndex uint64
+	Ko          *crypto.PublicKey //one time address
+	C           *crypto.PublicKey //commitment, G + amount H for outputs without one
+}
+
+// RingCTInput spends one member of a ring, txin_to_key
+type RingCTInput struct {
+	KeyOffsets []uint64 //global indices of the ring members, each relative to the previous one
+	KeyImage   *crypto.PublicKey
+}
+
+// RingCTOutput is a txout_to_tagged_key output
+type RingCTOutput struct {
+	Ko      *crypto.PublicKey //one time address
+	ViewTag byte
+}
+
+// RingCTTx is a signed RingCT transaction, the inputs are sorted by key image
+type RingCTTx struct {
+	UnlockTime         uint64
+	Inputs             []RingCTInput
+	Outputs            []RingCTOutput
+	R                  *crypto.PublicKey   //transaction public key
+	AdditionalKeys     []*crypto.PublicKey //additional transaction public keys, nil if not needed
+	EncryptedPaymentID *[address.PaymentIDLength]byte
+	Fee                uint64
+	EcdhInfo           []*EcdhInfo         //compact encrypted amounts of the outputs
+	OutPk              []*crypto.PublicKey //output commitments
+	PseudoOuts         []*crypto.PublicKey //input commitments, they sum to the output commitments and the fee
+	BulletproofPlus    *crypto.BulletproofPlus
+	CLSAGs             []*crypto.CLSAG
+}
+
+// txWriter appends the monero binary serialization of transaction fields
+type txWriter struct {
+	b []byte
+}
+
+func (w *txWriter) varint(n uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.b = append(w.b, b[:binary.PutUvarint(b[:], n)]...)
+}
+
+func (w *txWriter) bytes(b ...byte) {
+	w.b = append(w.b, b...)
+}
+
+func (w *txWriter) key(K interface{ Bytes() []byte }) {
+	w.b = append(w.b, K.Bytes()...)
+}
+
+// Extra returns the transaction extra, its fields are sorted by tag
+//
+// monero/src/cryptonote_basic/cryptonote_format_utils.cpp sort_tx_extra
+func (tx *RingCTTx) Extra() (r []byte) {
+	w := new(txWriter)
+	w.bytes(txExtraPubKeyTag)
+	w.key(tx.R)
+	if tx.EncryptedPaymentID != nil {
+		w.bytes(txExtraNonceTag, 1+address.PaymentIDLength, txExtraNonceEncryptedPID)
+		w.bytes(tx.EncryptedPaymentID[:]...)
+	}
+	if len(tx.AdditionalKeys) > 0 {
+		w.bytes(txExtraAdditionalKeysTag)
+		w.varint(uint64(len(tx.AdditionalKeys)))
+		for _, K := range tx.AdditionalKeys {
+			w.key(K)
+		}
+	}
+	r = w.b
+	return
+}
+
+// prefix returns the serialized transaction prefix
+func (tx *RingCTTx) prefix() (w *txWriter) {
+	w = new(txWriter)
+	w.varint(ringCTTxVersion)
+	w.varint(tx.UnlockTime)
+	w.varint(uint64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		w.bytes(txInToKeyTag)
+		w.varint(0)
+		w.varint(uint64(len(in.KeyOffsets)))
+		for _, offset := range in.KeyOffsets {
+			w.varint(offset)
+		}
+		w.key(in.KeyImage)
+	}
+	w.varint(uint64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		w.varint(0)
+		w.bytes(txOutToTaggedKeyTag)
+		w.key(out.Ko)
+		w.bytes(out.ViewTag)
+	}
+	extra := tx.Extra()
+	w.varint(uint64(len(extra)))
+	w.bytes(extra...)
+	return
+}
+
+// base returns the serialized rctSigBase
+func (tx *RingCTTx) base() (w *txWriter) {
+	w = new(txWriter)
+	w.bytes(rctTypeBulletproofPlus)
+	w.varint(tx.Fee)
+	for _, e := range tx.EcdhInfo {
+		w.bytes(e.Amount[:8]...)
+	}
+	for _, C := range tx.OutPk {
+		w.key(C)
+	}
+	return
+}
+
+// rangeProofKeys returns the keys of the range proof hashed into the signed message
+func (tx *RingCTTx) rangeProofKeys() (w *txWriter) {
+	w = new(txWriter)
+	p := tx.BulletproofPlus
+	w.key(p.A)
+	w.key(p.A1)
+	w.key(p.B)
+	w.key(p.R1)
+	w.key(p.S1)
+	w.key(p.D1)
+	for _, L := range p.L {
+		w.key(L)
+	}
+	for _, R := range p.R {
+		w.key(R)
+	}
+	return
+}
+
+// prunable returns the serialized rctSigPrunable
+func (tx *RingCTTx) prunable() (w *txWriter) {
+	w = new(txWriter)
+	w.varint(1)
+	p := tx.BulletproofPlus
+	w.key(p.A)
+	w.key(p.A1)
+	w.key(p.B)
+	w.key(p.R1)
+	w.key(p.S1)
+	w.key(p.D1)
+	w.varint(uint64(len(p.L)))
+	for _, L := range p.L {
+		w.key(L)
+	}
+	w.varint(uint64(len(p.R)))
+	for _, R := range p.R {
+		w.key(R)
+	}
+	for _, sig := range tx.CLSAGs {
+		for _, s := range sig.S {
+			w.key(s)
+		}
+		w.key(sig.C1)
+		w.key(sig.D)
+	}
+	for _, C := range tx.PseudoOuts {
+		w.key(C)
+	}
+	return
+}
+
+// Bytes returns the serialized transaction
+func (tx *RingCTTx) Bytes() (r []byte) {
+	r = append(append(tx.prefix().b, tx.base().b...), tx.prunable().b...)
+	return
+}
+
+// PrefixHash returns the hash of the transaction prefix
+func (tx *RingCTTx) PrefixHash() (h crypto.Hash) {
+	h = crypto.Keccak256(tx.prefix().b)
+	return
+}
+
+// Message returns the message signed by the CLSAGs
+//
+// monero/src/ringct/rctSigs.cpp get_pre_mlsag_hash
+func (tx *RingCTTx) Message() (m crypto.Hash) {
+	prefix, base := tx.PrefixHash(), crypto.Keccak256(tx.base().b)
+	proof := crypto.Keccak256(tx.rangeProofKeys().b)
+	m = crypto.Keccak256(prefix[:], base[:], proof[:])
+	return
+}
+
+// Hash returns the transaction hash, the hash of the prefix, base and prunable hashes
+//
+// monero/src/cryptonote_basic/cryptonote_format_utils.cpp calculate_transaction_hash
+func (tx *RingCTTx) Hash() (h crypto.Hash) {
+	prefix, base, prunable := tx.PrefixHash(), crypto.Keccak256(tx.base().b), crypto.Keccak256(tx.prunable().b)
+	h = crypto.Keccak256(prefix[:], base[:], prunable[:])
+	return
+}
+
+// Weight returns the weight of the transaction, its size plus the bulletproof clawback
+func (tx *RingCTTx) Weight() (weight uint64) {
+	weight = uint64(len(tx.Bytes())) + bulletproofClawback(len(tx.Outputs))
+	return
+}
+
+// Transaction returns the part of tx scanned by wallets, for tx mined at height with its first output at global
+// index firstGlobalIndex
+func (tx *RingCTTx) Transaction(height, firstGlobalIndex uint64) (r *Transaction) {
+	r = &Transaction{
+		Hash:           tx.Hash(),
+		Height:         height,
+		UnlockTime:     tx.UnlockTime,
+		R:              tx.R,
+		AdditionalKeys: tx.AdditionalKeys,
+	}
+	for _, in := range tx.Inputs {
+		r.KeyImages = append(r.KeyImages, in.KeyImage)
+	}
+	for n, out := range tx.Outputs {
+		viewTag := out.ViewTag
+		r.Outputs = append(r.Outputs, TxOutput{
+			Ko:          out.Ko,
+			ViewTag:     &viewTag,
+			EcdhInfo:    tx.EcdhInfo[n],
+			C:           tx.OutPk[n],
+			GlobalIndex: firstGlobalIndex + uint64(n),
+		})
+	}
+	return
+}
+
+// Verify checks the signatures, range proof and balance of tx spending from rings, rings[i] are the members
+// referenced by input i
+//
+// monero/src/ringct/rctSigs.cpp verRctSemanticsSimple, verRctNonSemanticsSimple
+func (tx *RingCTTx) Verify(rings [][]RingMember) (err error) {
+	n := len(tx.Inputs)
+	if n == 0 || len(rings) != n || len(tx.PseudoOuts) != n || len(tx.CLSAGs) != n ||
+		len(tx.Outputs) == 0 || len(tx.OutPk) != len(tx.Outputs) || len(tx.EcdhInfo) != len(tx.Outputs) ||
+		tx.BulletproofPlus == nil {
+		err = err_msg.ErrTxSemantics
+		return
+	}
+	for i, in := range tx.Inputs {
+		if !ringMatchesOffsets(rings[i], in.KeyOffsets) {
+			err = err_msg.ErrTxSemantics
+			return
+		}
+	}
+
+	// sum pseudo outputs = sum output commitments + fee H
+	sum := crypto.PointI()
+	for _, C := range tx.PseudoOuts {
+		sum = sum.Add(C)
+	}
+	outputs := newAmount64(tx.Fee).Scalar().MultH()
+	for _, C := range tx.OutPk {
+		outputs = outputs.Add(C)
+	}
+	if sum.Equal(outputs) == 0 {
+		err = err_msg.ErrTxBalance
+		return
+	}
+	if !tx.BulletproofPlus.Verify(tx.OutPk) {
+		err = err_msg.ErrTxRangeProof
+		return
+	}
+
+	m := tx.Message()
+	for i, in := range tx.Inputs {
+		P, C := ringKeys(rings[i])
+		if !tx.CLSAGs[i].Verify(m, P, C, tx.PseudoOuts[i], in.KeyImage) {
+			err = err_msg.ErrTxSignature
+			return
+		}
+	}
+	return
+}
+
+// keyOffsets returns the global indices of ring relative to the previous member, ring is sorted by global index
+func keyOffsets(ring []RingMember) (r []uint64) {
+	var previous uint64
+	for _, member := range ring {
+		r = append(r, member.GlobalIndex-previous)
+		previous = member.GlobalIndex
+	}
+	return
+}
+
+func ringMatchesOffsets(ring []RingMember, offsets []uint64) (r bool) {
+	if len(ring) != len(offsets) {
+		return
+	}
+	var globalIndex uint64
+	for n, offset := range offsets {
+		globalIndex += offset
+		if ring[n].GlobalIndex != globalIndex {
+			return
+		}
+	}
+	r = true
+	return
+}
+
+func ringKeys(ring []RingMember) (P, C []*crypto.Point) {
+	for _, member := range ring {
+		P, C = append(P, member.Ko), append(C, member.C)
+	}
+	return
+}
@@ -0,0 +1,194 @@
+package wallet
+
+import (
+	"context"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"log"
+	"runtime"
+	"sync"
+)
+
+// TxOutput is an output of a transaction as it appears on the blockchain
+type TxOutput struct {
+	Ko          *crypto.PublicKey //one time address
+	ViewTag     *byte             //nil for outputs created before the view tag hardfork
+	Amount      uint64            //amount of outputs without ecdhInfo (coinbase and pre RingCT outputs)
+	EcdhInfo    *EcdhInfo         //encrypted amount of RingCT outputs, nil if the amount is not encrypted
+	C           *crypto.PublicKey //commitment of RingCT outputs
+	GlobalIndex uint64
+}
+
+// Transaction is the part of a transaction needed to find the outputs it pays to and spends from a wallet
+type Transaction struct {
+	Hash           crypto.Hash
+	Height         uint64
+	UnlockTime     uint64
+	R              *crypto.PublicKey   //transaction public key
+	AdditionalKeys []*crypto.PublicKey //additional transaction public keys, nil if the transaction has none
+	KeyImages      []*crypto.PublicKey //key images of the inputs
+	Outputs        []TxOutput
+}
+
+// ScanResult are the outputs of Tx received by the wallet
+type ScanResult struct {
+	Tx      *Transaction
+	Outputs []*Output
+	Skipped []uint64 //indices of the outputs to the wallet whose encrypted amount does not decode
+	Err     error
+}
+
+// Scanner scans transactions for outputs of a wallet on a pool of goroutines
+type Scanner struct {
+	w       *Wallet
+	workers int
+}
+
+// NewScanner returns a Scanner for w using workers goroutines, or one per CPU if workers is not positive
+func NewScanner(w *Wallet, workers int) (s *Scanner) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	s = &Scanner{w: w, workers: workers}
+	return
+}
+
+// Scan scans the transactions received from txs and sends a result for each of them, in the same order, on the
+// returned channel, which is closed once txs is closed or ctx is done
+// the caller must receive all results or cancel ctx
+func (s *Scanner) Scan(ctx context.Context, txs <-chan *Transaction) <-chan *ScanResult {
+	type job struct {
+		tx     *Transaction
+		result chan *ScanResult
+	}
+	jobs := make(chan job)
+	// results of the jobs in the order of txs, this also bounds the number of transactions in flight
+	pending := make(chan chan *ScanResult, 2*s.workers)
+	results := make(chan *ScanResult)
+
+	var wg sync.WaitGroup
+	for n := 0; n < s.workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				j.result <- s.ScanTransaction(j.tx)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for {
+			var tx *Transaction
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case tx, ok = <-txs:
+				if !ok {
+					return
+				}
+			}
+			result := make(chan *ScanResult, 1)
+			select {
+			case <-ctx.Done():
+				return
+			case pending <- result:
+			}
+			jobs <- job{tx, result}
+		}
+	}()
+
+	go func() {
+		defer close(results)
+		for result := range pending {
+			var r *ScanResult
+			select {
+			case <-ctx.Done():
+				return
+			case r = <-result:
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case results <- r:
+			}
+		}
+		wg.Wait()
+	}()
+	return results
+}
+
+// ScanTransaction returns the outputs of tx received by the wallet, with their amounts and key images, the key
+// images are nil for view only wallets, outputs whose encrypted amount does not match their commitment are skipped
+// as wallet2 does
+//
+// monero/src/wallet/wallet2.cpp wallet2::process_new_transaction
+func (s *Scanner) ScanTransaction(tx *Transaction) (r *ScanResult) {
+	r = &ScanResult{Tx: tx}
+	for n, out := range tx.Outputs {
+		outputIndex := uint64(n)
+		i, txPublicKey, ok := s.w.ScanOutput(out.Ko, tx.R, tx.AdditionalKeys, outputIndex, out.ViewTag)
+		if !ok {
+			continue
+		}
+		o := &Output{
+			TxHash:      tx.Hash,
+			Index:       outputIndex,
+			GlobalIndex: out.GlobalIndex,
+			Height:      tx.Height,
+			UnlockTime:  tx.UnlockTime,
+			SubAddress:  i,
+			Ko:          out.Ko,
+			TxPublicKey: txPublicKey,
+			Amount:      out.Amount,
+		}
+		if out.EcdhInfo != nil {
+			var err error
+			if o.Amount, o.Mask, err = s.w.DecodeAmount(txPublicKey, outputIndex, out.EcdhInfo, out.C); err != nil {
+				r.Skipped = append(r.Skipped, outputIndex)
+				continue
+			}
+		}
+		// view only wallets import the key images later
+		if !s.w.IsViewOnly() {
+			o.KeyImage = s.w.outputPrivateKey(o).KeyImage()
+		}
+		r.Outputs = append(r.Outputs, o)
+	}
+	return
+}
+
+// Restore scans txs, which must be in blockchain order, and records the received and spent outputs in the
+// output store of the wallet, the outputs already recorded are left as they are so that a block range can be
+// rescanned
+func (s *Scanner) Restore(ctx context.Context, txs <-chan *Transaction) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for r := range s.Scan(ctx, txs) {
+		if r.Err != nil {
+			err = r.Err
+			return
+		}
+		for _, KI := range r.Tx.KeyImages {
+			// most inputs spend outputs of other wallets
+			if err = s.w.outputs.MarkSpent(KI, r.Tx.Height, r.Tx.Hash); err != nil && err != err_msg.ErrKeyImage {
+				return
+			}
+			err = nil
+		}
+		for _, n := range r.Skipped {
+			log.Printf("skipping output %d of transaction %x: the amount does not decode", n, r.Tx.Hash[:])
+		}
+		for _, o := range r.Outputs {
+			if err = s.w.addOutput(o); err != nil && err != err_msg.ErrDuplicateOutput {
+				return
+			}
+			err = nil
+		}
+	}
+	err = ctx.Err()
+	return
+}
@@ -0,0 +1,182 @@
+package wallet
+
+import (
+	"context"
+	"encoding/binary"
+	"gomonero/address"
+	"gomonero/crypto"
+	"testing"
+	"time"
+)
+
+// newTestTransaction returns a transaction paying amount to each destination
+func newTestTransaction(t testing.TB, height uint64, amount uint64, destinations ...address.Destination) (tx *Transaction) {
+	keys, err := address.NewTxKeys(destinations, -1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx = &Transaction{
+		Height:         height,
+		R:              keys.R,
+		AdditionalKeys: keys.AdditionalKeys,
+		KeyImages:      []*crypto.PublicKey{crypto.NewRandomScalar().MultG()},
+	}
+	binary.LittleEndian.PutUint64(tx.Hash[:], height)
+	var a amount64
+	binary.LittleEndian.PutUint64(a[:], amount)
+	for _, o := range keys.Outputs {
+		viewTag := o.ViewTag
+		e, err := EncodeEcdhInfo(o.AmountKey, amount, nil, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		C := CommitmentMask(o.AmountKey).DoubleScalarBaseMult(a.Scalar(), crypto.PointH())
+		tx.Outputs = append(tx.Outputs, TxOutput{Ko: o.Ko, ViewTag: &viewTag, EcdhInfo: e, C: C})
+	}
+	return
+}
+
+func TestScanner(t *testing.T) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(2, 5)
+	other := NewWallet()
+
+	var txs []*Transaction
+	for height := uint64(0); height < 50; height++ {
+		switch height % 3 {
+		case 0:
+			txs = append(txs, newTestTransaction(t, height, height, &w.address))
+		case 1:
+			txs = append(txs, newTestTransaction(t, height, height, &other.address, w.SubAddress(SubAddressIndex{1, 4})))
+		case 2:
+			txs = append(txs, newTestTransaction(t, height, height, &other.address))
+		}
+	}
+
+	in := make(chan *Transaction)
+	go func() {
+		for _, tx := range txs {
+			in <- tx
+		}
+		close(in)
+	}()
+	n := 0
+	for r := range NewScanner(w, 4).Scan(context.Background(), in) {
+		if r.Tx != txs[n] {
+			t.Fatalf("result %v out of order", n)
+		}
+		want := 1
+		if n%3 == 2 {
+			want = 0
+		}
+		if len(r.Outputs) != want {
+			t.Errorf("tx %v: want %v outputs, got %v", n, want, len(r.Outputs))
+		}
+		for _, o := range r.Outputs {
+			if o.Amount != uint64(n) || o.TxHash != txs[n].Hash {
+				t.Errorf("tx %v: wrong output %+v", n, o)
+			}
+			if n%3 == 1 && (o.SubAddress != SubAddressIndex{1, 4} || o.Index != 1) {
+				t.Errorf("tx %v: want subaddress {1 4} output 1, got %v output %v", n, o.SubAddress, o.Index)
+			}
+		}
+		n++
+	}
+	if n != len(txs) {
+		t.Errorf("want %v results, got %v", len(txs), n)
+	}
+}
+
+func TestScannerRestore(t *testing.T) {
+	w := NewWallet()
+	received := newTestTransaction(t, 10, 7, &w.address)
+	restore := func(txs ...*Transaction) {
+		in := make(chan *Transaction, len(txs))
+		for _, tx := range txs {
+			in <- tx
+		}
+		close(in)
+		if err := NewScanner(w, 2).Restore(context.Background(), in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restore(received, newTestTransaction(t, 11, 3, &NewWallet().address))
+	if got := w.Balance(); got != 7 {
+		t.Errorf("Balance: want 7, got %v", got)
+	}
+
+	// a later transaction spends the received output
+	ko, _ := w.StandardAddressOneTimeAddressPrivateKey(received.R, 0)
+	KI := ko.KeyImage()
+	spend := newTestTransaction(t, 12, 1, &NewWallet().address)
+	spend.KeyImages = append(spend.KeyImages, KI)
+	restore(spend)
+	if got := w.Balance(); got != 0 {
+		t.Errorf("Balance after spend: want 0, got %v", got)
+	}
+
+	// rescanning the same blocks leaves the outputs as they are
+	restore(received, spend)
+	if got := len(w.outputs.Outputs()); got != 1 {
+		t.Errorf("outputs after rescan: want 1, got %v", got)
+	}
+	if got := w.Balance(); got != 0 {
+		t.Errorf("Balance after rescan: want 0, got %v", got)
+	}
+
+	// an output whose amount does not decode is skipped, the other outputs of the block range are recorded
+	bad := newTestTransaction(t, 13, 5, &w.address)
+	bad.Outputs[0].C = crypto.NewRandomScalar().MultG()
+	if r := NewScanner(w, 1).ScanTransaction(bad); len(r.Outputs) != 0 || len(r.Skipped) != 1 || r.Err != nil {
+		t.Errorf("want output 0 skipped, got %+v", r)
+	}
+	restore(bad, newTestTransaction(t, 14, 4, &w.address))
+	if got := w.Balance(); got != 4 {
+		t.Errorf("Balance after malformed output: want 4, got %v", got)
+	}
+}
+
+func TestScannerCancel(t *testing.T) {
+	w := NewWallet()
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan *Transaction)
+	results := NewScanner(w, 2).Scan(ctx, in)
+	in <- newTestTransaction(t, 0, 1, &w.address)
+	cancel()
+	// the results channel is closed without the transactions channel being closed
+	for range results {
+	}
+	if err := NewScanner(w, 2).Restore(ctx, in); err != context.Canceled {
+		t.Errorf("Restore: want %v, got %v", context.Canceled, err)
+	}
+}
+
+func benchmarkScanner(b *testing.B, workers int) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(5, 20)
+	other := NewWallet()
+	txs := make([]*Transaction, 64)
+	for i := range txs {
+		txs[i] = newTestTransaction(b, uint64(i), 1, &other.address, &other.address)
+	}
+	s := NewScanner(w, workers)
+	b.ResetTimer()
+	// b.Elapsed needs go 1.20
+	start := time.Now()
+	for n := 0; n < b.N; n++ {
+		in := make(chan *Transaction)
+		go func() {
+			for _, tx := range txs {
+				in <- tx
+			}
+			close(in)
+		}()
+		for range s.Scan(context.Background(), in) {
+		}
+	}
+	b.ReportMetric(float64(b.N*len(txs)*2)/time.Since(start).Seconds(), "outputs/s")
+}
+
+func BenchmarkScanner1(b *testing.B)      { benchmarkScanner(b, 1) }
+func BenchmarkScanner4(b *testing.B)      { benchmarkScanner(b, 4) }
+func BenchmarkScannerNumCPU(b *testing.B) { benchmarkScanner(b, 0) }
@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"encoding/json"
+	"gomonero/crypto"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// TxKeyStore records the private keys of the transactions built by a wallet, the sender proves its payments with
+// them
+//
+// monero/src/wallet/wallet2.h m_tx_keys, m_additional_tx_keys
+type TxKeyStore interface {
+	// Add records the transaction private key r and the additional private keys of the transaction txHash
+	Add(txHash crypto.Hash, r *crypto.PrivateKey, additional []*crypto.PrivateKey) error
+	// Get returns the private keys of the transaction txHash
+	Get(txHash crypto.Hash) (r *crypto.PrivateKey, additional []*crypto.PrivateKey, ok bool)
+}
+
+// TxPrivateKeys are the private keys of a transaction recorded in a TxKeyStore
+type TxPrivateKeys struct {
+	TxHash     crypto.Hash
+	R          *crypto.PrivateKey   //transaction private key
+	Additional []*crypto.PrivateKey `json:",omitempty"` //additional private keys, one per output if needed
+}
+
+// MemoryTxKeyStore is a TxKeyStore which is held in memory, it is safe for concurrent use
+type MemoryTxKeyStore struct {
+	mu   sync.RWMutex
+	keys []*TxPrivateKeys //in the order they were added
+	byTx map[crypto.Hash]*TxPrivateKeys
+}
+
+func NewMemoryTxKeyStore() (s *MemoryTxKeyStore) {
+	s = new(MemoryTxKeyStore)
+	s.byTx = make(map[crypto.Hash]*TxPrivateKeys)
+	return
+}
+
+func (s *MemoryTxKeyStore) Add(txHash crypto.Hash, r *crypto.PrivateKey, additional []*crypto.PrivateKey) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(&TxPrivateKeys{TxHash: txHash, R: r, Additional: append([]*crypto.PrivateKey{}, additional...)})
+	return
+}
+
+// add records k, replacing the keys already recorded for the same transaction, the caller must hold the lock
+func (s *MemoryTxKeyStore) add(k *TxPrivateKeys) {
+	if old, ok := s.byTx[k.TxHash]; ok {
+		*old = *k
+		return
+	}
+	s.keys = append(s.keys, k)
+	s.byTx[k.TxHash] = k
+}
+
+func (s *MemoryTxKeyStore) Get(txHash crypto.Hash) (r *crypto.PrivateKey, additional []*crypto.PrivateKey, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.byTx[txHash]
+	if !ok {
+		return
+	}
+	r = k.R
+	if len(k.Additional) > 0 {
+		additional = append([]*crypto.PrivateKey{}, k.Additional...)
+	}
+	return
+}
+
+// FileTxKeyStore is a MemoryTxKeyStore which is written to a json file after every change, the file holds private
+// keys and must be kept like the wallet cache
+type FileTxKeyStore struct {
+	MemoryTxKeyStore
+	path string
+}
+
+// NewFileTxKeyStore opens the transaction key store at path, the file is created on the first change if it does not
+// exist
+func NewFileTxKeyStore(path string) (s *FileTxKeyStore, err error) {
+	s = &FileTxKeyStore{path: path}
+	s.byTx = make(map[crypto.Hash]*TxPrivateKeys)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		err = nil
+		return
+	}
+	if err != nil {
+		s = nil
+		return
+	}
+	var keys []*TxPrivateKeys
+	if err = json.Unmarshal(data, &keys); err != nil {
+		s = nil
+		return
+	}
+	for _, k := range keys {
+		s.add(k)
+	}
+	return
+}
+
+func (s *FileTxKeyStore) Add(txHash crypto.Hash, r *crypto.PrivateKey, additional []*crypto.PrivateKey) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.add(&TxPrivateKeys{TxHash: txHash, R: r, Additional: append([]*crypto.PrivateKey{}, additional...)})
+	err = s.save()
+	return
+}
+
+// save writes the keys to the file, the caller must hold the lock
+func (s *FileTxKeyStore) save() (err error) {
+	data, err := json.Marshal(s.keys)
+	if err != nil {
+		return
+	}
+	err = writeFileAtomic(s.path, data)
+	return
+}
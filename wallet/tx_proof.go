@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"gomonero/address"
+	"gomonero/crypto"
+	"gomonero/err_msg"
+	"strings"
+)
+
+// payment proofs, an OutProofV2 by the sender of a transaction proves with its transaction private keys r that it
+// paid an address, an InProofV2 by the receiver proves with its private view key kv that the address was paid
+// each proof holds, for the transaction public key and each additional key, the shared secret D = r Kv = kv R and a
+// proof of NewTxProof, verifiers recompute the amount paid with the derivation 8 D
+//
+// monero/src/wallet/wallet2.cpp get_tx_proof, check_tx_proof, check_tx_key_helper
+
+const (
+	outProofV2 = "OutProofV2"
+	inProofV2  = "InProofV2"
+
+	// base58 sizes of a shared secret and of a signature
+	txProofSecretLength    = 44
+	txProofSignatureLength = 88
+)
+
+// TxKey returns the private keys of the transaction txHash built by the wallet
+//
+// monero/src/wallet/wallet2.cpp get_tx_key
+func (w *Wallet) TxKey(txHash crypto.Hash) (r *crypto.PrivateKey, additional []*crypto.PrivateKey, ok bool) {
+	r, additional, ok = w.txKeys.Get(txHash)
+	return
+}
+
+// txProofHash returns the hash signed by the proofs, Keccak(txid || message)
+func txProofHash(txHash crypto.Hash, message []byte) (h crypto.Hash) {
+	h = crypto.Keccak256(txHash[:], message)
+	return
+}
+
+// txProofBase returns the base of the public keys of the proofs for a, the spend key of subaddresses, nil for G
+func txProofBase(a address.Destination) (B *crypto.Point) {
+	if a.IsSubaddress() {
+		B = a.SpendKey()
+	}
+	return
+}
+
+func encodeTxProof(header string, D []*crypto.Point, sigs []*crypto.Signature) (proof string) {
+	proof = header
+	for n := range D {
+		proof += address.EncodeMoneroBase58(D[n].Bytes()) + address.EncodeMoneroBase58(sigs[n].C.Bytes(), sigs[n].R.Bytes())
+	}
+	return
+}
+
+// OutProof returns the OutProofV2 of the payment of tx, built by the wallet, to a
+func (w *Wallet) OutProof(tx *Transaction, a address.Destination, message []byte) (proof string, err error) {
+	r, additional, ok := w.TxKey(tx.Hash)
+	if !ok {
+		err = err_msg.ErrTxKey
+		return
+	}
+	proof, err = NewOutProof(tx, r, additional, a, message)
+	return
+}
+
+// NewOutProof returns the OutProofV2 of the payment of tx to a, from the transaction private key r and the
+// additional private keys of tx
+// the proofs show D = r Kv with R = r G, or R = r Ks for a subaddress
+func NewOutProof(tx *Transaction, r *crypto.PrivateKey, additional []*crypto.PrivateKey, a address.Destination, message []byte) (proof string, err error) {
+	if len(additional) != len(tx.AdditionalKeys) {
+		err = err_msg.ErrTxKey
+		return
+	}
+	h := txProofHash(tx.Hash, message)
+	B := txProofBase(a)
+	var D []*crypto.Point
+	var sigs []*crypto.Signature
+	for _, k := range append([]*crypto.PrivateKey{r}, additional...) {
+		R := k.MultG()
+		if B != nil {
+			R = B.ScalarMult(k)
+		}
+		Di := a.ViewKey().ScalarMult(k)
+		D, sigs = append(D, Di), append(sigs, crypto.NewTxProof(h, R, a.ViewKey(), B, Di, k))
+	}
+	proof = encodeTxProof(outProofV2, D, sigs)
+	return
+}
+
+// InProof returns the InProofV2 of the payment of tx to subaddress i of the wallet, {0, 0} is the standard address
+// the proofs show D = kv R with Kv = kv G, or Kvi = kv Ksi for a subaddress
+func (w *Wallet) InProof(tx *Transaction, i SubAddressIndex, message []byte) (proof string, err error) {
+	var a address.Destination = &w.address
+	if i != (SubAddressIndex{}) {
+		a = w.SubAddress(i)
+	}
+	h := txProofHash(tx.Hash, message)
+	B := txProofBase(a)
+	var D []*crypto.Point
+	var sigs []*crypto.Signature
+	for _, R := range append([]*crypto.PublicKey{tx.R}, tx.AdditionalKeys...) {
+		Di := R.ScalarMult(w.kv)
+		D, sigs = append(D, Di), append(sigs, crypto.NewTxProof(h, a.ViewKey(), R, B, Di, w.kv))
+	}
+	proof = encodeTxProof(inProofV2, D, sigs)
+	return
+}
+
+// VerifyTxProof checks an OutProofV2 or an InProofV2 of the payment of tx to a and returns the amount tx paid to a
+func VerifyTxProof(tx *Transaction, a address.Destination, message []byte, proof string) (received uint64, err error) {
+	in := strings.HasPrefix(proof, inProofV2)
+	if !in && !strings.HasPrefix(proof, outProofV2) {
+		err = err_msg.ErrTxProofFormat
+		return
+	}
+	encoded := strings.TrimPrefix(strings.TrimPrefix(proof, inProofV2), outProofV2)
+	txKeys := append([]*crypto.PublicKey{tx.R}, tx.AdditionalKeys...)
+	if len(encoded) != len(txKeys)*(txProofSecretLength+txProofSignatureLength) {
+		err = err_msg.ErrTxProofFormat
+		return
+	}
+	h := txProofHash(tx.Hash, message)
+	B := txProofBase(a)
+	derivations := make([]*crypto.Point, len(txKeys))
+	good := false
+	for n, R := range txKeys {
+		chunk := encoded[n*(txProofSecretLength+txProofSignatureLength):]
+		D := crypto.NewPointFromBytes(decodeTxProofChunk(chunk[:txProofSecretLength], crypto.KeyLength))
+		raw := decodeTxProofChunk(chunk[txProofSecretLength:txProofSecretLength+txProofSignatureLength], 2*crypto.KeyLength)
+		if D.Err != nil || raw == nil {
+			err = err_msg.ErrTxProofFormat
+			return
+		}
+		sig := &crypto.Signature{C: crypto.NewScalarFromBytes(raw[:crypto.KeyLength]), R: crypto.NewScalarFromBytes(raw[crypto.KeyLength:])}
+		if sig.C.Err != nil || sig.R.Err != nil {
+			err = err_msg.ErrTxProofFormat
+			return
+		}
+		if in {
+			good = good || crypto.VerifyTxProof(h, a.ViewKey(), R, B, D, sig)
+		} else {
+			good = good || crypto.VerifyTxProof(h, R, a.ViewKey(), B, D, sig)
+		}
+		derivations[n] = D.MultByCofactor()
+	}
+	if !good {
+		err = err_msg.ErrTxProof
+		return
+	}
+	received, err = receivedWithDerivations(tx, a, derivations[0], derivations[1:])
+	return
+}
+
+// decodeTxProofChunk returns the length bytes encoded in base58 by s, nil if s is not their canonical encoding
+func decodeTxProofChunk(s string, length int) (r []byte) {
+	r = address.DecodeMoneroBase58(s)
+	if len(r) != length || address.EncodeMoneroBase58(r) != s {
+		r = nil
+	}
+	return
+}
+
+// receivedWithDerivations returns the amount paid by tx to a, output n is derived from Kss or additional[n]
+func receivedWithDerivations(tx *Transaction, a address.Destination, Kss *crypto.Point, additional []*crypto.Point) (received uint64, err error) {
+	for n, out := range tx.Outputs {
+		index := uint64(n)
+		found := Kss
+		if crypto.DerivePublicKey(Kss, index, a.SpendKey()).Equal(out.Ko) == 0 {
+			if n >= len(additional) || crypto.DerivePublicKey(additional[n], index, a.SpendKey()).Equal(out.Ko) == 0 {
+				continue
+			}
+			found = additional[n]
+		}
+		amount := out.Amount
+		if out.EcdhInfo != nil {
+			if amount, _, err = DecodeEcdhInfo(found.DerivationToScalar(index), out.EcdhInfo, out.C); err != nil {
+				return
+			}
+		}
+		received += amount
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package wallet
+
+import (
+	"gomonero/address"
+	"gomonero/err_msg"
+	"testing"
+)
+
+func TestTxProof(t *testing.T) {
+	w := NewWallet()
+	other := NewWallet()
+	other.InitializeSubAddressLookup(2, 5)
+	message := []byte("payment for order 42")
+
+	for _, test := range []struct {
+		name  string
+		index SubAddressIndex
+	}{
+		{"standard address", SubAddressIndex{}},
+		{"subaddress", SubAddressIndex{1, 2}},
+	} {
+		var a address.Destination = &other.address
+		if test.index != (SubAddressIndex{}) {
+			a = other.SubAddress(test.index)
+		}
+		sources := newTestSources(t, w, 3000)
+		built, err := w.BuildTx([]TxDestination{{Address: a, Amount: 1000}}, sources, 100, SubAddressIndex{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := built.Transaction(100, 0)
+
+		outProof, err := w.OutProof(tx, a, message)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		inProof, err := other.InProof(tx, test.index, message)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		for _, proof := range []string{outProof, inProof} {
+			if received, err := VerifyTxProof(tx, a, message, proof); err != nil || received != 1000 {
+				t.Errorf("%s: want 1000 received, got %d: %v", test.name, received, err)
+			}
+			if _, err := VerifyTxProof(tx, a, []byte("other message"), proof); err != err_msg.ErrTxProof {
+				t.Errorf("%s: other message, want %v, got %v", test.name, err_msg.ErrTxProof, err)
+			}
+			if _, err := VerifyTxProof(tx, other.SubAddress(SubAddressIndex{0, 1}), message, proof); err != err_msg.ErrTxProof {
+				t.Errorf("%s: other address, want %v, got %v", test.name, err_msg.ErrTxProof, err)
+			}
+			if _, err := VerifyTxProof(tx, a, message, proof[:len(proof)-1]); err != err_msg.ErrTxProofFormat {
+				t.Errorf("%s: truncated, want %v, got %v", test.name, err_msg.ErrTxProofFormat, err)
+			}
+		}
+
+		// the sender proves its change with an in proof too
+		changeProof, err := w.InProof(tx, SubAddressIndex{}, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if received, err := VerifyTxProof(tx, &w.address, message, changeProof); err != nil || received != 1900 {
+			t.Errorf("%s: want 1900 change, got %d: %v", test.name, received, err)
+		}
+	}
+
+	if _, err := other.OutProof(&Transaction{}, &w.address, message); err != err_msg.ErrTxKey {
+		t.Errorf("want %v, got %v", err_msg.ErrTxKey, err)
+	}
+	if _, err := VerifyTxProof(&Transaction{}, &w.address, message, "SpendProofV1"); err != err_msg.ErrTxProofFormat {
+		t.Errorf("want %v, got %v", err_msg.ErrTxProofFormat, err)
+	}
+}
@@ -4,6 +4,15 @@ import (
 	"encoding/binary"
 	"gomonero/address"
 	"gomonero/crypto"
+	"gomonero/err_msg"
+	"math"
+	"sync"
+)
+
+// monero/src/wallet/wallet2.h
+const (
+	DefaultLookaheadMajor = 50  //SUBADDRESS_LOOKAHEAD_MAJOR
+	DefaultLookaheadMinor = 200 //SUBADDRESS_LOOKAHEAD_MINOR
 )
 
 type SubAddressIndex struct {
@@ -12,18 +21,18 @@ type SubAddressIndex struct {
 
 type Wallet struct {
 	kv               *crypto.PrivateKey
-	ks               *crypto.PrivateKey
+	ks               *crypto.PrivateKey      //nil for view only wallets
 	address          address.StandardAddress //pub keys
-	outputs          []*output
+	outputs          OutputStore
 	subAddressLookup map[[32]byte]SubAddressIndex //map of public spend keys for IDing transactions
-}
-
-type output struct {
-	Ko     *crypto.PublicKey
-	ko     *crypto.PrivateKey
-	amount uint64
-	mask   [crypto.HashLength]byte
-	spent  bool
+	lookupSizes      []uint32                     //number of subaddresses of each account in the lookup table
+	lookaheadMajor   uint32                       //number of accounts and subaddresses kept in the table past the highest used ones
+	lookaheadMinor   uint32
+	lookupMu         sync.RWMutex //guards the subaddress lookup table and accounts
+	accounts         []uint32     //number of subaddresses created in each account
+	height           uint64       //restore height
+	labels           map[SubAddressIndex]string
+	txKeys           TxKeyStore //private keys of the transactions built by the wallet
 }
 
 func NewWallet() (w *Wallet) {
@@ -33,6 +42,9 @@ func NewWallet() (w *Wallet) {
 	w.kv, w.address.Kv = crypto.NewKeyPair()
 	w.ks, w.address.Ks = crypto.NewKeyPair()
 	w.address.Network = address.MainNetwork
+	w.outputs = NewMemoryOutputStore()
+	w.txKeys = NewMemoryTxKeyStore()
+	w.InitializeSubAddressLookup(DefaultLookaheadMajor, DefaultLookaheadMinor)
 	return
 }
 
@@ -43,27 +55,172 @@ func (w *Wallet) FromKeys(kv, ks *crypto.PrivateKey) *Wallet {
 	w.ks = ks
 	w.address.Ks = ks.PublicKey()
 	w.address.Network = address.MainNetwork
+	w.outputs = NewMemoryOutputStore()
+	w.txKeys = NewMemoryTxKeyStore()
+	w.InitializeSubAddressLookup(DefaultLookaheadMajor, DefaultLookaheadMinor)
 	return w
 }
 
-func (w *Wallet) ScanOutputForStandardAddress(Ko, Ke *crypto.PublicKey) (r int) {
+// NewViewOnlyWallet returns a wallet which can find received outputs but can not spend them, their key images are
+// imported from the wallet holding the private spend key
+func NewViewOnlyWallet(kv *crypto.PrivateKey, Ks *crypto.PublicKey, network int) (w *Wallet) {
+	w = new(Wallet)
+	w.kv = kv
+	w.address = address.StandardAddress{Network: network, Kv: kv.PublicKey(), Ks: Ks}
+	w.outputs = NewMemoryOutputStore()
+	w.txKeys = NewMemoryTxKeyStore()
+	w.InitializeSubAddressLookup(DefaultLookaheadMajor, DefaultLookaheadMinor)
+	return
+}
+
+// IsViewOnly returns true if the wallet does not hold the private spend key
+func (w *Wallet) IsViewOnly() (r bool) {
+	r = w.ks == nil
+	return
+}
+
+// OutputStore returns the store holding the outputs received by the wallet
+func (w *Wallet) OutputStore() (s OutputStore) {
+	s = w.outputs
+	return
+}
+
+// SetOutputStore replaces the store holding the outputs received by the wallet, e.g. with a FileOutputStore
+func (w *Wallet) SetOutputStore(s OutputStore) {
+	w.outputs = s
+}
+
+// TxKeyStore returns the store holding the private keys of the transactions built by the wallet
+func (w *Wallet) TxKeyStore() (s TxKeyStore) {
+	s = w.txKeys
+	return
+}
+
+// SetTxKeyStore replaces the store holding the private keys of the transactions built by the wallet, e.g. with a
+// FileTxKeyStore
+func (w *Wallet) SetTxKeyStore(s TxKeyStore) {
+	w.txKeys = s
+}
+
+// AddOutput records an output received by the wallet, computing its key image
+// o.SubAddress {0, 0} is the standard address
+// a view only wallet keeps the output apart until its key image is imported
+func (w *Wallet) AddOutput(o *Output) (err error) {
+	if w.outputPublicKey(o).Equal(o.Ko) == 0 {
+		err = err_msg.ErrOneTimeAddress
+		return
+	}
+	c := *o
+	c.KeyImage = nil
+	if !w.IsViewOnly() {
+		c.KeyImage = w.outputPrivateKey(o).KeyImage()
+	}
+	err = w.addOutput(&c)
+	return
+}
+
+// addOutput records o and creates its subaddress, outputs without key image are pending in the output store
+func (w *Wallet) addOutput(o *Output) (err error) {
+	if o.KeyImage == nil {
+		err = w.outputs.AddPending(o)
+	} else {
+		err = w.outputs.Add(o)
+	}
+	if err != nil {
+		return
+	}
+	w.markSubAddressCreated(o.SubAddress)
+	return
+}
+
+// outputPrivateKey returns the private key of the one time address of o, ks + x, the wallet must not be view only
+func (w *Wallet) outputPrivateKey(o *Output) (ko *crypto.PrivateKey) {
+	ko = w.ks.Add(w.outputViewSecret(o))
+	return
+}
+
+// outputViewSecret returns x = ko - ks of o, the part of the private key of its one time address derived with the
+// view key, Hs(Kss || varint(index)) and the subaddress secret
+func (w *Wallet) outputViewSecret(o *Output) (x *crypto.Scalar) {
+	Kss := crypto.GenerateKeyDerivation(o.TxPublicKey, w.kv)
+	x = Kss.DerivationToScalar(o.Index)
+	if o.SubAddress != (SubAddressIndex{}) {
+		x = x.Add(w.subAddressSecret(o.SubAddress))
+	}
+	return
+}
+
+// outputPublicKey returns the one time address of o derived with the view key
+func (w *Wallet) outputPublicKey(o *Output) (Ko *crypto.PublicKey) {
+	Kss := crypto.GenerateKeyDerivation(o.TxPublicKey, w.kv)
+	Ks := w.address.Ks
+	if o.SubAddress != (SubAddressIndex{}) {
+		Ks = w.SubAddressPublicSpendKey(o.SubAddress)
+	}
+	Ko = crypto.DerivePublicKey(Kss, o.Index, Ks)
+	return
+}
+
+// MarkSpent records that the output with key image KI was spent in txHash at height
+func (w *Wallet) MarkSpent(KI *crypto.PublicKey, height uint64, txHash crypto.Hash) (err error) {
+	err = w.outputs.MarkSpent(KI, height, txHash)
+	return
+}
+
+// Height returns the restore height of the wallet
+func (w *Wallet) Height() (h uint64) {
+	h = w.height
+	return
+}
+
+// SetHeight sets the restore height of the wallet
+func (w *Wallet) SetHeight(h uint64) {
+	w.height = h
+}
+
+// Label returns the label of subaddress i, or "" if it has none
+func (w *Wallet) Label(i SubAddressIndex) (label string) {
+	label = w.labels[i]
+	return
+}
+
+// SetLabel sets the label of subaddress i
+func (w *Wallet) SetLabel(i SubAddressIndex, label string) {
+	if w.labels == nil {
+		w.labels = make(map[SubAddressIndex]string)
+	}
+	w.labels[i] = label
+}
+
+// ScanOutputForStandardAddress used to recognize output outputIndex of a transaction sent to the standard address
+// viewTag is nil for outputs created before the view tag hardfork
+func (w *Wallet) ScanOutputForStandardAddress(Ko, Ke *crypto.PublicKey, outputIndex uint64, viewTag *byte) (r int) {
 	//kv * Ke * 8 = Kss = random scalar * public view key = shared secret
-	Kss := w.kv.MultPoint(Ke).MultByCofactor()
+	Kss := crypto.GenerateKeyDerivation(Ke, w.kv)
 
-	//Check if Ks = Ko - Hs(Kss) * G to recognize output
-	Ks := Ko.Subtract(Kss.HashToScalar().MultG())
+	//the view tag rejects most outputs of other wallets with a single hash
+	if viewTag != nil && Kss.ViewTag(outputIndex) != *viewTag {
+		return
+	}
+
+	//Check if Ks = Ko - Hs(Kss || varint(outputIndex)) * G to recognize output
+	Ks := crypto.DeriveSubaddressPublicKey(Ko, Kss, outputIndex)
 
 	return Ks.Equal(w.address.Ks)
 }
 
-func (w *Wallet) StandardAddressOneTimeAddressPrivateKey(Ke *crypto.PublicKey) (ko *crypto.PrivateKey) {
+func (w *Wallet) StandardAddressOneTimeAddressPrivateKey(Ke *crypto.PublicKey, outputIndex uint64) (ko *crypto.PrivateKey, err error) {
 	//todo consider using a less wordy function name
+	if w.IsViewOnly() {
+		err = err_msg.ErrWatchOnly
+		return
+	}
 
 	//kv * Ke * 8 = Kss = random scalar * public view key = shared secret
-	Kss := w.kv.MultPoint(Ke).MultByCofactor()
+	Kss := crypto.GenerateKeyDerivation(Ke, w.kv)
 
-	//One time address private spend key = Hs(Kss) + ks
-	ko = Kss.HashToScalar().Add(w.ks)
+	//One time address private spend key = Hs(Kss || varint(outputIndex)) + ks
+	ko = crypto.DeriveSecretKey(Kss, outputIndex, w.ks)
 	return
 }
 
@@ -87,7 +244,7 @@ func (w *Wallet) SubAddress(i SubAddressIndex) (A *address.Subaddress) {
 	Kvi := Ksi.ScalarMult(w.kv)
 
 	A = &address.Subaddress{
-		Network: address.MainNetworkSubAddress,
+		Network: address.SubAddressNetwork(w.address.Network),
 		Kvi:     Kvi,
 		Ksi:     Ksi,
 	}
@@ -100,18 +257,24 @@ func (w *Wallet) SubAddressPublicSpendKey(i SubAddressIndex) (Ksi *crypto.Public
 	// ksi = private spend key for SubAddress i
 	// Ks = public spend key
 
-	// ksi = ks + Hs("SubAddr\0" || kv || index_major || index_minor)
-	ksi := w.SubAddressPrivateSpendKey(i)
-
-	// Ksi = ksi * G
-	Ksi = ksi.MultG()
+	// Ksi = Ks + Hs("SubAddr\0" || kv || index_major || index_minor) * G, which does not need ks
+	Ksi = w.address.Ks.Add(w.subAddressSecret(i).MultG())
 
 	return
 }
 
-func (w *Wallet) SubAddressPrivateSpendKey(i SubAddressIndex) (ksi *crypto.PrivateKey) {
+func (w *Wallet) SubAddressPrivateSpendKey(i SubAddressIndex) (ksi *crypto.PrivateKey, err error) {
+	if w.IsViewOnly() {
+		err = err_msg.ErrWatchOnly
+		return
+	}
 	// ksi = ks + Hs("SubAddr\x00" || kv || index_major || index_minor)
+	ksi = w.ks.Add(w.subAddressSecret(i))
+	return
+}
 
+// subAddressSecret returns Hs("SubAddr\x00" || kv || index_major || index_minor)
+func (w *Wallet) subAddressSecret(i SubAddressIndex) (m *crypto.Scalar) {
 	data := []byte("SubAddr\x00")
 
 	data = append(data, w.kv.Bytes()...)
@@ -123,50 +286,184 @@ func (w *Wallet) SubAddressPrivateSpendKey(i SubAddressIndex) (ksi *crypto.Priva
 	binary.LittleEndian.PutUint32(index[0:], i.Minor)
 	data = append(data, index...)
 
-	ksi = w.ks.Add(crypto.HashToScalar(data))
+	m = crypto.HashToScalar(data)
 	return
 }
 
+// InitializeSubAddressLookup builds the subaddress lookup table for MajorMax accounts of MinorMax subaddresses
+// and uses the same numbers as lookahead, the table grows when outputs to the last subaddresses are found
 func (w *Wallet) InitializeSubAddressLookup(MajorMax, MinorMax uint32) {
-	var i, j uint32
-	w.subAddressLookup = make(map[[32]byte]SubAddressIndex, i*j)
-	for i = 0; i < MajorMax; i++ {
-		for j = 0; j < MinorMax; j++ {
-			PubKey := w.SubAddressPublicSpendKey(SubAddressIndex{i, j}).Byte32()
-			w.subAddressLookup[PubKey] = SubAddressIndex{i, j}
+	w.lookupMu.Lock()
+	defer w.lookupMu.Unlock()
+	w.lookaheadMajor, w.lookaheadMinor = MajorMax, MinorMax
+	w.lookupSizes = nil
+	w.subAddressLookup = make(map[[32]byte]SubAddressIndex, int(MajorMax)*int(MinorMax))
+	for major := uint32(0); major < MajorMax; major++ {
+		w.growSubAddressLookup(major, MinorMax)
+	}
+	w.expandCreatedSubAddresses()
+}
+
+// SetSubAddressLookahead sets how many accounts and subaddresses past the highest used ones are kept in the lookup
+// table (set_subaddress_lookahead in the reference wallet)
+func (w *Wallet) SetSubAddressLookahead(major, minor uint32) {
+	w.lookupMu.Lock()
+	defer w.lookupMu.Unlock()
+	w.lookaheadMajor, w.lookaheadMinor = major, minor
+	w.expandCreatedSubAddresses()
+}
+
+// SubAddressLookahead returns the lookahead of the subaddress lookup table
+func (w *Wallet) SubAddressLookahead() (major, minor uint32) {
+	w.lookupMu.RLock()
+	defer w.lookupMu.RUnlock()
+	major, minor = w.lookaheadMajor, w.lookaheadMinor
+	return
+}
+
+// growSubAddressLookup adds the subaddresses of account major up to minorEnd to the lookup table, the caller must
+// hold lookupMu
+func (w *Wallet) growSubAddressLookup(major, minorEnd uint32) {
+	if w.subAddressLookup == nil {
+		w.subAddressLookup = make(map[[32]byte]SubAddressIndex)
+	}
+	for uint32(len(w.lookupSizes)) <= major {
+		w.lookupSizes = append(w.lookupSizes, 0)
+	}
+	// only the subaddresses which are not in the table yet
+	for minor := w.lookupSizes[major]; minor < minorEnd; minor++ {
+		i := SubAddressIndex{major, minor}
+		w.subAddressLookup[w.SubAddressPublicSpendKey(i).Byte32()] = i
+	}
+	if minorEnd > w.lookupSizes[major] {
+		w.lookupSizes[major] = minorEnd
+	}
+}
+
+// subAddressLookupEnds returns the number of accounts and of subaddresses of account i.Major the lookup table must
+// hold to keep the lookahead past subaddress i, the caller must hold lookupMu
+// (get_subaddress_clamped_sum in the reference wallet)
+func (w *Wallet) subAddressLookupEnds(i SubAddressIndex) (majorEnd, minorEnd uint32) {
+	// the table always holds i itself, even without lookahead
+	end := func(index, lookahead uint32) uint32 {
+		if lookahead == 0 {
+			lookahead = 1
+		}
+		if index > math.MaxUint32-lookahead {
+			return math.MaxUint32
+		}
+		return index + lookahead
+	}
+	majorEnd, minorEnd = end(i.Major, w.lookaheadMajor), end(i.Minor, w.lookaheadMinor)
+	return
+}
+
+// expandSubAddressLookup keeps the lookahead past subaddress i, which an output was received to, as
+// expand_subaddresses in the reference wallet: the table holds the accounts up to i.Major + lookahead, the accounts
+// it adds get the minor lookahead of subaddresses, and account i.Major the subaddresses up to i.Minor + lookahead
+func (w *Wallet) expandSubAddressLookup(i SubAddressIndex) {
+	w.lookupMu.RLock()
+	majorEnd, minorEnd := w.subAddressLookupEnds(i)
+	expand := uint32(len(w.lookupSizes)) < majorEnd || w.lookupSizes[i.Major] < minorEnd
+	w.lookupMu.RUnlock()
+	if !expand {
+		return
+	}
+	w.lookupMu.Lock()
+	defer w.lookupMu.Unlock()
+	w.expandSubAddressLookupLocked(i)
+}
+
+// expandSubAddressLookupLocked is expandSubAddressLookup for a caller holding lookupMu
+func (w *Wallet) expandSubAddressLookupLocked(i SubAddressIndex) {
+	majorEnd, minorEnd := w.subAddressLookupEnds(i)
+	_, accountEnd := w.subAddressLookupEnds(SubAddressIndex{})
+	for major := uint32(len(w.lookupSizes)); major < majorEnd; major++ {
+		w.growSubAddressLookup(major, accountEnd)
+	}
+	w.growSubAddressLookup(i.Major, minorEnd)
+}
+
+// expandCreatedSubAddresses keeps the lookahead past the last subaddress created in each account, the caller must
+// hold lookupMu
+func (w *Wallet) expandCreatedSubAddresses() {
+	for major, n := range w.numSubAddresses() {
+		if n > 0 {
+			w.expandSubAddressLookupLocked(SubAddressIndex{uint32(major), n - 1})
 		}
 	}
 }
 
 func (w *Wallet) SubAddressLookup(Ksi *crypto.PublicKey) (i SubAddressIndex, ok bool) {
+	w.lookupMu.RLock()
+	defer w.lookupMu.RUnlock()
 	i, ok = w.subAddressLookup[Ksi.Byte32()]
 	return
 }
 
-// ScanOutputForSubAddress used to recognize outputs sent to a subaddress and return the subaddress index
-func (w *Wallet) ScanOutputForSubAddress(Ko *crypto.PublicKey, Ke *crypto.PublicKey) (i SubAddressIndex, ok bool) {
+// ScanOutputForSubAddress used to recognize output outputIndex of a transaction sent to a subaddress and return the subaddress index
+// viewTag is nil for outputs created before the view tag hardfork
+func (w *Wallet) ScanOutputForSubAddress(Ko *crypto.PublicKey, Ke *crypto.PublicKey, outputIndex uint64, viewTag *byte) (i SubAddressIndex, ok bool) {
 	// Ko = output address (one time address)
 	// Ke = ephemeral key
 	// kv = private view key
-	// Kss = shared secret = kv * Ke
-	// Ksi = calculated subaddress public spend key = Ko - Hs(Kss)*G
+	// Kss = shared secret = kv * Ke * 8
+	// Ksi = calculated subaddress public spend key = Ko - Hs(Kss || varint(outputIndex))*G
 
-	Kss := Ke.ScalarMult(w.kv)
-	Ksi := Ko.Subtract(Kss.HashToScalar().MultG())
-	return w.SubAddressLookup(Ksi)
+	Kss := crypto.GenerateKeyDerivation(Ke, w.kv)
+	if viewTag != nil && Kss.ViewTag(outputIndex) != *viewTag {
+		return
+	}
+	Ksi := crypto.DeriveSubaddressPublicKey(Ko, Kss, outputIndex)
+	if i, ok = w.SubAddressLookup(Ksi); ok {
+		w.expandSubAddressLookup(i)
+	}
+	return
 
 }
 
-func (w *Wallet) SubaddressOutputPrivateKey(Ke *crypto.PublicKey, i SubAddressIndex) (ko *crypto.PrivateKey) {
+// ScanOutput used to recognize output outputIndex of a transaction with public key Ke and additional public keys
+// additionalKeys (nil if the transaction has none), it returns the subaddress index, SubAddressIndex{0, 0} for the
+// standard address, and the transaction public key the one time address was derived from
+//
+// cryptonote_format_utils.cpp is_out_to_acc_precomp
+func (w *Wallet) ScanOutput(Ko, Ke *crypto.PublicKey, additionalKeys []*crypto.PublicKey, outputIndex uint64, viewTag *byte) (i SubAddressIndex, txPublicKey *crypto.PublicKey, ok bool) {
+	candidates := []*crypto.PublicKey{Ke}
+	if outputIndex < uint64(len(additionalKeys)) {
+		candidates = append(candidates, additionalKeys[outputIndex])
+	}
+	for _, K := range candidates {
+		Kss := crypto.GenerateKeyDerivation(K, w.kv)
+		if viewTag != nil && Kss.ViewTag(outputIndex) != *viewTag {
+			continue
+		}
+		Ksi := crypto.DeriveSubaddressPublicKey(Ko, Kss, outputIndex)
+		if Ksi.Equal(w.address.Ks) == 1 {
+			i, txPublicKey, ok = SubAddressIndex{}, K, true
+			return
+		}
+		if i, ok = w.SubAddressLookup(Ksi); ok {
+			txPublicKey = K
+			w.expandSubAddressLookup(i)
+			return
+		}
+	}
+	return
+}
+
+func (w *Wallet) SubaddressOutputPrivateKey(Ke *crypto.PublicKey, outputIndex uint64, i SubAddressIndex) (ko *crypto.PrivateKey, err error) {
 	// Ke = ephemeral key (txPublicKey)
-	// Kss = kv * Ke
+	// Kss = kv * Ke * 8
 	// ksi = subaddress private spend key = Hs("SubAddr\x00" || kv || index_major || index_minor)
-	// ko = output private key = Hs(Kss) + ksi
+	// ko = output private key = Hs(Kss || varint(outputIndex)) + ksi
 
-	Kss := Ke.ScalarMult(w.kv)
-	ksi := w.SubAddressPrivateSpendKey(i)
+	Kss := crypto.GenerateKeyDerivation(Ke, w.kv)
+	ksi, err := w.SubAddressPrivateSpendKey(i)
+	if err != nil {
+		return
+	}
 
-	ko = Kss.HashToScalar().Add(ksi)
+	ko = crypto.DeriveSecretKey(Kss, outputIndex, ksi)
 
 	return
 
@@ -17,9 +17,9 @@ func TestSubAddressIndexFromOutput(t *testing.T) {
 
 		want := SubAddressIndex{Major: major, Minor: minor}
 
-		output, txPublicKey, _ := currentWallet.SubAddress(want).OneTimeAddress()
+		output, txPublicKey, viewTag, _ := currentWallet.SubAddress(want).OneTimeAddress(uint64(i))
 
-		got, ok := currentWallet.ScanOutputForSubAddress(output, txPublicKey)
+		got, ok := currentWallet.ScanOutputForSubAddress(output, txPublicKey, uint64(i), &viewTag)
 
 		if want != got || !ok {
 			t.Errorf("SubAddress not found. want: %v got: %v", want, got)
@@ -38,9 +38,9 @@ func TestPrivateOutputKey(t *testing.T) {
 
 		index := SubAddressIndex{Major: major, Minor: minor}
 
-		want, txPublicKey, _ := currentWallet.SubAddress(index).OneTimeAddress()
+		want, txPublicKey, _, _ := currentWallet.SubAddress(index).OneTimeAddress(uint64(i))
 
-		p := currentWallet.SubaddressOutputPrivateKey(txPublicKey, index)
+		p, _ := currentWallet.SubaddressOutputPrivateKey(txPublicKey, uint64(i), index)
 
 		got := p.MultG()
 
@@ -121,3 +121,173 @@ func BenchmarkWallet_SubAddress(b *testing.B) {
 		w.SubAddress(i)
 	}
 }
+
+func TestScanOutputForStandardAddress(t *testing.T) {
+	w := NewWallet()
+	for i := uint64(0); i < 16; i++ {
+		Ko, Ke, viewTag, err := w.address.OneTimeAddress(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.ScanOutputForStandardAddress(Ko, Ke, i, &viewTag) != 1 {
+			t.Errorf("output %v not recognized", i)
+		}
+		if w.ScanOutputForStandardAddress(Ko, Ke, i, nil) != 1 {
+			t.Errorf("output %v without view tag not recognized", i)
+		}
+		if w.ScanOutputForStandardAddress(Ko, Ke, i+1, nil) != 0 {
+			t.Errorf("output %v recognized with the wrong output index", i)
+		}
+		wrongTag := viewTag + 1
+		if w.ScanOutputForStandardAddress(Ko, Ke, i, &wrongTag) != 0 {
+			t.Errorf("output %v recognized with the wrong view tag", i)
+		}
+		if ko, _ := w.StandardAddressOneTimeAddressPrivateKey(Ke, i); ko.MultG().Equal(Ko) == 0 {
+			t.Errorf("output %v: wrong private key", i)
+		}
+	}
+}
+
+// benchmarks scanning outputs which do not belong to the wallet
+func BenchmarkScanOutputForSubAddress(b *testing.B) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(5, 20)
+	Ko, Ke, viewTag, _ := NewWallet().SubAddress(SubAddressIndex{0, 1}).OneTimeAddress(0)
+	b.Run("view tag", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			w.ScanOutputForSubAddress(Ko, Ke, 0, &viewTag)
+		}
+	})
+	b.Run("no view tag", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			w.ScanOutputForSubAddress(Ko, Ke, 0, nil)
+		}
+	})
+}
+
+func TestScanOutputWithAdditionalKeys(t *testing.T) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(2, 5)
+	other := NewWallet()
+	standard := w.address
+
+	tests := []struct {
+		name         string
+		destinations []address.Destination
+		want         []SubAddressIndex //subaddress index of each output, ignored for outputs to other
+		additional   bool
+	}{
+		{"single subaddress", []address.Destination{w.SubAddress(SubAddressIndex{1, 2})}, []SubAddressIndex{{1, 2}}, false},
+		{"standard addresses", []address.Destination{&standard, &other.address}, []SubAddressIndex{{}, {}}, false},
+		{"subaddress and standard address", []address.Destination{&other.address, w.SubAddress(SubAddressIndex{0, 4})}, []SubAddressIndex{{}, {0, 4}}, true},
+		{"two subaddresses", []address.Destination{w.SubAddress(SubAddressIndex{0, 1}), w.SubAddress(SubAddressIndex{1, 3}), &standard}, []SubAddressIndex{{0, 1}, {1, 3}, {}}, true},
+	}
+	for _, tt := range tests {
+		keys, err := address.NewTxKeys(tt.destinations, -1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (keys.AdditionalKeys != nil) != tt.additional {
+			t.Errorf("%s: want additional keys %v, got %v", tt.name, tt.additional, keys.AdditionalKeys != nil)
+		}
+		for n, o := range keys.Outputs {
+			outputIndex := uint64(n)
+			mine := tt.destinations[n] != address.Destination(&other.address)
+			i, txPublicKey, ok := w.ScanOutput(o.Ko, keys.R, keys.AdditionalKeys, outputIndex, &o.ViewTag)
+			if ok != mine {
+				t.Errorf("%s: output %v: want recognized %v, got %v", tt.name, n, mine, ok)
+				continue
+			}
+			if !mine {
+				continue
+			}
+			if i != tt.want[n] {
+				t.Errorf("%s: output %v: want %v, got %v", tt.name, n, tt.want[n], i)
+			}
+			var ko *crypto.PrivateKey
+			if i == (SubAddressIndex{}) {
+				ko, _ = w.StandardAddressOneTimeAddressPrivateKey(txPublicKey, outputIndex)
+			} else {
+				ko, _ = w.SubaddressOutputPrivateKey(txPublicKey, outputIndex, i)
+			}
+			if ko.MultG().Equal(o.Ko) == 0 {
+				t.Errorf("%s: output %v: wrong private key", tt.name, n)
+			}
+		}
+	}
+}
+
+func TestSubAddressLookahead(t *testing.T) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(2, 3)
+	scan := func(i SubAddressIndex) (ok bool) {
+		Ko, Ke, viewTag, _ := w.SubAddress(i).OneTimeAddress(0)
+		_, ok = w.ScanOutputForSubAddress(Ko, Ke, 0, &viewTag)
+		return
+	}
+	if scan(SubAddressIndex{2, 4}) {
+		t.Fatalf("subaddress outside the lookahead recognized")
+	}
+	if !scan(SubAddressIndex{1, 2}) {
+		t.Fatalf("subaddress inside the lookahead not recognized")
+	}
+	// like expand_subaddresses the table grows per account: account 1 to 5 subaddresses and a new account 2 of 3
+	if w.lookupSizes == nil || len(w.lookupSizes) != 3 || w.lookupSizes[0] != 3 || w.lookupSizes[1] != 5 || w.lookupSizes[2] != 3 {
+		t.Fatalf("want table sizes [3 5 3], got %v", w.lookupSizes)
+	}
+	if len(w.subAddressLookup) != 3+5+3 {
+		t.Errorf("want %v table entries, got %v", 3+5+3, len(w.subAddressLookup))
+	}
+	if !scan(SubAddressIndex{1, 4}) || !scan(SubAddressIndex{2, 2}) {
+		t.Errorf("table did not grow past the used subaddress")
+	}
+	if scan(SubAddressIndex{0, 4}) {
+		t.Errorf("table grew in an account without used subaddresses")
+	}
+	if scan(SubAddressIndex{2, 5}) {
+		t.Errorf("subaddress outside the lookahead recognized")
+	}
+}
+
+func TestSubAddressLookaheadDefault(t *testing.T) {
+	kv, ks := crypto.NewRandomScalar(), crypto.NewRandomScalar()
+	wallets := []*Wallet{NewWallet(), new(Wallet).FromKeys(kv, ks), NewViewOnlyWallet(kv, ks.PublicKey(), address.MainNetwork)}
+	for _, w := range wallets {
+		if major, minor := w.SubAddressLookahead(); major != DefaultLookaheadMajor || minor != DefaultLookaheadMinor {
+			t.Errorf("want lookahead %v %v, got %v %v", DefaultLookaheadMajor, DefaultLookaheadMinor, major, minor)
+		}
+		if _, ok := w.SubAddressLookup(w.SubAddressPublicSpendKey(SubAddressIndex{DefaultLookaheadMajor - 1, DefaultLookaheadMinor - 1})); !ok {
+			t.Errorf("last subaddress of the default lookahead not in the lookup table")
+		}
+	}
+}
+
+func TestSubAddressLookupConcurrent(t *testing.T) {
+	w := NewWallet()
+	w.InitializeSubAddressLookup(1, 2)
+	type output struct {
+		Ko, Ke  *crypto.PublicKey
+		viewTag byte
+	}
+	var outputs []output
+	for j := uint32(1); j < 20; j++ {
+		Ko, Ke, viewTag, _ := w.SubAddress(SubAddressIndex{0, j}).OneTimeAddress(0)
+		outputs = append(outputs, output{Ko, Ke, viewTag})
+	}
+	done := make(chan bool)
+	for n := 0; n < 4; n++ {
+		go func() {
+			// outputs are found in order, each one extends the table to the next
+			for _, o := range outputs {
+				w.ScanOutputForSubAddress(o.Ko, o.Ke, 0, &o.viewTag)
+			}
+			done <- true
+		}()
+	}
+	for n := 0; n < 4; n++ {
+		<-done
+	}
+	if len(w.lookupSizes) != 1 || w.lookupSizes[0] != 21 {
+		t.Errorf("want 21 subaddresses, got %v", w.lookupSizes)
+	}
+}